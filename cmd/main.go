@@ -2,10 +2,15 @@ package main
 
 import (
 	database "api-sample-with-echo-ddd/config"
+	"api-sample-with-echo-ddd/domain/model"
 	"api-sample-with-echo-ddd/infra"
 	router "api-sample-with-echo-ddd/interface"
 	"api-sample-with-echo-ddd/interface/handler"
+	"api-sample-with-echo-ddd/interface/middleware"
 	"api-sample-with-echo-ddd/usecase"
+	"context"
+	"fmt"
+	"os"
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo"
@@ -17,15 +22,186 @@ func main() {
 		panic("failed to load .env file")
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// エンティティのIDは全て同じ生成器で採番し、作成時刻順に並ぶようにする
+	ids := model.NewUUIDv7Generator()
+	userFactory, err := newUserFactory(ids)
+	if err != nil {
+		panic(err)
+	}
+
 	config := database.NewConfig()
 	db := database.NewDB(config)
 	e := echo.New()
+	e.HTTPErrorHandler = handler.HTTPErrorHandler
+
+	// auth
+	authConfig := database.NewAuthConfig()
+	signingKey, verifyingKey := authConfig.PrivateKey, authConfig.PublicKey
+	if authConfig.Algorithm == infra.JWTAlgorithmHS256 {
+		signingKey, verifyingKey = []byte(authConfig.Secret), []byte(authConfig.Secret)
+	}
+	tokenIssuer, err := infra.NewJWTIssuer(infra.JWTConfig{
+		Algorithm:    authConfig.Algorithm,
+		SigningKey:   signingKey,
+		VerifyingKey: verifyingKey,
+		Issuer:       authConfig.Issuer,
+		TTL:          authConfig.AccessTokenTTL,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	// mail
+	mailConfig := database.NewMailConfig()
+	var mailer usecase.Mailer
+	switch mailConfig.Driver {
+	case "smtp":
+		mailer = infra.NewSMTPMailer(infra.SMTPConfig{
+			Host:     mailConfig.SMTPHost,
+			Port:     mailConfig.SMTPPort,
+			Username: mailConfig.SMTPUsername,
+			Password: mailConfig.SMTPPassword,
+			From:     mailConfig.From,
+		})
+	case "file":
+		mailer, err = infra.NewFileMailer(mailConfig.FilePath, mailConfig.From)
+		if err != nil {
+			panic(err)
+		}
+	case "stdout":
+		mailer = infra.NewWriterMailer(os.Stdout, mailConfig.From)
+	default:
+		panic("unknown MAIL_DRIVER: " + mailConfig.Driver)
+	}
+	tokenSigner, err := infra.NewHMACTokenSigner([]byte(authConfig.TokenSigningSecret))
+	if err != nil {
+		panic(err)
+	}
+
+	// 権限が無いため拒否した操作は標準エラー出力に記録する
+	accessPolicy := usecase.NewAccessPolicy(infra.NewLogAccessDenialRecorder(os.Stderr))
 
 	// user
-	userRepo := infra.NewUserRepository(db)
-	userUsecase := usecase.NewUserUsecase(userRepo)
+	txManager := infra.NewTransactionManager(db)
+	userRepo := infra.NewUserRepository(db, ids, userFactory)
+	auditLogRepo := infra.NewAuditLogRepository(db)
+	oneTimeTokenRepo := infra.NewOneTimeTokenRepository(db)
+	mailQueue := usecase.NewMailQueue(mailConfig.QueueSize)
+	mailRateLimiter, err := infra.NewMemoryRateLimiter(mailConfig.RateLimit, mailConfig.RateLimitWindow)
+	if err != nil {
+		panic(err)
+	}
+	emailVerificationUsecase := usecase.NewEmailVerificationUsecase(userRepo, oneTimeTokenRepo, auditLogRepo, txManager, tokenSigner, mailer, mailQueue, mailRateLimiter, userFactory, ids, usecase.EmailVerificationConfig{
+		TTL: authConfig.EmailVerificationTTL,
+		URL: authConfig.EmailVerificationURL,
+	})
+	emailVerificationHandler := handler.NewEmailVerificationHandler(emailVerificationUsecase)
+	userUsecase := usecase.NewUserUsecase(userRepo, auditLogRepo, txManager, emailVerificationUsecase, accessPolicy, userFactory, ids)
 	userHandler := handler.NewUserHandler(userUsecase)
-	router.InitRouting(e, userHandler)
+	auditLogHandler := handler.NewAuditLogHandler(usecase.NewAuditLogUsecase(auditLogRepo))
+	webhookSubscriptionRepo := infra.NewWebhookSubscriptionRepository(db)
+	webhookDeliveryRepo := infra.NewWebhookDeliveryRepository(db)
+	webhookHandler := handler.NewWebhookHandler(usecase.NewWebhookUsecase(webhookSubscriptionRepo, webhookDeliveryRepo, ids))
+
+	refreshTokenRepo := infra.NewRefreshTokenRepository(db)
+	authUsecase := usecase.NewAuthUsecase(userRepo, refreshTokenRepo, tokenIssuer, userFactory, ids, authConfig.RefreshTokenTTL)
+	authHandler := handler.NewAuthHandler(authUsecase)
+	passwordResetUsecase := usecase.NewPasswordResetUsecase(userRepo, oneTimeTokenRepo, refreshTokenRepo, auditLogRepo, txManager, tokenSigner, mailer, mailQueue, mailRateLimiter, userFactory, ids, usecase.PasswordResetConfig{
+		TTL: authConfig.PasswordResetTTL,
+		URL: authConfig.PasswordResetURL,
+	})
+	passwordResetHandler := handler.NewPasswordResetHandler(passwordResetUsecase)
+
+	serverConfig := database.NewServerConfig()
+	timeouts := middleware.RouteTimeouts{Default: serverConfig.RequestTimeout, Routes: serverConfig.RouteTimeouts}
+	router.InitRouting(e, userHandler, authHandler, emailVerificationHandler, passwordResetHandler, auditLogHandler, webhookHandler, tokenIssuer, usecase.NewAccountStatusChecker(userRepo), accessPolicy, serverConfig.TrustedProxies, timeouts, serverConfig.RequireIfMatch)
+
+	go mailQueue.Run(context.Background())
+
+	sweeper := usecase.NewRefreshTokenSweeper(refreshTokenRepo, authConfig.RefreshTokenSweepInterval)
+	go sweeper.Run(context.Background())
+
+	userConfig := database.NewUserConfig()
+	purger := usecase.NewUserPurger(userRepo, userConfig.DeletedRetention, userConfig.PurgeInterval)
+	go purger.Run(context.Background())
+
+	// outbox
+	outboxConfig := database.NewOutboxConfig()
+	var publishers []usecase.EventPublisher
+	for _, name := range outboxConfig.Publishers {
+		switch name {
+		case "log":
+			publishers = append(publishers, infra.NewLogEventPublisher(os.Stdout))
+		case "webhook":
+			publishers = append(publishers, usecase.NewWebhookEventPublisher(webhookSubscriptionRepo, webhookDeliveryRepo, ids))
+		default:
+			panic("unknown OUTBOX_PUBLISHERS: " + name)
+		}
+	}
+	relay := usecase.NewOutboxRelay(infra.NewOutboxRepository(db), usecase.OutboxRelayConfig{
+		BatchSize:      outboxConfig.BatchSize,
+		Interval:       outboxConfig.RelayInterval,
+		RetryBaseDelay: outboxConfig.RetryBaseDelay,
+		RetryMaxDelay:  outboxConfig.RetryMaxDelay,
+		Retention:      outboxConfig.Retention,
+	}, publishers...)
+	go relay.Run(context.Background())
+
+	// webhook
+	webhookConfig := database.NewWebhookConfig()
+	dispatcher := usecase.NewWebhookDispatcher(webhookSubscriptionRepo, webhookDeliveryRepo, infra.NewHTTPWebhookSender(webhookConfig.Timeout), ids, usecase.WebhookDispatcherConfig{
+		BatchSize:            webhookConfig.BatchSize,
+		Interval:             webhookConfig.DispatchInterval,
+		RetryBaseDelay:       webhookConfig.RetryBaseDelay,
+		RetryMaxDelay:        webhookConfig.RetryMaxDelay,
+		MaxAttempts:          webhookConfig.MaxAttempts,
+		DisableAfterFailures: webhookConfig.DisableAfterFailures,
+	})
+	go dispatcher.Run(context.Background())
 
 	e.Logger.Fatal(e.Start(":8080"))
 }
+
+// newUserFactory 環境変数のメールアドレスとパスワードの設定からUserFactoryを作成する。
+// マイグレーションでもメールアドレスの正規化に同じ設定を使う
+func newUserFactory(ids model.IDGenerator) (*model.UserFactory, error) {
+	emailConfig := database.NewEmailConfig()
+	passwordConfig, err := database.NewPasswordConfig()
+	if err != nil {
+		return nil, err
+	}
+	passwordPolicy := model.PasswordPolicy{
+		MinLength:      passwordConfig.MinLength,
+		MaxLength:      passwordConfig.MaxLength,
+		ForbiddenWords: passwordConfig.ForbiddenWords,
+		Algorithm:      model.PasswordAlgorithm(passwordConfig.HashAlgorithm),
+		Cost:           passwordConfig.BcryptCost,
+		Argon2:         model.DefaultArgon2Params(),
+	}
+	passwordPolicy.Argon2.Memory = passwordConfig.Argon2Memory
+	passwordPolicy.Argon2.Iterations = passwordConfig.Argon2Iterations
+	passwordPolicy.Argon2.Parallelism = passwordConfig.Argon2Parallelism
+	for _, class := range passwordConfig.RequiredClasses {
+		passwordPolicy.RequiredClasses = append(passwordPolicy.RequiredClasses, model.CharacterClass(class))
+	}
+	if passwordConfig.BreachedPasswordFile != "" {
+		breached, err := infra.LoadBreachedPasswordList(passwordConfig.BreachedPasswordFile)
+		if err != nil {
+			return nil, err
+		}
+		passwordPolicy.BreachedPasswords = breached
+	}
+
+	return model.NewUserFactory(ids, model.EmailPolicy{
+		CanonicalizePlusAddress: emailConfig.CanonicalizePlusAddress,
+		DisposableDomains:       emailConfig.DisposableDomains,
+	}, passwordPolicy)
+}
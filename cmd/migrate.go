@@ -0,0 +1,118 @@
+package main
+
+import (
+	database "api-sample-with-echo-ddd/config"
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/infra"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = `usage: main migrate <command>
+
+commands:
+  up             未適用のマイグレーションを全て適用し、メールアドレスの正規化の設定を反映する
+  down [N]       最後に適用したものからN件(既定は1件)取り消す
+  status         マイグレーションの適用状況を表示する
+  redo           最後に適用したマイグレーションを取り消して再度適用する
+  create <name>  次のバージョンのup/downファイルを作成する
+  unlock         異常終了したプロセスが残したロックを解放する`
+
+// runMigrate migrateサブコマンドを実行する
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		paths, err := infra.CreateMigration(infra.MigrationsDir, args[1])
+		for _, path := range paths {
+			fmt.Println("created", path)
+		}
+		return err
+	}
+
+	factory, err := newUserFactory(model.NewUUIDv7Generator())
+	if err != nil {
+		return err
+	}
+	migrator, err := infra.NewMigrator(database.NewDB(database.NewConfig()), factory)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		printMigrations("applied", applied)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return errors.New(migrateUsage)
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		printMigrations("reverted", reverted)
+		return err
+	case "redo":
+		redone, err := migrator.Redo(ctx)
+		if redone != nil {
+			printMigrations("redone", []infra.Migration{*redone})
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printStatuses(statuses)
+		return nil
+	case "unlock":
+		if err := migrator.Unlock(ctx); err != nil {
+			return err
+		}
+		fmt.Println("released migration lock")
+		return nil
+	}
+	return errors.New(migrateUsage)
+}
+
+func printMigrations(action string, migrations []infra.Migration) {
+	if len(migrations) == 0 {
+		fmt.Println("no migrations", action)
+	}
+	for _, m := range migrations {
+		fmt.Printf("%s %04d_%s\n", action, m.Version, m.Name)
+	}
+}
+
+func printStatuses(statuses []infra.MigrationStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		status, appliedAt := "pending", ""
+		if s.AppliedAt != nil {
+			status, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+		}
+		if s.Dirty {
+			status = "modified"
+		}
+		if s.Missing {
+			status = "missing"
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+	}
+	w.Flush()
+}
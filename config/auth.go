@@ -0,0 +1,72 @@
+package database
+
+import (
+	"os"
+	"time"
+)
+
+type AuthConfig struct {
+	Algorithm                 string
+	Secret                    string
+	PrivateKey                []byte
+	PublicKey                 []byte
+	Issuer                    string
+	AccessTokenTTL            time.Duration
+	RefreshTokenTTL           time.Duration
+	RefreshTokenSweepInterval time.Duration
+	TokenSigningSecret        string
+	EmailVerificationTTL      time.Duration
+	EmailVerificationURL      string
+	PasswordResetTTL          time.Duration
+	PasswordResetURL          string
+}
+
+func NewAuthConfig() AuthConfig {
+	config := AuthConfig{
+		Algorithm:                 os.Getenv("JWT_ALGORITHM"),
+		Secret:                    os.Getenv("JWT_SECRET"),
+		Issuer:                    os.Getenv("JWT_ISSUER"),
+		AccessTokenTTL:            15 * time.Minute,
+		RefreshTokenTTL:           30 * 24 * time.Hour,
+		RefreshTokenSweepInterval: time.Hour,
+		TokenSigningSecret:        os.Getenv("TOKEN_SIGNING_SECRET"),
+		EmailVerificationTTL:      24 * time.Hour,
+		EmailVerificationURL:      os.Getenv("EMAIL_VERIFICATION_URL"),
+		PasswordResetTTL:          time.Hour,
+		PasswordResetURL:          os.Getenv("PASSWORD_RESET_URL"),
+	}
+	if config.Algorithm == "" {
+		config.Algorithm = "HS256"
+	}
+	for key, target := range map[string]*time.Duration{
+		"JWT_ACCESS_TOKEN_TTL":         &config.AccessTokenTTL,
+		"REFRESH_TOKEN_TTL":            &config.RefreshTokenTTL,
+		"REFRESH_TOKEN_SWEEP_INTERVAL": &config.RefreshTokenSweepInterval,
+		"EMAIL_VERIFICATION_TTL":       &config.EmailVerificationTTL,
+		"PASSWORD_RESET_TTL":           &config.PasswordResetTTL,
+	} {
+		if value := os.Getenv(key); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				panic("failed to parse " + key)
+			}
+			*target = d
+		}
+	}
+	if path := os.Getenv("JWT_PRIVATE_KEY_FILE"); path != "" {
+		key, err := os.ReadFile(path)
+		if err != nil {
+			panic("failed to read JWT_PRIVATE_KEY_FILE")
+		}
+		config.PrivateKey = key
+	}
+	if path := os.Getenv("JWT_PUBLIC_KEY_FILE"); path != "" {
+		key, err := os.ReadFile(path)
+		if err != nil {
+			panic("failed to read JWT_PUBLIC_KEY_FILE")
+		}
+		config.PublicKey = key
+	}
+
+	return config
+}
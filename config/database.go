@@ -2,6 +2,7 @@ package database
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 
 	"gorm.io/driver/mysql"
@@ -9,25 +10,39 @@ import (
 )
 
 type Config struct {
-	Host     string
-	Port     string
-	DBName   string
-	User     string
-	Password string
+	Host      string
+	Port      string
+	DBName    string
+	User      string
+	Password  string
+	Charset   string
+	ParseTime string
+	Loc       string
 }
 
 func NewConfig() Config {
 	return Config{
-		Host:     os.Getenv("DB_HOST"),
-		Port:     os.Getenv("DB_PORT"),
-		DBName:   os.Getenv("DB_NAME"),
-		User:     os.Getenv("DB_USER"),
-		Password: os.Getenv("DB_PASSWORD"),
+		Host:      os.Getenv("DB_HOST"),
+		Port:      os.Getenv("DB_PORT"),
+		DBName:    os.Getenv("DB_NAME"),
+		User:      os.Getenv("DB_USER"),
+		Password:  os.Getenv("DB_PASSWORD"),
+		Charset:   os.Getenv("DB_CHARSET"),
+		ParseTime: os.Getenv("DB_PARSE_TIME"),
+		Loc:       os.Getenv("DB_LOC"),
 	}
 }
 
 func NewDB(config Config) *gorm.DB {
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", config.User, config.Password, config.Host, config.Port, config.DBName)
+	// schema_migrationsなどの日時をtime.Timeとして読み込むためparseTimeを既定で有効にする
+	params := url.Values{"charset": {"utf8mb4"}, "parseTime": {"true"}, "loc": {"Local"}}
+	for key, value := range map[string]string{"charset": config.Charset, "parseTime": config.ParseTime, "loc": config.Loc} {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	dsn += "?" + params.Encode()
 
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
 	if err != nil {
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+type EmailConfig struct {
+	CanonicalizePlusAddress bool
+	DisposableDomains       []string
+}
+
+// NewEmailConfig EMAIL_DISPOSABLE_DOMAINS_FILEは1行に1ドメインを記述したファイルで、空行と"#"以降は無視する
+func NewEmailConfig() EmailConfig {
+	config := EmailConfig{}
+	if value := os.Getenv("EMAIL_CANONICALIZE_PLUS_ADDRESS"); value != "" {
+		canonicalize, err := strconv.ParseBool(value)
+		if err != nil {
+			panic("failed to parse EMAIL_CANONICALIZE_PLUS_ADDRESS")
+		}
+		config.CanonicalizePlusAddress = canonicalize
+	}
+	if path := os.Getenv("EMAIL_DISPOSABLE_DOMAINS_FILE"); path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			panic("failed to read EMAIL_DISPOSABLE_DOMAINS_FILE")
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			line, _, _ = strings.Cut(line, "#")
+			if line = strings.TrimSpace(line); line != "" {
+				config.DisposableDomains = append(config.DisposableDomains, line)
+			}
+		}
+	}
+
+	return config
+}
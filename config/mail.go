@@ -0,0 +1,72 @@
+package database
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+type MailConfig struct {
+	// Driver smtp、file、stdoutのいずれか
+	Driver       string
+	From         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	FilePath     string
+	// QueueSize 送信待ちにできるメールの数
+	QueueSize int
+	// RateLimit 同じメールアドレスに対し、RateLimitWindowの間に受け付ける再送などの回数
+	RateLimit       int
+	RateLimitWindow time.Duration
+}
+
+func NewMailConfig() MailConfig {
+	config := MailConfig{
+		Driver:          os.Getenv("MAIL_DRIVER"),
+		From:            os.Getenv("MAIL_FROM"),
+		SMTPHost:        os.Getenv("SMTP_HOST"),
+		SMTPPort:        587,
+		SMTPUsername:    os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:    os.Getenv("SMTP_PASSWORD"),
+		FilePath:        os.Getenv("MAIL_FILE"),
+		QueueSize:       100,
+		RateLimit:       5,
+		RateLimitWindow: time.Hour,
+	}
+	if config.Driver == "" {
+		config.Driver = "stdout"
+	}
+	if config.From == "" {
+		config.From = "no-reply@example.com"
+	}
+	if value := os.Getenv("SMTP_PORT"); value != "" {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			panic("failed to parse SMTP_PORT")
+		}
+		config.SMTPPort = port
+	}
+	for key, target := range map[string]*int{
+		"MAIL_QUEUE_SIZE": &config.QueueSize,
+		"MAIL_RATE_LIMIT": &config.RateLimit,
+	} {
+		if value := os.Getenv(key); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				panic("failed to parse " + key)
+			}
+			*target = n
+		}
+	}
+	if value := os.Getenv("MAIL_RATE_LIMIT_WINDOW"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			panic("failed to parse MAIL_RATE_LIMIT_WINDOW")
+		}
+		config.RateLimitWindow = d
+	}
+
+	return config
+}
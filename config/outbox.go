@@ -0,0 +1,61 @@
+package database
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type OutboxConfig struct {
+	// Publishers イベントの配信先。logは標準出力に1行に1件のJSONとして書き出し、webhookは購読先への配信を作成する
+	Publishers     []string
+	BatchSize      int
+	RelayInterval  time.Duration
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// Retention 配信したイベントを削除するまでの保持期間
+	Retention time.Duration
+}
+
+func NewOutboxConfig() OutboxConfig {
+	config := OutboxConfig{
+		Publishers:     []string{"log", "webhook"},
+		BatchSize:      100,
+		RelayInterval:  time.Second,
+		RetryBaseDelay: 5 * time.Second,
+		RetryMaxDelay:  time.Hour,
+		Retention:      7 * 24 * time.Hour,
+	}
+	if value, ok := os.LookupEnv("OUTBOX_PUBLISHERS"); ok {
+		config.Publishers = nil
+		for _, publisher := range strings.Split(value, ",") {
+			if publisher = strings.TrimSpace(publisher); publisher != "" {
+				config.Publishers = append(config.Publishers, publisher)
+			}
+		}
+	}
+	if value := os.Getenv("OUTBOX_BATCH_SIZE"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			panic("failed to parse OUTBOX_BATCH_SIZE")
+		}
+		config.BatchSize = n
+	}
+	for key, target := range map[string]*time.Duration{
+		"OUTBOX_RELAY_INTERVAL":   &config.RelayInterval,
+		"OUTBOX_RETRY_BASE_DELAY": &config.RetryBaseDelay,
+		"OUTBOX_RETRY_MAX_DELAY":  &config.RetryMaxDelay,
+		"OUTBOX_RETENTION":        &config.Retention,
+	} {
+		if value := os.Getenv(key); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				panic("failed to parse " + key)
+			}
+			*target = d
+		}
+	}
+
+	return config
+}
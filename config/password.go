@@ -0,0 +1,89 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type PasswordConfig struct {
+	MinLength            int
+	MaxLength            int
+	RequiredClasses      []string
+	ForbiddenWords       []string
+	BreachedPasswordFile string
+	HashAlgorithm        string
+	BcryptCost           int
+	Argon2Memory         uint32
+	Argon2Iterations     uint32
+	Argon2Parallelism    uint8
+}
+
+// NewPasswordConfig PASSWORD_REQUIRED_CLASSESはletter, number, uppercase, lowercase, symbolをカンマ区切りで指定する。
+// PASSWORD_HASH_ALGORITHMはbcryptかargon2idで、PASSWORD_ARGON2_MEMORYの単位はKiB。
+// argon2idのパラメータは型の範囲を超える値を切り詰めずにエラーとする
+func NewPasswordConfig() (PasswordConfig, error) {
+	config := PasswordConfig{
+		MinLength:            8,
+		MaxLength:            64,
+		RequiredClasses:      []string{"letter", "number"},
+		BreachedPasswordFile: os.Getenv("PASSWORD_BREACHED_FILE"),
+		HashAlgorithm:        "bcrypt",
+		BcryptCost:           10,
+		Argon2Memory:         19 * 1024,
+		Argon2Iterations:     2,
+		Argon2Parallelism:    1,
+	}
+	if value := os.Getenv("PASSWORD_HASH_ALGORITHM"); value != "" {
+		config.HashAlgorithm = value
+	}
+	for key, target := range map[string]*int{
+		"PASSWORD_MIN_LENGTH":  &config.MinLength,
+		"PASSWORD_MAX_LENGTH":  &config.MaxLength,
+		"PASSWORD_BCRYPT_COST": &config.BcryptCost,
+	} {
+		if value := os.Getenv(key); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return PasswordConfig{}, fmt.Errorf("failed to parse %s: %w", key, err)
+			}
+			*target = n
+		}
+	}
+	for key, target := range map[string]*uint32{
+		"PASSWORD_ARGON2_MEMORY":     &config.Argon2Memory,
+		"PASSWORD_ARGON2_ITERATIONS": &config.Argon2Iterations,
+	} {
+		if value := os.Getenv(key); value != "" {
+			n, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return PasswordConfig{}, fmt.Errorf("failed to parse %s: %w", key, err)
+			}
+			*target = uint32(n)
+		}
+	}
+	if value := os.Getenv("PASSWORD_ARGON2_PARALLELISM"); value != "" {
+		n, err := strconv.ParseUint(value, 10, 8)
+		if err != nil {
+			return PasswordConfig{}, fmt.Errorf("failed to parse PASSWORD_ARGON2_PARALLELISM: %w", err)
+		}
+		config.Argon2Parallelism = uint8(n)
+	}
+	if value, ok := os.LookupEnv("PASSWORD_REQUIRED_CLASSES"); ok {
+		config.RequiredClasses = splitList(value)
+	}
+	config.ForbiddenWords = splitList(os.Getenv("PASSWORD_FORBIDDEN_WORDS"))
+
+	return config, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ServerConfig struct {
+	RequestTimeout time.Duration
+	RouteTimeouts  map[string]time.Duration
+	RequireIfMatch bool
+	// TrustedProxies X-Forwarded-ForとX-Real-IPを信頼するリバースプロキシのアドレス
+	TrustedProxies []*net.IPNet
+}
+
+// NewServerConfig ROUTE_TIMEOUTSは"POST /auth/login=5s,GET /users=3s"の形式で指定する。
+// TRUSTED_PROXIESはIPアドレスかCIDRをカンマ区切りで指定する
+func NewServerConfig() ServerConfig {
+	config := ServerConfig{
+		RequestTimeout: 10 * time.Second,
+		RouteTimeouts:  map[string]time.Duration{},
+	}
+	if value := os.Getenv("REQUEST_TIMEOUT"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			panic("failed to parse REQUEST_TIMEOUT")
+		}
+		config.RequestTimeout = d
+	}
+	if value := os.Getenv("REQUIRE_IF_MATCH"); value != "" {
+		required, err := strconv.ParseBool(value)
+		if err != nil {
+			panic("failed to parse REQUIRE_IF_MATCH")
+		}
+		config.RequireIfMatch = required
+	}
+	for _, entry := range strings.Split(os.Getenv("ROUTE_TIMEOUTS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, value, ok := strings.Cut(entry, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !hasPath {
+			panic("failed to parse ROUTE_TIMEOUTS")
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			panic("failed to parse ROUTE_TIMEOUTS")
+		}
+		config.RouteTimeouts[strings.ToUpper(method)+" "+strings.TrimSpace(path)] = d
+	}
+	for _, entry := range splitList(os.Getenv("TRUSTED_PROXIES")) {
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			panic("failed to parse TRUSTED_PROXIES")
+		}
+		config.TrustedProxies = append(config.TrustedProxies, network)
+	}
+
+	return config
+}
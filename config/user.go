@@ -0,0 +1,39 @@
+package database
+
+import (
+	"os"
+	"time"
+)
+
+// minDeletedRetention 誤って削除したユーザーを復元できるよう、保持期間はこれより短くできない
+const minDeletedRetention = 24 * time.Hour
+
+type UserConfig struct {
+	// DeletedRetention 論理削除したユーザーを完全に削除するまでの保持期間
+	DeletedRetention time.Duration
+	PurgeInterval    time.Duration
+}
+
+func NewUserConfig() UserConfig {
+	config := UserConfig{
+		DeletedRetention: 30 * 24 * time.Hour,
+		PurgeInterval:    time.Hour,
+	}
+	for key, target := range map[string]*time.Duration{
+		"USER_DELETED_RETENTION": &config.DeletedRetention,
+		"USER_PURGE_INTERVAL":    &config.PurgeInterval,
+	} {
+		if value := os.Getenv(key); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				panic("failed to parse " + key)
+			}
+			*target = d
+		}
+	}
+	if config.DeletedRetention < minDeletedRetention {
+		panic("USER_DELETED_RETENTION must be at least " + minDeletedRetention.String())
+	}
+
+	return config
+}
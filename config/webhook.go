@@ -0,0 +1,61 @@
+package database
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+type WebhookConfig struct {
+	// Timeout 1回の送信で応答を待つ時間
+	Timeout          time.Duration
+	BatchSize        int
+	DispatchInterval time.Duration
+	RetryBaseDelay   time.Duration
+	RetryMaxDelay    time.Duration
+	// MaxAttempts 1つの配信を試みる回数の上限
+	MaxAttempts int
+	// DisableAfterFailures 購読先への配信が続けてこの回数失敗したら、その購読先への配信を止める
+	DisableAfterFailures int
+}
+
+func NewWebhookConfig() WebhookConfig {
+	config := WebhookConfig{
+		Timeout:              10 * time.Second,
+		BatchSize:            50,
+		DispatchInterval:     time.Second,
+		RetryBaseDelay:       30 * time.Second,
+		RetryMaxDelay:        time.Hour,
+		MaxAttempts:          8,
+		DisableAfterFailures: 20,
+	}
+	for key, target := range map[string]*int{
+		"WEBHOOK_BATCH_SIZE":             &config.BatchSize,
+		"WEBHOOK_MAX_ATTEMPTS":           &config.MaxAttempts,
+		"WEBHOOK_DISABLE_AFTER_FAILURES": &config.DisableAfterFailures,
+	} {
+		if value := os.Getenv(key); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				panic("failed to parse " + key)
+			}
+			*target = n
+		}
+	}
+	for key, target := range map[string]*time.Duration{
+		"WEBHOOK_TIMEOUT":           &config.Timeout,
+		"WEBHOOK_DISPATCH_INTERVAL": &config.DispatchInterval,
+		"WEBHOOK_RETRY_BASE_DELAY":  &config.RetryBaseDelay,
+		"WEBHOOK_RETRY_MAX_DELAY":   &config.RetryMaxDelay,
+	} {
+		if value := os.Getenv(key); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				panic("failed to parse " + key)
+			}
+			*target = d
+		}
+	}
+
+	return config
+}
@@ -0,0 +1,159 @@
+package model
+
+import "time"
+
+// redacted 監査ログに値を残さない項目の代わりに記録する文字列
+const redacted = "[REDACTED]"
+
+// AuditAction 監査ログに記録するユーザーへの操作
+type AuditAction string
+
+const (
+	AuditActionUserCreated       AuditAction = "user.created"
+	AuditActionUserUpdated       AuditAction = "user.updated"
+	AuditActionUserDeleted       AuditAction = "user.deleted"
+	AuditActionUserRestored      AuditAction = "user.restored"
+	AuditActionUserSuspended     AuditAction = "user.suspended"
+	AuditActionUserReinstated    AuditAction = "user.reinstated"
+	AuditActionUserDeactivated   AuditAction = "user.deactivated"
+	AuditActionUserRoleAssigned  AuditAction = "user.role_assigned"
+	AuditActionUserEmailVerified AuditAction = "user.email_verified"
+	AuditActionUserPasswordReset AuditAction = "user.password_reset"
+	AuditActionUserPurged        AuditAction = "user.purged"
+)
+
+// ParseAuditAction 文字列を監査ログの操作として検証する
+func ParseAuditAction(action string) (AuditAction, error) {
+	switch AuditAction(action) {
+	case AuditActionUserCreated, AuditActionUserUpdated, AuditActionUserDeleted, AuditActionUserRestored,
+		AuditActionUserSuspended, AuditActionUserReinstated, AuditActionUserDeactivated,
+		AuditActionUserRoleAssigned, AuditActionUserEmailVerified, AuditActionUserPasswordReset, AuditActionUserPurged:
+		return AuditAction(action), nil
+	}
+	return "", NewValidationError(CodeInvalidFormat, "操作の種類が不正です")
+}
+
+// AuditChange 項目ごとの変更前後の値。値が無い場合はnil
+type AuditChange struct {
+	Field  string
+	Before *string
+	After  *string
+}
+
+// AuditLog ユーザーに対する操作の記録。追記のみで、記録した後に変更や削除はしない
+type AuditLog struct {
+	ID        string
+	ActorID   string
+	Action    AuditAction
+	TargetID  string
+	Changes   []AuditChange
+	RequestID string
+	IP        string
+	CreatedAt time.Time
+}
+
+// AuditContext 操作を行った利用者とリクエストの情報。未認証の操作ではActorIDが空になる
+type AuditContext struct {
+	ActorID   string
+	RequestID string
+	IP        string
+}
+
+// NewAuditLog beforeからafterへの変更をactionとして記録する。作成ではbeforeを、削除ではafterをnilにする
+func NewAuditLog(ids IDGenerator, action AuditAction, before *User, after *User, audit AuditContext) AuditLog {
+	target := after
+	if target == nil {
+		target = before
+	}
+	return AuditLog{
+		ID:        ids.Generate(),
+		ActorID:   audit.ActorID,
+		Action:    action,
+		TargetID:  target.ID(),
+		Changes:   DiffUsers(before, after),
+		RequestID: audit.RequestID,
+		IP:        audit.IP,
+		CreatedAt: time.Now(),
+	}
+}
+
+// NewPurgeAuditLog 保持期間を過ぎたユーザーを完全に削除したことを記録する。システムによる操作のためActorIDは空になる。
+// 削除した個人情報を残さないよう、変更内容は論理削除した日時のみとする
+func NewPurgeAuditLog(ids IDGenerator, userID string, deletedAt time.Time) AuditLog {
+	return AuditLog{
+		ID:        ids.Generate(),
+		Action:    AuditActionUserPurged,
+		TargetID:  userID,
+		Changes:   []AuditChange{{Field: "deleted_at", Before: timeValue(&deletedAt)}},
+		CreatedAt: time.Now(),
+	}
+}
+
+// DiffUsers 値が変わった項目を列挙する。パスワードは変更の有無のみを記録し、ハッシュは残さない。
+// バージョンと更新日時は全ての変更で変わるため含めない
+func DiffUsers(before *User, after *User) []AuditChange {
+	var changes []AuditChange
+	for _, field := range auditFields {
+		var b, a *string
+		if before != nil {
+			b = field.value(before)
+		}
+		if after != nil {
+			a = field.value(after)
+		}
+		if equalValue(b, a) {
+			continue
+		}
+		if field.secret {
+			b, a = redactValue(b), redactValue(a)
+		}
+		changes = append(changes, AuditChange{Field: field.name, Before: b, After: a})
+	}
+	return changes
+}
+
+// auditFields 監査ログで差分を記録する項目
+var auditFields = []struct {
+	name   string
+	secret bool
+	value  func(u *User) *string
+}{
+	{name: "username", value: func(u *User) *string { return stringValue(u.username) }},
+	{name: "email", value: func(u *User) *string { return stringValue(u.email) }},
+	{name: "email_verified_at", value: func(u *User) *string { return timeValue(u.emailVerifiedAt) }},
+	{name: "password", secret: true, value: func(u *User) *string { return stringValue(u.passwordHash) }},
+	{name: "role", value: func(u *User) *string { return stringValue(string(u.role)) }},
+	{name: "status", value: func(u *User) *string { return stringValue(string(u.status)) }},
+	{name: "status_reason", value: func(u *User) *string { return stringValue(u.statusReason) }},
+	{name: "deleted_at", value: func(u *User) *string { return timeValue(u.deletedAt) }},
+}
+
+func stringValue(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func timeValue(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.UTC().Format(time.RFC3339Nano)
+	return &s
+}
+
+func redactValue(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	r := redacted
+	return &r
+}
+
+func equalValue(a *string, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,177 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
+)
+
+// RFC 5321のメールアドレスの長さの上限（オクテット数）
+const (
+	maxEmailLocalLength = 64
+	maxEmailLength      = 254
+)
+
+// emailDomainProfile ドメインを小文字化・Unicode正規化した上でpunycodeに変換し、ラベルの長さも検証する
+var emailDomainProfile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.VerifyDNSLength(true),
+)
+
+// EmailPolicy メールアドレスの正規化と登録可否の設定
+type EmailPolicy struct {
+	// CanonicalizePlusAddress trueの場合、一意性の判定でローカル部の"+"以降を無視する。
+	// 保存済みのemail_canonicalは、変更後にマイグレーションを実行すると計算し直される
+	CanonicalizePlusAddress bool
+	// DisposableDomains 登録を拒否する使い捨てメールのドメイン。サブドメインも拒否する
+	DisposableDomains []string
+}
+
+type emailRules struct {
+	canonicalizePlusAddress bool
+	disposableDomains       map[string]struct{}
+}
+
+func newEmailRules(policy EmailPolicy) emailRules {
+	domains := make(map[string]struct{}, len(policy.DisposableDomains))
+	for _, domain := range policy.DisposableDomains {
+		domain = strings.TrimSpace(domain)
+		if ascii, err := emailDomainProfile.ToASCII(domain); err == nil {
+			domain = ascii
+		}
+		domains[strings.ToLower(domain)] = struct{}{}
+	}
+	return emailRules{
+		canonicalizePlusAddress: policy.CanonicalizePlusAddress,
+		disposableDomains:       domains,
+	}
+}
+
+// isDisposable ドメイン自身か、その上位のドメインがブロックリストにあるかを判定する
+func (r emailRules) isDisposable(domain string) bool {
+	for {
+		if _, ok := r.disposableDomains[domain]; ok {
+			return true
+		}
+		i := strings.IndexByte(domain, '.')
+		if i < 0 {
+			return false
+		}
+		domain = domain[i+1:]
+	}
+}
+
+// Email メールアドレス。前後の空白を除き、ドメインは小文字のpunycodeに揃える。
+// ローカル部は大文字・小文字を含めて入力のまま保持する
+type Email struct {
+	local  string
+	domain string
+}
+
+// NewUserEmail 登録できるメールアドレスかを設定に従って検証する
+func (f *UserFactory) NewUserEmail(email string) (Email, error) {
+	e, err := parseEmail(email)
+	if err != nil {
+		return Email{}, err
+	}
+	if f.email.isDisposable(e.domain) {
+		return Email{}, NewValidationError(CodeDisposableDomain, "使い捨てメールアドレスは登録できません")
+	}
+	return e, nil
+}
+
+func parseEmail(email string) (Email, error) {
+	invalid := NewValidationError(CodeInvalidFormat, "メールアドレスが不正です")
+	email = strings.TrimSpace(email)
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return Email{}, invalid
+	}
+	local := norm.NFC.String(email[:at])
+	if !isDotAtom(local) {
+		return Email{}, invalid
+	}
+	domain, err := emailDomainProfile.ToASCII(email[at+1:])
+	if err != nil || !strings.Contains(domain, ".") || isNumeric(domain[strings.LastIndexByte(domain, '.')+1:]) {
+		return Email{}, invalid
+	}
+	if len(local) > maxEmailLocalLength || len(local)+1+len(domain) > maxEmailLength {
+		return Email{}, NewValidationError(CodeTooLong, "メールアドレスが長すぎます")
+	}
+
+	return Email{
+		local:  local,
+		domain: domain,
+	}, nil
+}
+
+// isDotAtom ローカル部がRFC 5322のdot-atomか判定する。RFC 6531に従い非ASCIIの文字も許可し、引用符形式は扱わない
+func isDotAtom(local string) bool {
+	if local == "" || !utf8.ValidString(local) {
+		return false
+	}
+	for _, atom := range strings.Split(local, ".") {
+		if atom == "" {
+			return false
+		}
+		for _, r := range atom {
+			if r >= utf8.RuneSelf {
+				if !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.IsMark(r) {
+					return false
+				}
+				continue
+			}
+			if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || strings.ContainsRune("!#$%&'*+-/=?^_`{|}~", r)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isNumeric(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (e Email) String() string {
+	return e.local + "@" + e.domain
+}
+
+// canonical 一意性の判定に使う形式。ローカル部を小文字にし、設定に応じて"+"以降を取り除く
+func (r emailRules) canonical(e Email) string {
+	local := strings.ToLower(e.local)
+	if r.canonicalizePlusAddress {
+		if i := strings.IndexByte(local, '+'); i > 0 {
+			local = local[:i]
+		}
+	}
+	return local + "@" + e.domain
+}
+
+// CanonicalizeEmail 入力されたメールアドレスを一意性の判定に使う形式にする。
+// 不正な形式の場合は前後の空白を除いて小文字にしたものを返す
+func (f *UserFactory) CanonicalizeEmail(email string) string {
+	e, err := parseEmail(email)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(email))
+	}
+	return f.email.canonical(e)
+}
+
+// EmailCanonicalRules 正規化の規則を表す文字列。保存済みの値がどの規則で計算されたかを記録し、
+// 設定が変わったことを検出するために使う
+func (f *UserFactory) EmailCanonicalRules() string {
+	if f.email.canonicalizePlusAddress {
+		return "lowercase,strip-plus-address"
+	}
+	return "lowercase"
+}
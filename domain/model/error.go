@@ -0,0 +1,115 @@
+package model
+
+import "strings"
+
+// ErrorKind ドメインエラーの種別
+type ErrorKind int
+
+const (
+	ErrorKindValidation ErrorKind = iota + 1
+	ErrorKindNotFound
+	ErrorKindConflict
+	ErrorKindUnauthorized
+	ErrorKindForbidden
+	ErrorKindPreconditionFailed
+)
+
+// FieldError 入力項目ごとのバリデーションエラー
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// Error ドメイン層・ユースケース層が返すエラー
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is 種別が一致すればerrors.Isで同一とみなす
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return t.Kind == e.Kind && t.Message == ""
+}
+
+// errors.Isで種別を判定するためのエラー
+var (
+	ErrValidation         = &Error{Kind: ErrorKindValidation}
+	ErrNotFound           = &Error{Kind: ErrorKindNotFound}
+	ErrConflict           = &Error{Kind: ErrorKindConflict}
+	ErrUnauthorized       = &Error{Kind: ErrorKindUnauthorized}
+	ErrForbidden          = &Error{Kind: ErrorKindForbidden}
+	ErrPreconditionFailed = &Error{Kind: ErrorKindPreconditionFailed}
+)
+
+func NewValidationError(code string, message string) error {
+	return &Error{Kind: ErrorKindValidation, Code: code, Message: message}
+}
+
+// NewFieldsError 複数項目のバリデーションエラーをまとめる
+func NewFieldsError(fields []FieldError) error {
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = field.Message
+	}
+	return &Error{Kind: ErrorKindValidation, Message: strings.Join(messages, " / "), Fields: fields}
+}
+
+// fieldErrors errがバリデーションエラーであれば項目名を付けて追加する
+func fieldErrors(fields []FieldError, field string, err error) ([]FieldError, error) {
+	e, ok := err.(*Error)
+	if !ok || e.Kind != ErrorKindValidation {
+		return fields, err
+	}
+	if len(e.Fields) > 0 {
+		for _, f := range e.Fields {
+			fields = append(fields, FieldError{Field: field, Code: f.Code, Message: f.Message})
+		}
+		return fields, nil
+	}
+	return append(fields, FieldError{Field: field, Code: e.Code, Message: e.Message}), nil
+}
+
+func NewNotFoundError(message string, err error) error {
+	return &Error{Kind: ErrorKindNotFound, Message: message, Err: err}
+}
+
+func NewConflictError(message string, err error) error {
+	return &Error{Kind: ErrorKindConflict, Message: message, Err: err}
+}
+
+// NewFieldsConflictError 一意であるべき項目が既に使われている場合のエラー
+func NewFieldsConflictError(fields []FieldError, err error) error {
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = field.Message
+	}
+	return &Error{Kind: ErrorKindConflict, Message: strings.Join(messages, " / "), Fields: fields, Err: err}
+}
+
+func NewUnauthorizedError(message string) error {
+	return &Error{Kind: ErrorKindUnauthorized, Message: message}
+}
+
+func NewForbiddenError(message string) error {
+	return &Error{Kind: ErrorKindForbidden, Message: message}
+}
+
+func NewPreconditionFailedError(message string) error {
+	return &Error{Kind: ErrorKindPreconditionFailed, Message: message}
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// IDGenerator 識別子の生成器
+type IDGenerator interface {
+	Generate() string
+}
+
+// UUIDv7Generator 作成時刻順にソート可能なUUIDv7を生成する
+type UUIDv7Generator struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	lastMs int64
+	seq    uint16
+}
+
+func NewUUIDv7Generator() *UUIDv7Generator {
+	return &UUIDv7Generator{now: time.Now}
+}
+
+func (g *UUIDv7Generator) Generate() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("failed to read random bytes")
+	}
+
+	g.mu.Lock()
+	ms := g.now().UnixMilli()
+	if ms <= g.lastMs {
+		// 同一ミリ秒内はシーケンスで単調増加させる
+		ms = g.lastMs
+		g.seq++
+		if g.seq > 0x0fff {
+			ms++
+			g.seq = 0
+		}
+	} else {
+		g.seq = uint16(b[6]&0x07)<<8 | uint16(b[7])
+	}
+	g.lastMs = ms
+	seq := g.seq
+	g.mu.Unlock()
+
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+	b[6] = 0x70 | byte(seq>>8)&0x0f
+	b[7] = byte(seq)
+	b[8] = 0x80 | b[8]&0x3f
+
+	return formatUUID(b)
+}
+
+func formatUUID(b [16]byte) string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:36], b[10:16])
+	return string(buf[:])
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			c := s[i]
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
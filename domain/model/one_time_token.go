@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// TokenPurpose 1回限りのトークンの用途。用途の異なるトークンは互いに使えない
+type TokenPurpose string
+
+const (
+	TokenPurposeEmailVerification TokenPurpose = "email-verification"
+	TokenPurposePasswordReset     TokenPurpose = "password-reset"
+)
+
+// OneTimeToken メールで送るリンクに使う1回限りのトークン。平文は保持せずハッシュのみ保存する。
+// 発行時のメールアドレスを保持し、その後メールアドレスが変わった場合はメールアドレスの確認にもパスワードの再設定にも使えない
+type OneTimeToken struct {
+	ID        string
+	UserID    string
+	Purpose   TokenPurpose
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// NewOneTimeToken トークンを生成し、保存用のエンティティと利用者に渡す平文を返す
+func NewOneTimeToken(ids IDGenerator, purpose TokenPurpose, user *User, ttl time.Duration) (OneTimeToken, string, error) {
+	raw, err := generateToken()
+	if err != nil {
+		return OneTimeToken{}, "", err
+	}
+	now := time.Now()
+
+	return OneTimeToken{
+		ID:        ids.Generate(),
+		UserID:    user.ID(),
+		Purpose:   purpose,
+		Email:     user.Email(),
+		TokenHash: HashOneTimeToken(raw),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}, raw, nil
+}
+
+// HashOneTimeToken 保存・照合に使うハッシュ値を返す
+func HashOneTimeToken(raw string) string {
+	return hashToken(raw)
+}
+
+// IsExpired 有効期限切れであればtrueを返す
+func (t *OneTimeToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// IsUsed 使用済みであればtrueを返す
+func (t *OneTimeToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxMessage 配信待ちのドメインイベント。集約の変更と同じトランザクションで保存し、リレーが配信先に渡す。
+// 配信できるまで繰り返し試すため、同じメッセージが複数回配信されることがある
+type OutboxMessage struct {
+	ID          string
+	EventType   string
+	AggregateID string
+	// Payload イベントをJSONに変換した内容
+	Payload       []byte
+	OccurredAt    time.Time
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	PublishedAt   *time.Time
+	CreatedAt     time.Time
+}
+
+// NewOutboxMessage イベントを直ちに配信するメッセージに変換する
+func NewOutboxMessage(ids IDGenerator, event DomainEvent) (OutboxMessage, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return OutboxMessage{}, err
+	}
+	now := time.Now()
+
+	return OutboxMessage{
+		ID:            ids.Generate(),
+		EventType:     event.EventType(),
+		AggregateID:   event.AggregateID(),
+		Payload:       payload,
+		OccurredAt:    event.OccurredAt(),
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}, nil
+}
@@ -0,0 +1,211 @@
+package model
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxPasswordBytes bcryptがハッシュ化できるパスワードの上限（バイト数）
+const maxPasswordBytes = 72
+
+// minPersonalInfoLength これより短いユーザー名などは偶然の一致が多いため、パスワードに含まれていても拒否しない
+const minPersonalInfoLength = 4
+
+// CharacterClass パスワードに含めることを求める文字の種類
+type CharacterClass string
+
+const (
+	CharacterClassLetter    CharacterClass = "letter"
+	CharacterClassNumber    CharacterClass = "number"
+	CharacterClassUppercase CharacterClass = "uppercase"
+	CharacterClassLowercase CharacterClass = "lowercase"
+	CharacterClassSymbol    CharacterClass = "symbol"
+)
+
+var characterClassRules = map[CharacterClass]struct {
+	match   func(r rune) bool
+	code    string
+	message string
+}{
+	CharacterClassLetter:    {unicode.IsLetter, CodeMissingLetter, "パスワードは英字を含む必要があります"},
+	CharacterClassNumber:    {unicode.IsNumber, CodeMissingNumber, "パスワードは数字を含む必要があります"},
+	CharacterClassUppercase: {unicode.IsUpper, CodeMissingUppercase, "パスワードは大文字を含む必要があります"},
+	CharacterClassLowercase: {unicode.IsLower, CodeMissingLowercase, "パスワードは小文字を含む必要があります"},
+	CharacterClassSymbol:    {isSymbol, CodeMissingSymbol, "パスワードは記号を含む必要があります"},
+}
+
+func isSymbol(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// BreachedPasswords 漏洩したパスワードの一覧。k-匿名性の方式に従い、SHA-1ハッシュ（大文字の16進数）の
+// 先頭5文字を受け取り、一致するハッシュの残り35文字を返す。パスワードそのものは渡さない
+type BreachedPasswords interface {
+	Range(prefix string) ([]string, error)
+}
+
+// PasswordPolicy パスワードの検証とハッシュ化の設定
+type PasswordPolicy struct {
+	// MinLength MaxLength 文字数の下限と上限。上限に関わらず72バイトを超えるパスワードは拒否する
+	MinLength int
+	MaxLength int
+	// RequiredClasses 含めることを求める文字の種類
+	RequiredClasses []CharacterClass
+	// ForbiddenWords 大文字・小文字を区別せず、含めることを禁止する語句
+	ForbiddenWords []string
+	// BreachedPasswords nilの場合は漏洩したパスワードの照合を行わない
+	BreachedPasswords BreachedPasswords
+	// Algorithm 新しくハッシュ化する際のアルゴリズム。異なるアルゴリズムやパラメータのハッシュはログイン時にハッシュ化し直す
+	Algorithm PasswordAlgorithm
+	// Cost bcryptのコスト
+	Cost int
+	// Argon2 argon2idのパラメータ
+	Argon2 Argon2Params
+}
+
+// DefaultPasswordPolicy 8文字以上64文字以下で、英字と数字を含むパスワードを求め、bcryptでハッシュ化する
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:       8,
+		MaxLength:       64,
+		RequiredClasses: []CharacterClass{CharacterClassLetter, CharacterClassNumber},
+		Algorithm:       PasswordAlgorithmBcrypt,
+		Cost:            bcrypt.DefaultCost,
+		Argon2:          DefaultArgon2Params(),
+	}
+}
+
+type passwordRules struct {
+	PasswordPolicy
+	// dummyHash ユーザーが存在しない場合も照合にかかる時間を揃えるためのハッシュ。設定と同じアルゴリズムで作成する
+	dummyHash string
+}
+
+func newPasswordRules(policy PasswordPolicy) (passwordRules, error) {
+	if policy.MinLength < 1 || policy.MaxLength < policy.MinLength {
+		return passwordRules{}, fmt.Errorf("invalid password length: min=%d max=%d", policy.MinLength, policy.MaxLength)
+	}
+	switch policy.Algorithm {
+	case PasswordAlgorithmBcrypt:
+		if policy.Cost < bcrypt.MinCost || policy.Cost > bcrypt.MaxCost {
+			return passwordRules{}, fmt.Errorf("invalid bcrypt cost: %d", policy.Cost)
+		}
+	case PasswordAlgorithmArgon2id:
+		if err := policy.Argon2.validate(); err != nil {
+			return passwordRules{}, err
+		}
+	default:
+		return passwordRules{}, fmt.Errorf("unknown password algorithm: %q", policy.Algorithm)
+	}
+	for _, class := range policy.RequiredClasses {
+		if _, ok := characterClassRules[class]; !ok {
+			return passwordRules{}, fmt.Errorf("unknown character class: %q", class)
+		}
+	}
+	rules := passwordRules{PasswordPolicy: policy}
+	dummyHash, err := rules.hash("dummy-password")
+	if err != nil {
+		return passwordRules{}, err
+	}
+	rules.dummyHash = dummyHash
+	return rules, nil
+}
+
+type Password struct {
+	hashedValue string
+}
+
+// NewPassword 設定に違反した項目を全て集めて返す。personalInfoにはユーザー名やメールアドレスを渡し、
+// それら（メールアドレスはローカル部）がパスワードに含まれていれば拒否する
+func (f *UserFactory) NewPassword(password string, personalInfo ...string) (Password, error) {
+	violations, err := f.password.check(password, personalInfo)
+	if err != nil {
+		return Password{}, err
+	}
+	if len(violations) > 0 {
+		return Password{}, NewFieldsError(violations)
+	}
+
+	hashedPassword, err := f.password.hash(password)
+	if err != nil {
+		return Password{}, errors.New("パスワードのハッシュ化に失敗しました")
+	}
+
+	return Password{
+		hashedValue: hashedPassword,
+	}, nil
+}
+
+func (r passwordRules) check(password string, personalInfo []string) ([]FieldError, error) {
+	var violations []FieldError
+	violate := func(code string, message string) {
+		violations = append(violations, FieldError{Code: code, Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < r.MinLength {
+		violate(CodeTooShort, fmt.Sprintf("パスワードは%d文字以上で入力してください", r.MinLength))
+	}
+	if length > r.MaxLength {
+		violate(CodeTooLong, fmt.Sprintf("パスワードは%d文字以下で入力してください", r.MaxLength))
+	} else if len(password) > maxPasswordBytes {
+		violate(CodeTooLong, fmt.Sprintf("パスワードは%dバイト以下で入力してください", maxPasswordBytes))
+	}
+
+	for _, class := range r.RequiredClasses {
+		rule := characterClassRules[class]
+		if strings.IndexFunc(password, rule.match) < 0 {
+			violate(rule.code, rule.message)
+		}
+	}
+
+	lower := strings.ToLower(password)
+	for _, info := range personalInfo {
+		if local, _, ok := strings.Cut(info, "@"); ok {
+			info = local
+		}
+		if utf8.RuneCountInString(info) >= minPersonalInfoLength && strings.Contains(lower, strings.ToLower(info)) {
+			violate(CodeContainsPersonalInfo, "パスワードにユーザー名やメールアドレスを含めることはできません")
+			break
+		}
+	}
+	for _, word := range r.ForbiddenWords {
+		if word != "" && strings.Contains(lower, strings.ToLower(word)) {
+			violate(CodeContainsForbiddenWord, "パスワードに使用できない語句が含まれています")
+			break
+		}
+	}
+
+	if r.BreachedPasswords != nil {
+		breached, err := isBreached(r.BreachedPasswords, password)
+		if err != nil {
+			return nil, err
+		}
+		if breached {
+			violate(CodeBreachedPassword, "このパスワードは漏洩したパスワードの一覧に含まれているため使用できません")
+		}
+	}
+	return violations, nil
+}
+
+func isBreached(breached BreachedPasswords, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	suffixes, err := breached.Range(hash[:5])
+	if err != nil {
+		return false, fmt.Errorf("漏洩したパスワードの照合に失敗しました: %w", err)
+	}
+	for _, suffix := range suffixes {
+		if strings.EqualFold(suffix, hash[5:]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
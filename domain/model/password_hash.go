@@ -0,0 +1,122 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordAlgorithm パスワードのハッシュ化に使うアルゴリズム。
+// ハッシュは先頭の識別子でアルゴリズムとバージョンを判別できる形式で保存する。
+// bcryptは"$2a$10$..."、argon2idはPHC形式の"$argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>"
+type PasswordAlgorithm string
+
+const (
+	PasswordAlgorithmBcrypt   PasswordAlgorithm = "bcrypt"
+	PasswordAlgorithmArgon2id PasswordAlgorithm = "argon2id"
+)
+
+// Argon2Params argon2idのパラメータ。Memoryの単位はKiB
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params OWASPの推奨値（19MiB、2回、並列度1）
+func DefaultArgon2Params() Argon2Params {
+	return Argon2Params{
+		Memory:      19 * 1024,
+		Iterations:  2,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+func (p Argon2Params) validate() error {
+	if p.Iterations < 1 || p.Parallelism < 1 || p.Memory < 8*uint32(p.Parallelism) || p.SaltLength < 8 || p.KeyLength < 16 {
+		return fmt.Errorf("invalid argon2id parameters: %+v", p)
+	}
+	return nil
+}
+
+// hash 現在の設定のアルゴリズムとパラメータでハッシュ化する
+func (r passwordRules) hash(password string) (string, error) {
+	if r.Algorithm == PasswordAlgorithmArgon2id {
+		salt := make([]byte, r.Argon2.SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		return encodeArgon2id(r.Argon2, salt, argon2.IDKey([]byte(password), salt, r.Argon2.Iterations, r.Argon2.Memory, r.Argon2.Parallelism, r.Argon2.KeyLength)), nil
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), r.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// verify パスワードがハッシュと一致するかを返す。一致した場合、ハッシュのアルゴリズムやパラメータが
+// 現在の設定と異なればoutdatedにtrueを返す。形式が不正なハッシュには一致しない
+func (r passwordRules) verify(hash string, password string) (match bool, outdated bool) {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return false, false
+		}
+		computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+		if subtle.ConstantTimeCompare(computed, key) != 1 {
+			return false, false
+		}
+		return true, r.Algorithm != PasswordAlgorithmArgon2id || params != r.Argon2
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return false, false
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return true, r.Algorithm != PasswordAlgorithmBcrypt || err != nil || cost != r.Cost
+}
+
+func encodeArgon2id(params Argon2Params, salt []byte, key []byte) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func decodeArgon2id(hash string) (Argon2Params, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return Argon2Params{}, nil, nil, fmt.Errorf("invalid argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2Params{}, nil, nil, fmt.Errorf("unsupported argon2 version: %q", parts[2])
+	}
+	var params Argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return Argon2Params{}, nil, nil, err
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2Params{}, nil, nil, err
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	if err := params.validate(); err != nil {
+		return Argon2Params{}, nil, nil, err
+	}
+	return params, salt, key, nil
+}
@@ -0,0 +1,169 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type stubBreachedPasswords map[string][]string
+
+func (s stubBreachedPasswords) Range(prefix string) ([]string, error) {
+	return s[prefix], nil
+}
+
+func TestNewPassword_Policy(t *testing.T) {
+	type TestCase struct {
+		name          string
+		policy        func(policy *PasswordPolicy)
+		password      string
+		personalInfo  []string
+		expectedCodes []string
+	}
+	testCases := []TestCase{
+		{"有効なパスワード", nil, "password123", nil, nil},
+		{"違反した規則を全て返す", nil, "パス", nil, []string{CodeTooShort, CodeMissingNumber}},
+		{"文字数の上限を超える", nil, strings.Repeat("a1", 33), nil, []string{CodeTooLong}},
+		{"文字数の上限以内でも72バイトを超える", nil, strings.Repeat("あ", 30) + "a1", nil, []string{CodeTooLong}},
+		{"大文字と記号を求める", func(p *PasswordPolicy) {
+			p.RequiredClasses = []CharacterClass{CharacterClassUppercase, CharacterClassSymbol}
+		}, "password123", nil, []string{CodeMissingUppercase, CodeMissingSymbol}},
+		{"ユーザー名を含む", nil, "TestUser123", []string{"testuser", "other@example.com"}, []string{CodeContainsPersonalInfo}},
+		{"メールアドレスのローカル部を含む", nil, "mail-alice-1", []string{"someone", "Alice@example.com"}, []string{CodeContainsPersonalInfo}},
+		{"短いユーザー名は判定しない", nil, "bobpassword1", []string{"bob"}, nil},
+		{"禁止された語句を含む", func(p *PasswordPolicy) {
+			p.ForbiddenWords = []string{"Sample"}
+		}, "mysample123", nil, []string{CodeContainsForbiddenWord}},
+		{"漏洩したパスワード", func(p *PasswordPolicy) {
+			// SHA-1("password123") = CBFDAC6008F9CAB4083784CBD1874F76618D2A97
+			p.BreachedPasswords = stubBreachedPasswords{"CBFDA": {"0000000000000000000000000000000000A", "c6008f9cab4083784cbd1874f76618d2a97"}}
+		}, "password123", nil, []string{CodeBreachedPassword}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := DefaultPasswordPolicy()
+			policy.Cost = 4
+			if tc.policy != nil {
+				tc.policy(&policy)
+			}
+			factory, err := NewUserFactory(testIDs, EmailPolicy{}, policy)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			_, err = factory.NewPassword(tc.password, tc.personalInfo...)
+			if len(tc.expectedCodes) == 0 {
+				if err != nil {
+					t.Errorf("Expected no error, but got %v", err)
+				}
+				return
+			}
+			var e *Error
+			if !errors.As(err, &e) || e.Kind != ErrorKindValidation {
+				t.Fatalf("Expected validation error, but got %v", err)
+			}
+			var codes []string
+			for _, field := range e.Fields {
+				codes = append(codes, field.Code)
+			}
+			if strings.Join(codes, ",") != strings.Join(tc.expectedCodes, ",") {
+				t.Errorf("Expected codes %v, but got %v", tc.expectedCodes, codes)
+			}
+		})
+	}
+}
+
+func TestNewUserFactory_InvalidPasswordPolicy(t *testing.T) {
+	type TestCase struct {
+		name   string
+		policy func(policy *PasswordPolicy)
+	}
+	testCases := []TestCase{
+		{"上限が下限より小さい", func(p *PasswordPolicy) { p.MaxLength = p.MinLength - 1 }},
+		{"bcryptのコストが範囲外", func(p *PasswordPolicy) { p.Cost = 32 }},
+		{"未知の文字の種類", func(p *PasswordPolicy) { p.RequiredClasses = []CharacterClass{"kana"} }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := DefaultPasswordPolicy()
+			tc.policy(&policy)
+			if _, err := NewUserFactory(testIDs, EmailPolicy{}, policy); err == nil {
+				t.Errorf("Expected error, but got nil")
+			}
+		})
+	}
+}
+
+func TestUser_Authenticate_Rehash(t *testing.T) {
+	bcrypt4 := DefaultPasswordPolicy()
+	bcrypt4.Cost = 4
+	bcrypt5 := DefaultPasswordPolicy()
+	bcrypt5.Cost = 5
+	argon := DefaultPasswordPolicy()
+	argon.Algorithm = PasswordAlgorithmArgon2id
+	argon.Argon2 = Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	argonStronger := argon
+	argonStronger.Argon2.Iterations = 2
+
+	type TestCase struct {
+		name             string
+		hashedWith       PasswordPolicy
+		verifiedWith     PasswordPolicy
+		expectedRehashed bool
+		expectedPrefix   string
+	}
+	testCases := []TestCase{
+		{"bcryptで設定が同じ場合はそのまま", bcrypt4, bcrypt4, false, "$2a$04$"},
+		{"bcryptのコストを上げた場合はハッシュ化し直す", bcrypt4, bcrypt5, true, "$2a$05$"},
+		{"argon2idで設定が同じ場合はそのまま", argon, argon, false, "$argon2id$v=19$m=64,t=1,p=1$"},
+		{"bcryptからargon2idに移行する", bcrypt4, argon, true, "$argon2id$v=19$m=64,t=1,p=1$"},
+		{"argon2idのパラメータを変えた場合はハッシュ化し直す", argon, argonStronger, true, "$argon2id$v=19$m=64,t=2,p=1$"},
+		{"argon2idからbcryptに戻す", argon, bcrypt5, true, "$2a$05$"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hashedWith, err := NewUserFactory(testIDs, EmailPolicy{}, tc.hashedWith)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			factory, err := NewUserFactory(testIDs, EmailPolicy{}, tc.verifiedWith)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			user, err := hashedWith.NewUser("testuser", "test@example.com", "password123")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if _, err := user.Authenticate(factory, "wrongpassword1"); !errors.Is(err, ErrUnauthorized) {
+				t.Errorf("Expected unauthorized error, but got %v", err)
+			}
+			rehashed, err := user.Authenticate(factory, "password123")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if rehashed != tc.expectedRehashed {
+				t.Errorf("Expected rehashed %v, but got %v", tc.expectedRehashed, rehashed)
+			}
+			if !strings.HasPrefix(user.PasswordHash(), tc.expectedPrefix) {
+				t.Errorf("Expected hash to start with %q, but got %q", tc.expectedPrefix, user.PasswordHash())
+			}
+			if rehashed, err := user.Authenticate(factory, "password123"); err != nil || rehashed {
+				t.Errorf("Expected upgraded hash to be current, but got rehashed=%v err=%v", rehashed, err)
+			}
+		})
+	}
+
+	t.Run("形式が不正なハッシュには一致しない", func(t *testing.T) {
+		factory := newTestUserFactory(t)
+		for _, hash := range []string{"", "password123", "$argon2id$v=18$m=64,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5", "$argon2id$v=19$m=64$x$y"} {
+			user := ReconstructUser(UserSnapshot{PasswordHash: hash})
+			if _, err := user.Authenticate(factory, "password123"); !errors.Is(err, ErrUnauthorized) {
+				t.Errorf("Expected unauthorized error for %q, but got %v", hash, err)
+			}
+		}
+	})
+}
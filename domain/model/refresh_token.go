@@ -0,0 +1,79 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// RefreshToken アクセストークンを再発行するためのトークン。平文は保持せずハッシュのみ保存する
+type RefreshToken struct {
+	ID        string
+	UserID    string
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// NewRefreshToken トークンを生成し、保存用のエンティティと利用者に渡す平文を返す。
+// familyIDが空の場合はログインによる新しい系列として扱う
+func NewRefreshToken(ids IDGenerator, userID string, familyID string, ttl time.Duration) (RefreshToken, string, error) {
+	raw, err := generateToken()
+	if err != nil {
+		return RefreshToken{}, "", err
+	}
+
+	id := ids.Generate()
+	if familyID == "" {
+		familyID = id
+	}
+	now := time.Now()
+
+	return RefreshToken{
+		ID:        id,
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: HashRefreshToken(raw),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}, raw, nil
+}
+
+// HashRefreshToken 保存・照合に使うハッシュ値を返す
+func HashRefreshToken(raw string) string {
+	return hashToken(raw)
+}
+
+// generateToken 利用者に渡す推測できないトークンを生成する
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsExpired 有効期限切れであればtrueを返す
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// IsUsed ローテーション済みであればtrueを返す
+func (t *RefreshToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
+
+// IsRevoked 失効済みであればtrueを返す
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
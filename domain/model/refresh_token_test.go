@@ -0,0 +1,44 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewRefreshToken(t *testing.T) {
+	t.Run("平文は保存せずハッシュのみ保持する", func(t *testing.T) {
+		token, raw, err := NewRefreshToken(testIDs, "user-id", "", time.Hour)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if raw == "" || token.TokenHash == raw {
+			t.Errorf("Expected hashed token")
+		}
+		if token.TokenHash != HashRefreshToken(raw) {
+			t.Errorf("Expected hash of raw token")
+		}
+		if token.FamilyID != token.ID {
+			t.Errorf("Expected new family, but got %q", token.FamilyID)
+		}
+	})
+
+	t.Run("ローテーション時は系列を引き継ぐ", func(t *testing.T) {
+		token, _, err := NewRefreshToken(testIDs, "user-id", "family-id", time.Hour)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if token.FamilyID != "family-id" {
+			t.Errorf("Expected family-id, but got %q", token.FamilyID)
+		}
+	})
+
+	t.Run("有効期限", func(t *testing.T) {
+		token, _, _ := NewRefreshToken(testIDs, "user-id", "", time.Hour)
+		if token.IsExpired(time.Now()) {
+			t.Errorf("Expected not expired")
+		}
+		if !token.IsExpired(time.Now().Add(2 * time.Hour)) {
+			t.Errorf("Expected expired")
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package model
+
+import "time"
+
+// Role ユーザーの役割。役割ごとに他のユーザーに対して行える操作が決まっている
+type Role string
+
+const (
+	RoleMember  Role = "member"
+	RoleSupport Role = "support"
+	RoleAdmin   Role = "admin"
+)
+
+// Permission 他のユーザーに対する操作の権限
+type Permission string
+
+const (
+	PermissionReadUsers        Permission = "users:read"
+	PermissionReadDeletedUsers Permission = "users:read_deleted"
+	PermissionUpdateUsers      Permission = "users:update"
+	PermissionDeleteUsers      Permission = "users:delete"
+	// PermissionSuspendUsers 利用停止とその解除
+	PermissionSuspendUsers    Permission = "users:suspend"
+	PermissionDeactivateUsers Permission = "users:deactivate"
+	PermissionRestoreUsers    Permission = "users:restore"
+	PermissionAssignRoles     Permission = "users:assign_role"
+	// PermissionReadAuditLogs ユーザーに対する操作の監査ログの閲覧
+	PermissionReadAuditLogs Permission = "audit:read"
+	// PermissionManageWebhooks Webhookの購読先と配信履歴の管理
+	PermissionManageWebhooks Permission = "webhooks:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		PermissionReadUsers,
+		PermissionReadDeletedUsers,
+		PermissionUpdateUsers,
+		PermissionDeleteUsers,
+		PermissionSuspendUsers,
+		PermissionDeactivateUsers,
+		PermissionRestoreUsers,
+		PermissionAssignRoles,
+		PermissionReadAuditLogs,
+		PermissionManageWebhooks,
+	},
+	RoleSupport: {
+		PermissionReadUsers,
+		PermissionReadDeletedUsers,
+		PermissionSuspendUsers,
+		PermissionReadAuditLogs,
+	},
+	RoleMember: {},
+}
+
+// roleRanks 役割の序列。下位の役割の利用者は、権限があっても上位の役割のユーザーを操作できない
+var roleRanks = map[Role]int{
+	RoleMember:  0,
+	RoleSupport: 1,
+	RoleAdmin:   2,
+}
+
+// ParseRole 文字列を役割として検証する
+func ParseRole(role string) (Role, error) {
+	if _, ok := rolePermissions[Role(role)]; !ok {
+		return "", NewValidationError(CodeInvalidFormat, "役割が不正です")
+	}
+	return Role(role), nil
+}
+
+// Can 役割に権限が与えられていればtrueを返す
+func (r Role) Can(permission Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Outranks 役割がotherより上位であればtrueを返す
+func (r Role) Outranks(other Role) bool {
+	return roleRanks[r] > roleRanks[other]
+}
+
+// AllowsSelf 役割に関わらず、本人が自分のユーザーに対して行える操作であればtrueを返す
+func (p Permission) AllowsSelf() bool {
+	switch p {
+	case PermissionReadUsers, PermissionUpdateUsers, PermissionDeleteUsers:
+		return true
+	}
+	return false
+}
+
+// Can ユーザーの役割に権限が与えられていればtrueを返す
+func (u *User) Can(permission Permission) bool {
+	return u.role.Can(permission)
+}
+
+// AssignRole 役割を変更する
+func (u *User) AssignRole(role string, at time.Time) error {
+	parsed, err := ParseRole(role)
+	if err != nil {
+		return NewFieldsError([]FieldError{{Field: "role", Code: CodeInvalidFormat, Message: err.Error()}})
+	}
+	u.role = parsed
+	u.updatedAt = at
+	return nil
+}
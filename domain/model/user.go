@@ -1,116 +1,346 @@
 package model
 
 import (
-	"errors"
-	"regexp"
+	"strings"
 	"time"
-	"unicode"
-
-	"golang.org/x/crypto/bcrypt"
 )
 
+// User ユーザー集約。項目の変更はChangeなどのメソッドを通して検証した上で行う
 type User struct {
-	ID        string
-	Username  string
-	Email     string
-	Password  string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	id              string
+	username        string
+	email           string
+	emailVerifiedAt *time.Time
+	passwordHash    string
+	role            Role
+	status          UserStatus
+	statusReason    string
+	statusChangedAt *time.Time
+	version         int
+	createdAt       time.Time
+	updatedAt       time.Time
+	deletedAt       *time.Time
+	events          []DomainEvent
+}
+
+// UserSnapshot 永続化されたユーザーの状態。検証済みの値としてそのまま復元する
+type UserSnapshot struct {
+	ID              string
+	Username        string
+	Email           string
+	EmailVerifiedAt *time.Time
+	PasswordHash    string
+	Role            Role
+	Status          UserStatus
+	StatusReason    string
+	StatusChangedAt *time.Time
+	Version         int
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       *time.Time
+}
+
+// ReconstructUser リポジトリが永続化された状態からユーザーを復元する
+func ReconstructUser(snapshot UserSnapshot) *User {
+	return &User{
+		id:              snapshot.ID,
+		username:        snapshot.Username,
+		email:           snapshot.Email,
+		emailVerifiedAt: snapshot.EmailVerifiedAt,
+		passwordHash:    snapshot.PasswordHash,
+		role:            snapshot.Role,
+		status:          snapshot.Status,
+		statusReason:    snapshot.StatusReason,
+		statusChangedAt: snapshot.StatusChangedAt,
+		version:         snapshot.Version,
+		createdAt:       snapshot.CreatedAt,
+		updatedAt:       snapshot.UpdatedAt,
+		deletedAt:       snapshot.DeletedAt,
+	}
+}
+
+func (u *User) ID() string                  { return u.id }
+func (u *User) Username() string            { return u.username }
+func (u *User) Email() string               { return u.email }
+func (u *User) EmailVerifiedAt() *time.Time { return u.emailVerifiedAt }
+func (u *User) PasswordHash() string        { return u.passwordHash }
+func (u *User) Role() Role                  { return u.role }
+func (u *User) Status() UserStatus          { return u.status }
+func (u *User) StatusReason() string        { return u.statusReason }
+func (u *User) StatusChangedAt() *time.Time { return u.statusChangedAt }
+func (u *User) Version() int                { return u.version }
+func (u *User) CreatedAt() time.Time        { return u.createdAt }
+func (u *User) UpdatedAt() time.Time        { return u.updatedAt }
+func (u *User) DeletedAt() *time.Time       { return u.deletedAt }
+
+// IsDeleted 論理削除されていればtrueを返す
+func (u *User) IsDeleted() bool {
+	return u.deletedAt != nil
+}
+
+// Delete 論理削除する。Restoreで取り消せる
+func (u *User) Delete(at time.Time) error {
+	if u.IsDeleted() {
+		return NewConflictError("既に削除されています", nil)
+	}
+	u.deletedAt = &at
+	u.updatedAt = at
+	u.recordEvent(UserDeleted{UserID: u.id, At: at})
+	return nil
+}
+
+// Restore 論理削除を取り消す
+func (u *User) Restore(at time.Time) error {
+	if !u.IsDeleted() {
+		return NewConflictError("削除されていないユーザーは復元できません", nil)
+	}
+	u.deletedAt = nil
+	u.updatedAt = at
+	return nil
+}
+
+// IsEmailVerified 現在のメールアドレスの確認が済んでいればtrueを返す
+func (u *User) IsEmailVerified() bool {
+	return u.emailVerifiedAt != nil
 }
 
-func NewUser(username string, email string, password string) (User, error) {
-	userID := NewUserID()
+// VerifyEmail メールアドレスを確認済みにし、確認待ちのアカウントを有効にする。
+// 確認メールを送った後にメールアドレスが変わっていればエラーを返す
+func (u *User) VerifyEmail(email string, at time.Time) error {
+	if email != u.email {
+		return NewValidationError(CodeInvalidToken, "メールアドレスが変更されているため確認できません")
+	}
+	u.emailVerifiedAt = &at
+	u.activate(at)
+	u.updatedAt = at
+	return nil
+}
+
+// NewUser 全ての項目を検証し、失敗した項目をまとめてエラーとして返す。メールアドレスは未確認の状態で、確認待ちのアカウントとして作成する
+func (f *UserFactory) NewUser(username string, email string, password string) (User, error) {
+	var fields []FieldError
+	userID := NewUserID(f.ids)
 	userName, err := NewUserName(username)
-	if err != nil {
+	if fields, err = fieldErrors(fields, "username", err); err != nil {
 		return User{}, err
 	}
-	userEmail, err := NewUserEmail(email)
-	if err != nil {
+	userEmail, err := f.NewUserEmail(email)
+	if fields, err = fieldErrors(fields, "email", err); err != nil {
 		return User{}, err
 	}
-	userPassword, err := NewPassword(password)
-	if err != nil {
+	userPassword, err := f.NewPassword(password, username, email)
+	if fields, err = fieldErrors(fields, "password", err); err != nil {
 		return User{}, err
 	}
+	if len(fields) > 0 {
+		return User{}, NewFieldsError(fields)
+	}
 
-	return User{
-		ID:        userID.value,
-		Username:  userName.value,
-		Email:     userEmail.value,
-		Password:  userPassword.hashedValue,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}, nil
+	now := time.Now()
+	user := User{
+		id:           userID.value,
+		username:     userName.value,
+		email:        userEmail.String(),
+		passwordHash: userPassword.hashedValue,
+		role:         RoleMember,
+		status:       UserStatusPending,
+		version:      1,
+		createdAt:    now,
+		updatedAt:    now,
+	}
+	user.recordEvent(UserRegistered{UserID: user.id, Username: user.username, Email: user.email, At: now})
+	return user, nil
 }
 
-type UserID struct {
-	value string
+// Authenticate パスワードが一致しない場合は認証エラーを返す。一致したハッシュのアルゴリズムやパラメータが
+// 現在の設定と異なる場合はハッシュ化し直してrehashedにtrueを返すので、呼び出し元で保存する
+func (u *User) Authenticate(factory *UserFactory, password string) (rehashed bool, err error) {
+	match, outdated := factory.password.verify(u.passwordHash, password)
+	if !match {
+		return false, NewUnauthorizedError("メールアドレスまたはパスワードが正しくありません")
+	}
+	if !outdated {
+		return false, nil
+	}
+	hashed, err := factory.password.hash(password)
+	if err != nil {
+		// 古いハッシュのままでも認証は成立しているため、ハッシュ化し直さずに続ける
+		return false, nil
+	}
+	u.passwordHash = hashed
+	return true, nil
 }
 
-func NewUserID() UserID {
-	return UserID{
-		value: "",
+// RejectAuthentication ユーザーが存在しない場合に、Authenticateと同程度の時間をかけて認証エラーを返す
+func (f *UserFactory) RejectAuthentication(password string) error {
+	_, _ = f.password.verify(f.password.dummyHash, password)
+	return NewUnauthorizedError("メールアドレスまたはパスワードが正しくありません")
+}
+
+// CheckVersion 呼び出し元が参照したバージョンと一致しない場合はエラーを返す。0の場合は検証しない
+func (u *User) CheckVersion(version int) error {
+	if version != 0 && version != u.version {
+		return NewPreconditionFailedError("ユーザーは他の操作で更新されています")
 	}
+	return nil
 }
 
-type UserName struct {
-	value string
+// UserChanges ユーザーの変更内容。nilの項目は変更しない
+type UserChanges struct {
+	Username *string
+	Email    *string
+	Password *string
+	// ExpectedUsername ExpectedEmail 変更前の値がこれと一致しない場合は何も変更しない。nilの項目は確認しない
+	ExpectedUsername *string
+	ExpectedEmail    *string
 }
 
-func NewUserName(username string) (UserName, error) {
-	if len(username) < 3 || len(username) > 20 {
-		return UserName{}, errors.New("ユーザー名は3文字以上20文字以下で入力してください")
+// IsEmpty 変更する項目が無いかを判定する。確認する値のみの場合も空とみなす
+func (c UserChanges) IsEmpty() bool {
+	return c.Username == nil && c.Email == nil && c.Password == nil
+}
+
+// Change NewUserと同じ検証を行い、全ての項目が有効な場合のみ変更を反映する。
+// メールアドレスとパスワードはfactoryの設定で検証する。ユーザー名のみの変更ではfactoryはnilでもよい。
+// メールアドレスが変わった場合は未確認の状態に戻す。メールアドレスとパスワードの変更はイベントとして記録する。
+// 変更する項目が無い場合は更新日時も変えない
+func (u *User) Change(factory *UserFactory, changes UserChanges) error {
+	if changes.ExpectedUsername != nil && *changes.ExpectedUsername != u.username {
+		return NewConflictError("ユーザー名が期待した値と一致しません", nil)
+	}
+	if changes.ExpectedEmail != nil && *changes.ExpectedEmail != u.email {
+		return NewConflictError("メールアドレスが期待した値と一致しません", nil)
+	}
+	if changes.IsEmpty() {
+		return nil
 	}
 
-	return UserName{
-		value: username,
-	}, nil
+	var fields []FieldError
+	var userName UserName
+	var userEmail Email
+	var userPassword Password
+	var err error
+	if changes.Username != nil {
+		userName, err = NewUserName(*changes.Username)
+		if fields, err = fieldErrors(fields, "username", err); err != nil {
+			return err
+		}
+	}
+	if changes.Email != nil {
+		userEmail, err = factory.NewUserEmail(*changes.Email)
+		if fields, err = fieldErrors(fields, "email", err); err != nil {
+			return err
+		}
+	}
+	if changes.Password != nil {
+		personalInfo := []string{u.username, u.email}
+		if changes.Username != nil {
+			personalInfo[0] = *changes.Username
+		}
+		if changes.Email != nil {
+			personalInfo[1] = *changes.Email
+		}
+		userPassword, err = factory.NewPassword(*changes.Password, personalInfo...)
+		if fields, err = fieldErrors(fields, "password", err); err != nil {
+			return err
+		}
+	}
+	if len(fields) > 0 {
+		return NewFieldsError(fields)
+	}
+
+	now := time.Now()
+	if changes.Username != nil {
+		u.username = userName.value
+	}
+	if changes.Email != nil && userEmail.String() != u.email {
+		u.recordEvent(EmailChanged{UserID: u.id, PreviousEmail: u.email, Email: userEmail.String(), At: now})
+		u.email = userEmail.String()
+		u.emailVerifiedAt = nil
+	}
+	if changes.Password != nil {
+		u.passwordHash = userPassword.hashedValue
+		u.recordEvent(PasswordChanged{UserID: u.id, At: now})
+	}
+	u.updatedAt = now
+	return nil
 }
 
-type Email struct {
-	value string
+// Rename ユーザー名を変更する。ユーザー名の検証は設定に依らないため、UserFactoryを必要としない
+func (u *User) Rename(username string) error {
+	return u.Change(nil, UserChanges{Username: &username})
 }
 
-func NewUserEmail(email string) (Email, error) {
-	if !regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`).MatchString(email) {
-		return Email{}, errors.New("メールアドレスが不正です")
-	}
+// ChangeEmail メールアドレスを変更する
+func (u *User) ChangeEmail(factory *UserFactory, email string) error {
+	return u.Change(factory, UserChanges{Email: &email})
+}
 
-	return Email{
-		value: email,
-	}, nil
+// ChangePassword パスワードをハッシュ化して変更する
+func (u *User) ChangePassword(factory *UserFactory, password string) error {
+	return u.Change(factory, UserChanges{Password: &password})
 }
 
-type Password struct {
-	hashedValue string
+// バリデーションエラーのコード
+const (
+	CodeInvalidFormat         = "invalid_format"
+	CodeInvalidLength         = "invalid_length"
+	CodeTooShort              = "too_short"
+	CodeTooLong               = "too_long"
+	CodeAlreadyExists         = "already_exists"
+	CodeDisposableDomain      = "disposable_domain"
+	CodeMissingLetter         = "missing_letter"
+	CodeMissingNumber         = "missing_number"
+	CodeMissingUppercase      = "missing_uppercase"
+	CodeMissingLowercase      = "missing_lowercase"
+	CodeMissingSymbol         = "missing_symbol"
+	CodeContainsPersonalInfo  = "contains_personal_info"
+	CodeContainsForbiddenWord = "contains_forbidden_word"
+	CodeBreachedPassword      = "breached_password"
+	CodeInvalidToken          = "invalid_token"
+)
+
+// 一意であるべき項目が既に使われている場合の項目エラー。NewFieldsConflictErrorに渡す
+var (
+	FieldUsernameTaken = FieldError{Field: "username", Code: CodeAlreadyExists, Message: "ユーザー名は既に使用されています"}
+	FieldEmailTaken    = FieldError{Field: "email", Code: CodeAlreadyExists, Message: "メールアドレスは既に使用されています"}
+)
+
+type UserID struct {
+	value string
 }
 
-func NewPassword(password string) (Password, error) {
-	if len(password) < 8 {
-		return Password{}, errors.New("パスワードは8文字以上で入力してください")
+func NewUserID(ids IDGenerator) UserID {
+	return UserID{
+		value: ids.Generate(),
 	}
+}
 
-	hasLetter := false
-	hasNumber := false
-	for _, char := range password {
-		if unicode.IsLetter(char) {
-			hasLetter = true
-		}
-		if unicode.IsNumber(char) {
-			hasNumber = true
-		}
+// ParseUserID 文字列をユーザーIDとして検証する
+func ParseUserID(id string) (UserID, error) {
+	if !isUUID(id) {
+		return UserID{}, NewValidationError(CodeInvalidFormat, "ユーザーIDが不正です")
 	}
 
-	if !hasLetter || !hasNumber {
-		return Password{}, errors.New("パスワードは英数字を含む必要があります")
-	}
+	return UserID{
+		value: strings.ToLower(id),
+	}, nil
+}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return Password{}, errors.New("パスワードのハッシュ化に失敗しました")
+func (id UserID) String() string {
+	return id.value
+}
+
+type UserName struct {
+	value string
+}
+
+func NewUserName(username string) (UserName, error) {
+	if len(username) < 3 || len(username) > 20 {
+		return UserName{}, NewValidationError(CodeInvalidLength, "ユーザー名は3文字以上20文字以下で入力してください")
 	}
 
-	return Password{
-		hashedValue: string(hashedPassword),
+	return UserName{
+		value: username,
 	}, nil
 }
@@ -0,0 +1,76 @@
+package model
+
+import "time"
+
+// DomainEvent 集約で起きた出来事。集約が変更に伴って記録し、リポジトリが変更と同じトランザクションでアウトボックスに書き込む。
+// JSONに変換した内容が他のシステムに配信されるため、項目の削除や名前の変更は互換性を壊す
+type DomainEvent interface {
+	EventType() string
+	AggregateID() string
+	OccurredAt() time.Time
+}
+
+const (
+	EventTypeUserRegistered  = "user.registered"
+	EventTypeEmailChanged    = "user.email_changed"
+	EventTypePasswordChanged = "user.password_changed"
+	EventTypeUserDeleted     = "user.deleted"
+)
+
+// UserRegistered ユーザーが登録された
+type UserRegistered struct {
+	UserID   string    `json:"user_id"`
+	Username string    `json:"username"`
+	Email    string    `json:"email"`
+	At       time.Time `json:"occurred_at"`
+}
+
+func (e UserRegistered) EventType() string     { return EventTypeUserRegistered }
+func (e UserRegistered) AggregateID() string   { return e.UserID }
+func (e UserRegistered) OccurredAt() time.Time { return e.At }
+
+// EmailChanged メールアドレスが変更された。新しいメールアドレスは未確認の状態
+type EmailChanged struct {
+	UserID        string    `json:"user_id"`
+	PreviousEmail string    `json:"previous_email"`
+	Email         string    `json:"email"`
+	At            time.Time `json:"occurred_at"`
+}
+
+func (e EmailChanged) EventType() string     { return EventTypeEmailChanged }
+func (e EmailChanged) AggregateID() string   { return e.UserID }
+func (e EmailChanged) OccurredAt() time.Time { return e.At }
+
+// PasswordChanged パスワードが変更された。ハッシュ形式の移行による置き換えは含まない
+type PasswordChanged struct {
+	UserID string    `json:"user_id"`
+	At     time.Time `json:"occurred_at"`
+}
+
+func (e PasswordChanged) EventType() string     { return EventTypePasswordChanged }
+func (e PasswordChanged) AggregateID() string   { return e.UserID }
+func (e PasswordChanged) OccurredAt() time.Time { return e.At }
+
+// UserDeleted ユーザーが論理削除された
+type UserDeleted struct {
+	UserID string    `json:"user_id"`
+	At     time.Time `json:"occurred_at"`
+}
+
+func (e UserDeleted) EventType() string     { return EventTypeUserDeleted }
+func (e UserDeleted) AggregateID() string   { return e.UserID }
+func (e UserDeleted) OccurredAt() time.Time { return e.At }
+
+// Events 保存されていないイベントを記録した順に返す
+func (u *User) Events() []DomainEvent {
+	return u.events
+}
+
+// ClearEvents 保存したイベントを取り除く。リポジトリが書き込んだ後に呼び出す
+func (u *User) ClearEvents() {
+	u.events = nil
+}
+
+func (u *User) recordEvent(event DomainEvent) {
+	u.events = append(u.events, event)
+}
@@ -0,0 +1,22 @@
+package model
+
+// UserFactory ユーザーの作成と変更に使うID生成器と、メールアドレス・パスワードの設定をまとめる。
+// 設定から一度だけ作成し、ユーザーを扱うユースケースに渡す
+type UserFactory struct {
+	ids      IDGenerator
+	email    emailRules
+	password passwordRules
+}
+
+// NewUserFactory パスワードの設定が不正な場合はエラーを返す
+func NewUserFactory(ids IDGenerator, emailPolicy EmailPolicy, passwordPolicy PasswordPolicy) (*UserFactory, error) {
+	password, err := newPasswordRules(passwordPolicy)
+	if err != nil {
+		return nil, err
+	}
+	return &UserFactory{
+		ids:      ids,
+		email:    newEmailRules(emailPolicy),
+		password: password,
+	}, nil
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxStatusReasonLength 状態を変更した理由の文字数の上限
+const maxStatusReasonLength = 255
+
+// UserStatus アカウントの状態。登録直後はpendingで、メールアドレスを確認するとactiveになる。
+// 管理者はactiveとsuspendedを行き来させることができ、deactivatedからは戻せない
+type UserStatus string
+
+const (
+	UserStatusPending     UserStatus = "pending"
+	UserStatusActive      UserStatus = "active"
+	UserStatusSuspended   UserStatus = "suspended"
+	UserStatusDeactivated UserStatus = "deactivated"
+)
+
+// userStatusTransitions 状態ごとに遷移できる先の状態
+var userStatusTransitions = map[UserStatus][]UserStatus{
+	UserStatusPending:   {UserStatusActive, UserStatusSuspended, UserStatusDeactivated},
+	UserStatusActive:    {UserStatusSuspended, UserStatusDeactivated},
+	UserStatusSuspended: {UserStatusActive, UserStatusPending, UserStatusDeactivated},
+}
+
+// ParseUserStatus 文字列をアカウントの状態として検証する
+func ParseUserStatus(status string) (UserStatus, error) {
+	switch UserStatus(status) {
+	case UserStatusPending, UserStatusActive, UserStatusSuspended, UserStatusDeactivated:
+		return UserStatus(status), nil
+	}
+	return "", NewValidationError(CodeInvalidFormat, "ユーザーの状態が不正です")
+}
+
+func (s UserStatus) canTransitionTo(next UserStatus) bool {
+	for _, status := range userStatusTransitions[s] {
+		if status == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckSignIn ログインやアクセストークンの利用を認めない状態であればエラーを返す
+func (u *User) CheckSignIn() error {
+	switch u.status {
+	case UserStatusSuspended:
+		return NewForbiddenError("アカウントは利用停止中です")
+	case UserStatusDeactivated:
+		return NewForbiddenError("アカウントは無効化されています")
+	}
+	return nil
+}
+
+// Suspend アカウントの利用を停止する
+func (u *User) Suspend(reason string, at time.Time) error {
+	return u.transition(UserStatusSuspended, reason, at)
+}
+
+// Reinstate 利用停止を解除する。メールアドレスが未確認であれば確認待ちに戻す
+func (u *User) Reinstate(reason string, at time.Time) error {
+	if u.status != UserStatusSuspended {
+		return NewConflictError("利用停止中のユーザーではありません", nil)
+	}
+	next := UserStatusActive
+	if !u.IsEmailVerified() {
+		next = UserStatusPending
+	}
+	return u.transition(next, reason, at)
+}
+
+// Deactivate アカウントを無効化する。無効化したアカウントは元に戻せない
+func (u *User) Deactivate(reason string, at time.Time) error {
+	return u.transition(UserStatusDeactivated, reason, at)
+}
+
+// activate メールアドレスの確認に伴って確認待ちのアカウントを有効にする。それ以外の状態は変えない
+func (u *User) activate(at time.Time) {
+	if u.status == UserStatusPending {
+		u.status = UserStatusActive
+		u.statusChangedAt = &at
+	}
+}
+
+func (u *User) transition(next UserStatus, reason string, at time.Time) error {
+	if strings.TrimSpace(reason) == "" {
+		return NewFieldsError([]FieldError{{Field: "reason", Code: CodeTooShort, Message: "理由を入力してください"}})
+	}
+	if utf8.RuneCountInString(reason) > maxStatusReasonLength {
+		return NewFieldsError([]FieldError{{Field: "reason", Code: CodeTooLong, Message: fmt.Sprintf("理由は%d文字以下で入力してください", maxStatusReasonLength)}})
+	}
+	if !u.status.canTransitionTo(next) {
+		return NewConflictError("現在の状態からは変更できません", nil)
+	}
+	u.status = next
+	u.statusReason = reason
+	u.statusChangedAt = &at
+	u.updatedAt = at
+	return nil
+}
@@ -1,10 +1,15 @@
 package model
 
 import (
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewUser(t *testing.T) {
+	factory := newTestUserFactory(t)
 	type TestCase struct {
 		name          string
 		username      string
@@ -23,10 +28,13 @@ func TestNewUser(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := NewUser(tc.username, tc.email, tc.password)
+			_, err := factory.NewUser(tc.username, tc.email, tc.password)
 			if tc.expectedError && err == nil {
 				t.Errorf("Expected error, but got nil")
 			}
+			if tc.expectedError && !errors.Is(err, ErrValidation) {
+				t.Errorf("Expected validation error, but got %v", err)
+			}
 		})
 	}
 }
@@ -55,6 +63,7 @@ func TestNewUserName(t *testing.T) {
 }
 
 func TestNewUserEmail(t *testing.T) {
+	factory := newTestUserFactory(t)
 	type TestCase struct {
 		name          string
 		input         string
@@ -67,19 +76,103 @@ func TestNewUserEmail(t *testing.T) {
 		{"アットマークなし", "testexample.com", true, "メールアドレスが不正です"},
 		{"ドメインなし", "test@", true, "メールアドレスが不正です"},
 		{"空文字列", "", true, "メールアドレスが不正です"},
+		{"国際化ドメイン", "test@例え.jp", false, ""},
+		{"非ASCIIのローカル部", "テスト@example.jp", false, ""},
+		{"連続したドット", "test..email@example.com", true, "メールアドレスが不正です"},
+		{"トップレベルドメインなし", "test@localhost", true, "メールアドレスが不正です"},
+		{"ローカル部が64オクテットを超える", strings.Repeat("a", 65) + "@example.com", true, "メールアドレスが長すぎます"},
+		{"全体が254オクテットを超える", strings.Repeat("a", 60) + "@" + strings.Repeat("b", 63) + "." + strings.Repeat("c", 63) + "." + strings.Repeat("d", 63) + ".com", true, "メールアドレスが長すぎます"},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := NewUserEmail(tc.input)
+			_, err := factory.NewUserEmail(tc.input)
 			if tc.expectedError && err == nil {
 				t.Errorf("Expected error, but got nil")
 			}
+			if !tc.expectedError && err != nil {
+				t.Errorf("Expected no error, but got %v", err)
+			}
+			if tc.expectedError && err != nil && err.Error() != tc.errorMessage {
+				t.Errorf("Expected %q, but got %q", tc.errorMessage, err.Error())
+			}
+		})
+	}
+}
+
+func TestEmail_Normalize(t *testing.T) {
+	type TestCase struct {
+		name              string
+		policy            EmailPolicy
+		input             string
+		expectedValue     string
+		expectedCanonical string
+	}
+	testCases := []TestCase{
+		{"前後の空白を除きドメインを小文字にする", EmailPolicy{}, "  Test.User@Example.COM ", "Test.User@example.com", "test.user@example.com"},
+		{"国際化ドメインはpunycodeにする", EmailPolicy{}, "test@例え.JP", "test@xn--r8jz45g.jp", "test@xn--r8jz45g.jp"},
+		{"設定が無効な場合はサブアドレスを残す", EmailPolicy{}, "User+Tag@example.com", "User+Tag@example.com", "user+tag@example.com"},
+		{"設定が有効な場合はサブアドレスを取り除く", EmailPolicy{CanonicalizePlusAddress: true}, "User+Tag@example.com", "User+Tag@example.com", "user@example.com"},
+		{"設定が有効でも先頭の\"+\"は取り除かない", EmailPolicy{CanonicalizePlusAddress: true}, "+tag@example.com", "+tag@example.com", "+tag@example.com"},
+		{"ローカル部はUnicodeでも小文字にする", EmailPolicy{}, "Ünïcode@example.com", "Ünïcode@example.com", "ünïcode@example.com"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory, err := NewUserFactory(testIDs, tc.policy, DefaultPasswordPolicy())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			email, err := factory.NewUserEmail(tc.input)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %v", err)
+			}
+			if email.String() != tc.expectedValue {
+				t.Errorf("Expected %q, but got %q", tc.expectedValue, email.String())
+			}
+			if canonical := factory.CanonicalizeEmail(tc.input); canonical != tc.expectedCanonical {
+				t.Errorf("Expected canonical %q, but got %q", tc.expectedCanonical, canonical)
+			}
 		})
 	}
 }
 
+func TestUserFactory_EmailCanonicalRules(t *testing.T) {
+	off, err := NewUserFactory(testIDs, EmailPolicy{}, DefaultPasswordPolicy())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	on, err := NewUserFactory(testIDs, EmailPolicy{CanonicalizePlusAddress: true}, DefaultPasswordPolicy())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if off.EmailCanonicalRules() == on.EmailCanonicalRules() {
+		t.Errorf("Expected different rules, but both are %q", off.EmailCanonicalRules())
+	}
+}
+
+func TestNewUserEmail_DisposableDomain(t *testing.T) {
+	factory, err := NewUserFactory(testIDs, EmailPolicy{DisposableDomains: []string{"Mailinator.com"}}, DefaultPasswordPolicy())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, input := range []string{"test@mailinator.com", "test@sub.MAILINATOR.com"} {
+		_, err := factory.NewUserEmail(input)
+		var domainErr *Error
+		if !errors.As(err, &domainErr) || domainErr.Code != CodeDisposableDomain {
+			t.Errorf("Expected disposable domain error for %q, but got %v", input, err)
+		}
+	}
+	if _, err := factory.NewUserEmail("test@notmailinator.com"); err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+}
+
 func TestNewPassword(t *testing.T) {
+	factory := newTestUserFactory(t)
 	type TestCase struct {
 		name          string
 		input         string
@@ -90,16 +183,533 @@ func TestNewPassword(t *testing.T) {
 		{"有効なパスワード", "password123", false, ""},
 		{"最小長パスワード", "pass123a", false, ""},
 		{"短すぎるパスワード", "pass123", true, "パスワードは8文字以上で入力してください"},
-		{"数字なし", "password", true, "パスワードは英数字を含む必要があります"},
-		{"文字なし", "12345678", true, "パスワードは英数字を含む必要があります"},
+		{"数字なし", "password", true, "パスワードは数字を含む必要があります"},
+		{"文字なし", "12345678", true, "パスワードは英字を含む必要があります"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := factory.NewPassword(tc.input)
+			if tc.expectedError && err == nil {
+				t.Errorf("Expected error, but got nil")
+			}
+		})
+	}
+}
+
+func TestNewUserID(t *testing.T) {
+	t.Run("UUIDv7形式のIDが生成される", func(t *testing.T) {
+		id := NewUserID(NewUUIDv7Generator())
+		if _, err := ParseUserID(id.String()); err != nil {
+			t.Errorf("Expected valid UUID, but got %q", id.String())
+		}
+		if id.String()[14] != '7' {
+			t.Errorf("Expected version 7, but got %q", id.String())
+		}
+	})
+
+	t.Run("生成順にソート可能", func(t *testing.T) {
+		ids := NewUUIDv7Generator()
+		prev := NewUserID(ids).String()
+		for i := 0; i < 1000; i++ {
+			next := NewUserID(ids).String()
+			if next <= prev {
+				t.Fatalf("Expected %q > %q", next, prev)
+			}
+			prev = next
+		}
+	})
+}
+
+type fixedIDGenerator struct {
+	id string
+}
+
+func (g fixedIDGenerator) Generate() string {
+	return g.id
+}
+
+func TestNewUserFactory_IDGenerator(t *testing.T) {
+	factory, err := NewUserFactory(fixedIDGenerator{id: "fixed-id"}, EmailPolicy{}, DefaultPasswordPolicy())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	user, err := factory.NewUser("testuser", "test@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if user.ID() != "fixed-id" {
+		t.Errorf("Expected fixed-id, but got %q", user.ID())
+	}
+}
+
+func TestParseUserID(t *testing.T) {
+	type TestCase struct {
+		name          string
+		input         string
+		expected      string
+		expectedError bool
+	}
+	testCases := []TestCase{
+		{"有効なID", "0190a8e4-7c3b-7d2e-9f4a-1b2c3d4e5f60", "0190a8e4-7c3b-7d2e-9f4a-1b2c3d4e5f60", false},
+		{"大文字は小文字に正規化", "0190A8E4-7C3B-7D2E-9F4A-1B2C3D4E5F60", "0190a8e4-7c3b-7d2e-9f4a-1b2c3d4e5f60", false},
+		{"空文字列", "", "", true},
+		{"ハイフンなし", "0190a8e47c3b7d2e9f4a1b2c3d4e5f60", "", true},
+		{"16進数以外", "0190a8e4-7c3b-7d2e-9f4a-1b2c3d4e5f6z", "", true},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := NewPassword(tc.input)
+			id, err := ParseUserID(tc.input)
 			if tc.expectedError && err == nil {
 				t.Errorf("Expected error, but got nil")
 			}
+			if !tc.expectedError && id.String() != tc.expected {
+				t.Errorf("Expected %q, but got %q", tc.expected, id.String())
+			}
+		})
+	}
+}
+
+func TestNewUser_CollectsAllErrors(t *testing.T) {
+	factory := newTestUserFactory(t)
+	_, err := factory.NewUser("ab", "invalid-email", "short1")
+
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("Expected *Error, but got %v", err)
+	}
+	if len(e.Fields) != 3 {
+		t.Fatalf("Expected 3 field errors, but got %d", len(e.Fields))
+	}
+	expected := []string{"username", "email", "password"}
+	for i, field := range expected {
+		if e.Fields[i].Field != field {
+			t.Errorf("Expected field %q, but got %q", field, e.Fields[i].Field)
+		}
+		if e.Fields[i].Code == "" {
+			t.Errorf("Expected code for field %q", field)
+		}
+	}
+}
+
+func TestUser_Change(t *testing.T) {
+	factory := newTestUserFactory(t)
+	t.Run("指定した項目のみ変更される", func(t *testing.T) {
+		user, err := factory.NewUser("testuser", "test@example.com", "password123")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		password := user.PasswordHash()
+		updatedAt := user.UpdatedAt()
+
+		if err := user.ChangeEmail(factory, "new@example.com"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if user.Email() != "new@example.com" {
+			t.Errorf("Expected new@example.com, but got %q", user.Email())
+		}
+		if user.Username() != "testuser" || user.PasswordHash() != password {
+			t.Errorf("Expected other fields to be unchanged")
+		}
+		if user.UpdatedAt().Before(updatedAt) {
+			t.Errorf("Expected UpdatedAt to be bumped")
+		}
+	})
+
+	t.Run("パスワードはハッシュ化される", func(t *testing.T) {
+		user, _ := factory.NewUser("testuser", "test@example.com", "password123")
+
+		if err := user.ChangePassword(factory, "newpassword1"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if user.PasswordHash() == "newpassword1" {
+			t.Errorf("Expected password to be hashed")
+		}
+	})
+
+	t.Run("無効な値の場合は何も変更しない", func(t *testing.T) {
+		user, _ := factory.NewUser("testuser", "test@example.com", "password123")
+		before := user
+
+		username := "newuser"
+		email := "invalid"
+		err := user.Change(factory, UserChanges{Username: &username, Email: &email})
+		if !errors.Is(err, ErrValidation) {
+			t.Fatalf("Expected validation error, but got %v", err)
+		}
+		if !reflect.DeepEqual(user, before) {
+			t.Errorf("Expected user to be unchanged")
+		}
+	})
+
+	t.Run("変更前の値が期待した値と一致しない場合は何も変更しない", func(t *testing.T) {
+		user, _ := factory.NewUser("testuser", "test@example.com", "password123")
+
+		expected := "other"
+		username := "newuser"
+		err := user.Change(factory, UserChanges{Username: &username, ExpectedUsername: &expected})
+		if !errors.Is(err, ErrConflict) {
+			t.Fatalf("Expected conflict error, but got %v", err)
+		}
+		if user.Username() != "testuser" {
+			t.Errorf("Expected testuser, but got %q", user.Username())
+		}
+	})
+
+	t.Run("変更する項目が無い場合は更新日時を変えない", func(t *testing.T) {
+		user, _ := factory.NewUser("testuser", "test@example.com", "password123")
+		updatedAt := user.UpdatedAt()
+
+		expected := "testuser"
+		if err := user.Change(factory, UserChanges{ExpectedUsername: &expected}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !user.UpdatedAt().Equal(updatedAt) {
+			t.Errorf("Expected UpdatedAt to be unchanged")
+		}
+	})
+
+	t.Run("ユーザー名の検証", func(t *testing.T) {
+		user, _ := factory.NewUser("testuser", "test@example.com", "password123")
+
+		if err := user.Rename("ab"); err == nil {
+			t.Errorf("Expected error, but got nil")
+		}
+	})
+}
+
+func TestUser_Authenticate(t *testing.T) {
+	factory := newTestUserFactory(t)
+	user, err := factory.NewUser("testuser", "test@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if user.Role() != RoleMember {
+		t.Errorf("Expected role member, but got %q", user.Role())
+	}
+
+	if _, err := user.Authenticate(factory, "password123"); err != nil {
+		t.Errorf("Expected nil, but got %v", err)
+	}
+	if _, err := user.Authenticate(factory, "wrongpassword1"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected unauthorized error, but got %v", err)
+	}
+	if err := factory.RejectAuthentication("password123"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected unauthorized error, but got %v", err)
+	}
+}
+
+func TestUser_VerifyEmail(t *testing.T) {
+	factory := newTestUserFactory(t)
+	t.Run("作成直後は未確認で、確認すると確認済みになる", func(t *testing.T) {
+		user, err := factory.NewUser("testuser", "test@example.com", "password123")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if user.IsEmailVerified() {
+			t.Errorf("Expected new user to be unverified")
+		}
+
+		if err := user.VerifyEmail("test@example.com", time.Now()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !user.IsEmailVerified() {
+			t.Errorf("Expected user to be verified")
+		}
+	})
+
+	t.Run("発行時と異なるメールアドレスは確認できない", func(t *testing.T) {
+		user := ReconstructUser(UserSnapshot{Email: "new@example.com"})
+
+		if err := user.VerifyEmail("old@example.com", time.Now()); !errors.Is(err, ErrValidation) {
+			t.Errorf("Expected validation error, but got %v", err)
+		}
+		if user.IsEmailVerified() {
+			t.Errorf("Expected user to stay unverified")
+		}
+	})
+
+	t.Run("メールアドレスを変更すると未確認に戻る", func(t *testing.T) {
+		verifiedAt := time.Now()
+		user := ReconstructUser(UserSnapshot{Email: "test@example.com", EmailVerifiedAt: &verifiedAt})
+
+		if err := user.ChangeEmail(factory, "test@example.com"); err != nil || !user.IsEmailVerified() {
+			t.Errorf("Expected unchanged email to stay verified, err=%v", err)
+		}
+		if err := user.ChangeEmail(factory, "new@example.com"); err != nil || user.IsEmailVerified() {
+			t.Errorf("Expected changed email to be unverified, err=%v", err)
+		}
+	})
+}
+
+func TestUser_Status(t *testing.T) {
+	factory := newTestUserFactory(t)
+	now := time.Now()
+
+	t.Run("作成直後は確認待ちで、メールアドレスを確認すると有効になる", func(t *testing.T) {
+		user, err := factory.NewUser("testuser", "test@example.com", "password123")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if user.Status() != UserStatusPending {
+			t.Errorf("Expected pending, but got %q", user.Status())
+		}
+		if err := user.VerifyEmail("test@example.com", now); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if user.Status() != UserStatusActive {
+			t.Errorf("Expected active, but got %q", user.Status())
+		}
+	})
+
+	t.Run("利用停止中はメールアドレスを確認しても有効にならない", func(t *testing.T) {
+		user := ReconstructUser(UserSnapshot{Email: "test@example.com", Status: UserStatusSuspended})
+
+		if err := user.VerifyEmail("test@example.com", now); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if user.Status() != UserStatusSuspended {
+			t.Errorf("Expected suspended, but got %q", user.Status())
+		}
+	})
+
+	t.Run("利用停止を解除するとメールアドレスの確認状況に応じた状態に戻る", func(t *testing.T) {
+		verified := ReconstructUser(UserSnapshot{EmailVerifiedAt: &now, Status: UserStatusSuspended})
+		unverified := ReconstructUser(UserSnapshot{Status: UserStatusSuspended})
+
+		if err := verified.Reinstate("解除", now); err != nil || verified.Status() != UserStatusActive {
+			t.Errorf("Expected active, but got %q, err=%v", verified.Status(), err)
+		}
+		if err := unverified.Reinstate("解除", now); err != nil || unverified.Status() != UserStatusPending {
+			t.Errorf("Expected pending, but got %q, err=%v", unverified.Status(), err)
+		}
+	})
+
+	type TestCase struct {
+		name     string
+		from     UserStatus
+		change   func(u *User) error
+		expected UserStatus
+		err      error
+	}
+	suspend := func(u *User) error { return u.Suspend("規約違反", now) }
+	reinstate := func(u *User) error { return u.Reinstate("異議申し立てを承認", now) }
+	deactivate := func(u *User) error { return u.Deactivate("退会", now) }
+	testCases := []TestCase{
+		{"有効なユーザーを利用停止できる", UserStatusActive, suspend, UserStatusSuspended, nil},
+		{"利用停止中のユーザーを無効化できる", UserStatusSuspended, deactivate, UserStatusDeactivated, nil},
+		{"有効なユーザーは再開できない", UserStatusActive, reinstate, UserStatusActive, ErrConflict},
+		{"利用停止中のユーザーは再度利用停止できない", UserStatusSuspended, suspend, UserStatusSuspended, ErrConflict},
+		{"無効化されたユーザーは利用停止できない", UserStatusDeactivated, suspend, UserStatusDeactivated, ErrConflict},
+		{"無効化されたユーザーは再開できない", UserStatusDeactivated, reinstate, UserStatusDeactivated, ErrConflict},
+		{"理由が空の場合は変更できない", UserStatusActive, func(u *User) error { return u.Suspend(" ", now) }, UserStatusActive, ErrValidation},
+		{"理由が長すぎる場合は変更できない", UserStatusActive, func(u *User) error { return u.Suspend(strings.Repeat("あ", 256), now) }, UserStatusActive, ErrValidation},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			user := ReconstructUser(UserSnapshot{EmailVerifiedAt: &now, Status: tc.from})
+
+			err := tc.change(user)
+
+			if tc.err == nil && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if tc.err != nil && !errors.Is(err, tc.err) {
+				t.Errorf("Expected %v, but got %v", tc.err, err)
+			}
+			if user.Status() != tc.expected {
+				t.Errorf("Expected %q, but got %q", tc.expected, user.Status())
+			}
 		})
 	}
 }
+
+func TestUser_CheckSignIn(t *testing.T) {
+	for _, status := range []UserStatus{UserStatusPending, UserStatusActive} {
+		if err := ReconstructUser(UserSnapshot{Status: status}).CheckSignIn(); err != nil {
+			t.Errorf("Expected %q user to sign in, but got %v", status, err)
+		}
+	}
+	for _, status := range []UserStatus{UserStatusSuspended, UserStatusDeactivated} {
+		if err := ReconstructUser(UserSnapshot{Status: status}).CheckSignIn(); !errors.Is(err, ErrForbidden) {
+			t.Errorf("Expected forbidden error for %q user, but got %v", status, err)
+		}
+	}
+}
+
+func TestUser_Restore(t *testing.T) {
+	deletedAt := time.Now()
+	user := ReconstructUser(UserSnapshot{DeletedAt: &deletedAt})
+
+	if err := user.Restore(time.Now()); err != nil || user.IsDeleted() {
+		t.Errorf("Expected user to be restored, err=%v", err)
+	}
+	if err := user.Restore(time.Now()); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected conflict error for not deleted user, but got %v", err)
+	}
+}
+
+func TestUser_Delete(t *testing.T) {
+	user := ReconstructUser(UserSnapshot{})
+
+	if err := user.Delete(time.Now()); err != nil || !user.IsDeleted() {
+		t.Errorf("Expected user to be deleted, err=%v", err)
+	}
+	if err := user.Delete(time.Now()); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected conflict error for deleted user, but got %v", err)
+	}
+}
+
+func TestUser_Events(t *testing.T) {
+	factory := newTestUserFactory(t)
+	user, err := factory.NewUser("testuser", "test@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	email := "new@example.com"
+	password := "newpassword1"
+	username := "newuser"
+	if err := user.Change(factory, UserChanges{Username: &username, Email: &email, Password: &password}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := user.Delete(time.Now()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var types []string
+	for _, event := range user.Events() {
+		if event.AggregateID() != user.ID() {
+			t.Errorf("Expected aggregate ID %q, but got %q", user.ID(), event.AggregateID())
+		}
+		types = append(types, event.EventType())
+	}
+	expected := []string{EventTypeUserRegistered, EventTypeEmailChanged, EventTypePasswordChanged, EventTypeUserDeleted}
+	if !reflect.DeepEqual(types, expected) {
+		t.Errorf("Expected events %v, but got %v", expected, types)
+	}
+	if changed := user.Events()[1].(EmailChanged); changed.PreviousEmail != "test@example.com" || changed.Email != "new@example.com" {
+		t.Errorf("Expected email change from test@example.com to new@example.com, but got %+v", changed)
+	}
+
+	user.ClearEvents()
+	if len(user.Events()) != 0 {
+		t.Errorf("Expected events to be cleared")
+	}
+	if err := user.Rename("otheruser"); err != nil || len(user.Events()) != 0 {
+		t.Errorf("Expected no events for renaming, but got %v, err=%v", user.Events(), err)
+	}
+}
+
+func TestNewOutboxMessage(t *testing.T) {
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	message, err := NewOutboxMessage(testIDs, PasswordChanged{UserID: "user-id", At: at})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if message.ID == "" || message.EventType != EventTypePasswordChanged || message.AggregateID != "user-id" || !message.OccurredAt.Equal(at) {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+	if string(message.Payload) != `{"user_id":"user-id","occurred_at":"2025-01-01T00:00:00Z"}` {
+		t.Errorf("Unexpected payload: %s", message.Payload)
+	}
+	if message.PublishedAt != nil || message.NextAttemptAt.IsZero() {
+		t.Errorf("Expected message to be ready for publishing, but got %+v", message)
+	}
+}
+
+func TestDiffUsers(t *testing.T) {
+	before := ReconstructUser(UserSnapshot{ID: "user-id", Username: "olduser", Email: "old@example.com", PasswordHash: "old-hash", Role: RoleMember, Status: UserStatusActive, Version: 1})
+	after := ReconstructUser(UserSnapshot{ID: "user-id", Username: "newuser", Email: "old@example.com", PasswordHash: "new-hash", Role: RoleMember, Status: UserStatusActive, Version: 2})
+
+	changes := DiffUsers(before, after)
+
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, but got %+v", changes)
+	}
+	if changes[0].Field != "username" || *changes[0].Before != "olduser" || *changes[0].After != "newuser" {
+		t.Errorf("Expected username change, but got %+v", changes[0])
+	}
+	if changes[1].Field != "password" || *changes[1].Before != "[REDACTED]" || *changes[1].After != "[REDACTED]" {
+		t.Errorf("Expected redacted password change, but got %+v", changes[1])
+	}
+
+	created := DiffUsers(nil, after)
+	for _, change := range created {
+		if change.Before != nil || change.After == nil {
+			t.Errorf("Expected only after values for created user, but got %+v", change)
+		}
+	}
+	if len(DiffUsers(after, after)) != 0 {
+		t.Errorf("Expected no changes for the same user")
+	}
+}
+
+func TestRole_Can(t *testing.T) {
+	type TestCase struct {
+		role       Role
+		permission Permission
+		expected   bool
+	}
+	testCases := []TestCase{
+		{RoleAdmin, PermissionAssignRoles, true},
+		{RoleAdmin, PermissionRestoreUsers, true},
+		{RoleSupport, PermissionReadUsers, true},
+		{RoleSupport, PermissionSuspendUsers, true},
+		{RoleSupport, PermissionDeleteUsers, false},
+		{RoleSupport, PermissionAssignRoles, false},
+		{RoleSupport, PermissionReadAuditLogs, true},
+		{RoleMember, PermissionReadAuditLogs, false},
+		{RoleMember, PermissionReadUsers, false},
+		{Role("unknown"), PermissionReadUsers, false},
+	}
+
+	for _, tc := range testCases {
+		if actual := tc.role.Can(tc.permission); actual != tc.expected {
+			t.Errorf("Expected %q.Can(%q) to be %v, but got %v", tc.role, tc.permission, tc.expected, actual)
+		}
+	}
+}
+
+func TestUser_AssignRole(t *testing.T) {
+	user := ReconstructUser(UserSnapshot{Role: RoleMember})
+
+	if err := user.AssignRole("support", time.Now()); err != nil || user.Role() != RoleSupport {
+		t.Errorf("Expected support role, but got %q, err=%v", user.Role(), err)
+	}
+	if err := user.AssignRole("owner", time.Now()); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected validation error, but got %v", err)
+	}
+	if user.Role() != RoleSupport {
+		t.Errorf("Expected role to stay support, but got %q", user.Role())
+	}
+}
+
+func TestUser_CheckVersion(t *testing.T) {
+	user := User{version: 2}
+
+	if err := user.CheckVersion(2); err != nil {
+		t.Errorf("Expected nil, but got %v", err)
+	}
+	if err := user.CheckVersion(0); err != nil {
+		t.Errorf("Expected nil for unconditional check, but got %v", err)
+	}
+	if err := user.CheckVersion(1); !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("Expected precondition failed error, but got %v", err)
+	}
+}
+
+var testIDs = NewUUIDv7Generator()
+
+func newTestUserFactory(t *testing.T) *UserFactory {
+	t.Helper()
+	factory, err := NewUserFactory(testIDs, EmailPolicy{}, DefaultPasswordPolicy())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return factory
+}
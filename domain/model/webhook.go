@@ -0,0 +1,334 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxWebhookURLLength         = 2048
+	maxWebhookDescriptionLength = 255
+	// webhookSecretPrefix 署名用の秘密鍵であることが分かるように付ける接頭辞
+	webhookSecretPrefix = "whsec_"
+)
+
+// 配信時に付けるヘッダー。受信側はX-Webhook-IDで重複を取り除き、X-Webhook-TimestampとX-Webhook-Signatureで改ざんと再送攻撃を防ぐ
+const (
+	WebhookHeaderID        = "X-Webhook-ID"
+	WebhookHeaderEvent     = "X-Webhook-Event"
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+// webhookEventTypes Webhookで購読できるイベントの種類
+var webhookEventTypes = []string{EventTypeUserRegistered, EventTypeEmailChanged, EventTypePasswordChanged, EventTypeUserDeleted}
+
+// WebhookSubscription ドメインイベントをHTTPで通知する先。Secretは配信の署名に使うため平文で保持し、作成時のみ利用者に返す
+type WebhookSubscription struct {
+	ID          string
+	URL         string
+	EventTypes  []string
+	Description string
+	Secret      string
+	Active      bool
+	// ConsecutiveFailures 最後に配信に成功してから続けて失敗した回数
+	ConsecutiveFailures int
+	DisabledAt          *time.Time
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// NewWebhookSubscription 項目を検証し、署名用の秘密鍵を生成して有効な状態で作成する
+func NewWebhookSubscription(ids IDGenerator, rawURL string, eventTypes []string, description string) (WebhookSubscription, error) {
+	if err := validateWebhook(rawURL, eventTypes, description); err != nil {
+		return WebhookSubscription{}, err
+	}
+	secret, err := generateToken()
+	if err != nil {
+		return WebhookSubscription{}, err
+	}
+	now := time.Now()
+
+	return WebhookSubscription{
+		ID:          ids.Generate(),
+		URL:         rawURL,
+		EventTypes:  eventTypes,
+		Description: description,
+		Secret:      webhookSecretPrefix + secret,
+		Active:      true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, nil
+}
+
+// Change NewWebhookSubscriptionと同じ検証を行い、全ての項目が有効な場合のみ変更を反映する
+func (s *WebhookSubscription) Change(rawURL string, eventTypes []string, description string, at time.Time) error {
+	if err := validateWebhook(rawURL, eventTypes, description); err != nil {
+		return err
+	}
+	s.URL = rawURL
+	s.EventTypes = eventTypes
+	s.Description = description
+	s.UpdatedAt = at
+	return nil
+}
+
+// Enable 配信を再開する。失敗した回数は数え直す
+func (s *WebhookSubscription) Enable(at time.Time) {
+	s.Active = true
+	s.ConsecutiveFailures = 0
+	s.DisabledAt = nil
+	s.UpdatedAt = at
+}
+
+// Disable 配信を止める。配信待ちのものは再開するまで送らない
+func (s *WebhookSubscription) Disable(at time.Time) {
+	if !s.Active {
+		return
+	}
+	s.Active = false
+	s.DisabledAt = &at
+	s.UpdatedAt = at
+}
+
+// Subscribes eventTypeのイベントを購読していればtrueを返す
+func (s *WebhookSubscription) Subscribes(eventType string) bool {
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseWebhookID 購読先と配信のIDを検証する。どちらもUUIDで採番する
+func ParseWebhookID(id string) (string, error) {
+	if !isUUID(id) {
+		return "", NewValidationError(CodeInvalidFormat, "IDが不正です")
+	}
+	return strings.ToLower(id), nil
+}
+
+func validateWebhook(rawURL string, eventTypes []string, description string) error {
+	var fields []FieldError
+	if err := validateWebhookURL(rawURL); err != nil {
+		fields = append(fields, FieldError{Field: "url", Code: CodeInvalidFormat, Message: err.Error()})
+	}
+	if len(eventTypes) == 0 {
+		fields = append(fields, FieldError{Field: "events", Code: CodeTooShort, Message: "購読するイベントを1つ以上指定してください"})
+	}
+	for _, eventType := range eventTypes {
+		if !isWebhookEventType(eventType) {
+			fields = append(fields, FieldError{Field: "events", Code: CodeInvalidFormat, Message: fmt.Sprintf("イベントの種類が不正です: %s", eventType)})
+			break
+		}
+	}
+	if utf8.RuneCountInString(description) > maxWebhookDescriptionLength {
+		fields = append(fields, FieldError{Field: "description", Code: CodeTooLong, Message: fmt.Sprintf("説明は%d文字以下で入力してください", maxWebhookDescriptionLength)})
+	}
+	if len(fields) > 0 {
+		return NewFieldsError(fields)
+	}
+	return nil
+}
+
+func validateWebhookURL(rawURL string) error {
+	if len(rawURL) > maxWebhookURLLength {
+		return fmt.Errorf("URLは%d文字以下で入力してください", maxWebhookURLLength)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("URLはhttpsの絶対URLで入力してください")
+	}
+	if u.User != nil || u.Fragment != "" {
+		return fmt.Errorf("URLに認証情報やフラグメントは含められません")
+	}
+	// 名前解決後のアドレスは送信時に確認する。ここでは明らかに内部を指すURLを早めに拒否する
+	if ip := net.ParseIP(u.Hostname()); (ip != nil && !IsWebhookAddressAllowed(ip)) || strings.EqualFold(u.Hostname(), "localhost") {
+		return fmt.Errorf("内部のネットワークのアドレスは指定できません")
+	}
+	return nil
+}
+
+// IsWebhookAddressAllowed 配信先として許可するアドレスかを判定する。サーバー内部や社内のネットワークへの
+// リクエストに使われないよう、ループバック・プライベート・リンクローカルなどのアドレスは許可しない
+func IsWebhookAddressAllowed(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		// 0.0.0.0/8と、キャリアグレードNATの100.64.0.0/10
+		if ip[0] == 0 || ip[0] == 100 && ip[1]&0xc0 == 64 {
+			return false
+		}
+	}
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+func isWebhookEventType(eventType string) bool {
+	for _, t := range webhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus 配信の状態。失敗しても上限の回数まではpendingのまま再送する
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// ParseWebhookDeliveryStatus 文字列を配信の状態として検証する
+func ParseWebhookDeliveryStatus(status string) (WebhookDeliveryStatus, error) {
+	switch WebhookDeliveryStatus(status) {
+	case WebhookDeliveryPending, WebhookDeliverySucceeded, WebhookDeliveryFailed:
+		return WebhookDeliveryStatus(status), nil
+	}
+	return "", NewValidationError(CodeInvalidFormat, "配信の状態が不正です")
+}
+
+// WebhookDelivery 1つのイベントを1つの購読先に届ける配信。Payloadは送信する本文で、再送しても変わらない
+type WebhookDelivery struct {
+	ID             string
+	SubscriptionID string
+	EventID        string
+	EventType      string
+	Payload        []byte
+	Status         WebhookDeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// webhookBody 配信する本文。dataにはイベントの内容をそのまま入れる
+type webhookBody struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// NewWebhookDelivery アウトボックスのメッセージを購読先に直ちに送る配信を作成する
+func NewWebhookDelivery(ids IDGenerator, subscription *WebhookSubscription, message *OutboxMessage) (WebhookDelivery, error) {
+	payload, err := json.Marshal(webhookBody{
+		ID:         message.ID,
+		Type:       message.EventType,
+		OccurredAt: message.OccurredAt.UTC(),
+		Data:       message.Payload,
+	})
+	if err != nil {
+		return WebhookDelivery{}, err
+	}
+	now := time.Now()
+
+	return WebhookDelivery{
+		ID:             ids.Generate(),
+		SubscriptionID: subscription.ID,
+		EventID:        message.ID,
+		EventType:      message.EventType,
+		Payload:        payload,
+		Status:         WebhookDeliveryPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}, nil
+}
+
+// Succeed 配信できたことを記録する
+func (d *WebhookDelivery) Succeed(statusCode int, at time.Time) {
+	d.Attempts++
+	d.Status = WebhookDeliverySucceeded
+	d.LastStatusCode = statusCode
+	d.LastError = ""
+	d.DeliveredAt = &at
+	d.UpdatedAt = at
+}
+
+// Fail 配信できなかったことを記録する。maxAttempts回に達した場合は再送をやめ、それまではnextAttemptAtに再送する
+func (d *WebhookDelivery) Fail(statusCode int, reason string, maxAttempts int, nextAttemptAt time.Time, at time.Time) {
+	d.Attempts++
+	d.LastStatusCode = statusCode
+	d.LastError = reason
+	d.NextAttemptAt = nextAttemptAt
+	d.UpdatedAt = at
+	if d.Attempts >= maxAttempts {
+		d.Status = WebhookDeliveryFailed
+	}
+}
+
+// Replay 同じ本文で配信し直す。配信待ちのものは既に再送が予定されているためエラーを返す
+func (d *WebhookDelivery) Replay(at time.Time) error {
+	if d.Status == WebhookDeliveryPending {
+		return NewConflictError("配信待ちの配信は再送できません", nil)
+	}
+	d.Status = WebhookDeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = at
+	d.UpdatedAt = at
+	return nil
+}
+
+// WebhookDeliveryAttempt 配信を試みた1回ごとの記録。StatusCodeは応答が無かった場合に0になる
+type WebhookDeliveryAttempt struct {
+	ID          string
+	DeliveryID  string
+	StatusCode  int
+	Error       string
+	Duration    time.Duration
+	AttemptedAt time.Time
+}
+
+// NewWebhookDeliveryAttempt 配信を試みた結果を記録する。errがnilの場合は成功として扱う
+func NewWebhookDeliveryAttempt(ids IDGenerator, delivery *WebhookDelivery, statusCode int, err error, duration time.Duration, at time.Time) WebhookDeliveryAttempt {
+	attempt := WebhookDeliveryAttempt{
+		ID:          ids.Generate(),
+		DeliveryID:  delivery.ID,
+		StatusCode:  statusCode,
+		Duration:    duration,
+		AttemptedAt: at,
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	return attempt
+}
+
+// SignWebhook 配信の署名を返す。タイムスタンプ（UNIX時間の秒）と本文を"."でつないだ文字列のHMAC-SHA256で、
+// 受信側は同じ値を計算して比較し、タイムスタンプが古すぎる場合は拒否する
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature 署名が一致し、タイムスタンプがnowからtolerance以内であればtrueを返す
+func VerifyWebhookSignature(secret string, signature string, timestamp string, body []byte, now time.Time, tolerance time.Duration) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if diff := now.Sub(time.Unix(ts, 0)); diff > tolerance || diff < -tolerance {
+		return false
+	}
+	expected := SignWebhook(secret, ts, body)
+	return hmac.Equal([]byte(strings.TrimSpace(signature)), []byte(expected))
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+const (
+	DefaultAuditLogLimit = 50
+	MaxAuditLogLimit     = 200
+)
+
+// AuditLogRepository 監査ログは追記のみ行い、更新や削除の手段は提供しない
+type AuditLogRepository interface {
+	// Create 呼び出し元のトランザクション内で記録する
+	Create(ctx context.Context, log *model.AuditLog) error
+	FindAll(ctx context.Context, query AuditLogQuery) (*AuditLogPage, error)
+}
+
+// AuditLogQuery 監査ログの取得条件。新しい順に並べ、ゼロ値の項目は条件に含めない
+type AuditLogQuery struct {
+	Limit    int
+	Cursor   *AuditLogCursor
+	ActorID  string
+	TargetID string
+	Action   model.AuditAction
+	Since    time.Time
+	Until    time.Time
+}
+
+// AuditLogPage 監査ログの取得結果。続きがない場合NextCursorは空になる
+type AuditLogPage struct {
+	Logs       []*model.AuditLog
+	NextCursor string
+}
+
+// AuditLogCursor 記録日時とIDの組で次ページの開始位置を表す
+type AuditLogCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+func NewAuditLogCursor(log *model.AuditLog) AuditLogCursor {
+	return AuditLogCursor{CreatedAt: log.CreatedAt.UTC(), ID: log.ID}
+}
+
+// Encode クライアントには内容を意識させない文字列に変換する
+func (c AuditLogCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeAuditLogCursor Encodeした文字列を復元する
+func DecodeAuditLogCursor(s string) (AuditLogCursor, error) {
+	invalid := model.NewValidationError(model.CodeInvalidFormat, "カーソルが不正です")
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return AuditLogCursor{}, invalid
+	}
+	var cursor AuditLogCursor
+	if err := json.Unmarshal(b, &cursor); err != nil || cursor.ID == "" || cursor.CreatedAt.IsZero() {
+		return AuditLogCursor{}, invalid
+	}
+	return cursor, nil
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"time"
+)
+
+// OneTimeTokenRepository 1回限りのトークンを用途ごとに扱う。他の用途のトークンは見つからないものとする
+type OneTimeTokenRepository interface {
+	Create(ctx context.Context, token *model.OneTimeToken) error
+	FindByHash(ctx context.Context, purpose model.TokenPurpose, hash string) (*model.OneTimeToken, error)
+	// MarkUsed 未使用の場合のみ使用済みにする。既に使用済みであればfalseを返す
+	MarkUsed(ctx context.Context, id string, usedAt time.Time) (bool, error)
+	// DeleteByUserID 再発行や使用で不要になったユーザーのトークンを削除する
+	DeleteByUserID(ctx context.Context, purpose model.TokenPurpose, userID string) error
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"time"
+)
+
+// OutboxRepository 配信待ちのイベントを管理する。イベントの保存は集約のリポジトリが変更と同じトランザクションで行う
+type OutboxRepository interface {
+	// FindPending 未配信で、次に試す日時がnow以前のメッセージを保存した順に最大limit件返す
+	FindPending(ctx context.Context, now time.Time, limit int) ([]*model.OutboxMessage, error)
+	MarkPublished(ctx context.Context, id string, publishedAt time.Time) error
+	// MarkFailed 配信に失敗した回数と理由を記録し、nextAttemptAtまで配信を見送る
+	MarkFailed(ctx context.Context, id string, attempts int, nextAttemptAt time.Time, lastError string) error
+	// DeletePublished publishedBeforeより前に配信したメッセージを削除し、削除した件数を返す
+	DeletePublished(ctx context.Context, publishedBefore time.Time) (int64, error)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"time"
+)
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *model.RefreshToken) error
+	FindByHash(ctx context.Context, hash string) (*model.RefreshToken, error)
+	// MarkUsed 未使用の場合のみ使用済みにする。既に使用済みであればfalseを返す
+	MarkUsed(ctx context.Context, id string, usedAt time.Time) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string, revokedAt time.Time) error
+	RevokeByUserID(ctx context.Context, userID string, revokedAt time.Time) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,10 @@
+package repository
+
+import "context"
+
+// TransactionManager 複数のリポジトリ操作を1つのトランザクションで実行する
+type TransactionManager interface {
+	// Do fnに渡したコンテキストを使ったリポジトリ操作は同じトランザクションで実行される。
+	// fnがエラーを返した場合はロールバックする。既にトランザクション中の場合はそのトランザクションに参加する
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -1,11 +1,30 @@
 package repository
 
-import "api-sample-with-echo-ddd/domain/model"
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"time"
+)
 
+// UserRepository 論理削除されたユーザーは、明示したメソッドと条件以外では検索しない
 type UserRepository interface {
-	Create(user *model.User) (*model.User, error)
-	FindByID(id string) (*model.User, error)
-	FindAll() ([]*model.User, error)
-	Update(user *model.User) (*model.User, error)
-	Delete(user *model.User) error
+	Create(ctx context.Context, user *model.User) (*model.User, error)
+	FindByID(ctx context.Context, id string) (*model.User, error)
+	// FindByIDForUpdate トランザクション内で行ロックを取得して検索する
+	FindByIDForUpdate(ctx context.Context, id string) (*model.User, error)
+	// FindDeletedByIDForUpdate 論理削除されたユーザーを行ロックを取得して検索する
+	FindDeletedByIDForUpdate(ctx context.Context, id string) (*model.User, error)
+	// FindByEmail model.UserFactory.CanonicalizeEmailで正規化した形式が一致するユーザーを検索する
+	FindByEmail(ctx context.Context, email string) (*model.User, error)
+	// FindByUsername 大文字・小文字を区別せずに検索する
+	FindByUsername(ctx context.Context, username string) (*model.User, error)
+	FindAll(ctx context.Context, query UserQuery) (*UserPage, error)
+	Update(ctx context.Context, user *model.User) (*model.User, error)
+	// ReplacePasswordHash ハッシュがcurrentHashのままの場合のみ置き換え、置き換えたかどうかを返す
+	ReplacePasswordHash(ctx context.Context, id string, currentHash string, newHash string) (bool, error)
+	// Delete 論理削除する。ユーザー名とメールアドレスは完全に削除されるまで他のユーザーは使えない
+	Delete(ctx context.Context, user *model.User) error
+	// Purge deletedBeforeより前に論理削除されたユーザーを関連するトークンとともに完全に削除し、削除した件数を返す。
+	// 削除したユーザーごとに監査ログを記録する
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
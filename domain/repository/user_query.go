@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+const (
+	DefaultUserLimit = 20
+	MaxUserLimit     = 100
+)
+
+// UserSortField ユーザー一覧の並び替え項目
+type UserSortField string
+
+const (
+	UserSortByCreatedAt UserSortField = "created_at"
+	UserSortByUsername  UserSortField = "username"
+	UserSortByEmail     UserSortField = "email"
+)
+
+// ParseUserSortField 並び替え項目を検証する
+func ParseUserSortField(field string) (UserSortField, error) {
+	switch UserSortField(field) {
+	case UserSortByCreatedAt, UserSortByUsername, UserSortByEmail:
+		return UserSortField(field), nil
+	}
+	return "", model.NewValidationError(model.CodeInvalidFormat, "並び替え項目が不正です")
+}
+
+// UserQuery ユーザー一覧の取得条件。ゼロ値の項目は条件に含めない
+type UserQuery struct {
+	Limit          int
+	SortField      UserSortField
+	Descending     bool
+	Cursor         *UserCursor
+	EmailDomain    string
+	UsernamePrefix string
+	Status         model.UserStatus
+	IncludeDeleted bool
+	CreatedAfter   time.Time
+	CreatedBefore  time.Time
+}
+
+// UserPage ユーザー一覧の取得結果。続きがない場合NextCursorは空になる
+type UserPage struct {
+	Users      []*model.User
+	NextCursor string
+}
+
+// UserCursor 並び替え項目の値とIDの組で次ページの開始位置を表す
+type UserCursor struct {
+	SortField UserSortField `json:"s"`
+	Value     string        `json:"v"`
+	ID        string        `json:"id"`
+}
+
+func NewUserCursor(field UserSortField, user *model.User) UserCursor {
+	cursor := UserCursor{SortField: field, ID: user.ID()}
+	switch field {
+	case UserSortByUsername:
+		cursor.Value = user.Username()
+	case UserSortByEmail:
+		cursor.Value = user.Email()
+	default:
+		cursor.Value = user.CreatedAt().UTC().Format(time.RFC3339Nano)
+	}
+	return cursor
+}
+
+// Encode クライアントには内容を意識させない文字列に変換する
+func (c UserCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeUserCursor Encodeした文字列を復元する
+func DecodeUserCursor(s string) (UserCursor, error) {
+	invalid := model.NewValidationError(model.CodeInvalidFormat, "カーソルが不正です")
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return UserCursor{}, invalid
+	}
+	var cursor UserCursor
+	if err := json.Unmarshal(b, &cursor); err != nil || cursor.ID == "" {
+		return UserCursor{}, invalid
+	}
+	if _, err := ParseUserSortField(string(cursor.SortField)); err != nil {
+		return UserCursor{}, invalid
+	}
+	if cursor.SortField == UserSortByCreatedAt {
+		if _, err := time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+			return UserCursor{}, invalid
+		}
+	}
+	return cursor, nil
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"testing"
+	"time"
+)
+
+func TestUserCursor(t *testing.T) {
+	t.Run("エンコードした値を復元できる", func(t *testing.T) {
+		user := model.ReconstructUser(model.UserSnapshot{ID: "test-id", Username: "testuser", CreatedAt: time.Now()})
+
+		for _, field := range []UserSortField{UserSortByCreatedAt, UserSortByUsername, UserSortByEmail} {
+			cursor := NewUserCursor(field, user)
+			decoded, err := DecodeUserCursor(cursor.Encode())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if decoded != cursor {
+				t.Errorf("Expected %+v, but got %+v", cursor, decoded)
+			}
+		}
+	})
+
+	t.Run("不正なカーソル", func(t *testing.T) {
+		invalid := []string{
+			"",
+			"not-base64!",
+			UserCursor{SortField: "password", Value: "x", ID: "test-id"}.Encode(),
+			UserCursor{SortField: UserSortByCreatedAt, Value: "yesterday", ID: "test-id"}.Encode(),
+			UserCursor{SortField: UserSortByUsername, Value: "testuser"}.Encode(),
+		}
+
+		for _, s := range invalid {
+			if _, err := DecodeUserCursor(s); err == nil {
+				t.Errorf("Expected error for %q, but got nil", s)
+			}
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"encoding/base64"
+	"time"
+)
+
+const (
+	DefaultWebhookDeliveryLimit = 50
+	MaxWebhookDeliveryLimit     = 200
+)
+
+type WebhookSubscriptionRepository interface {
+	Create(ctx context.Context, subscription *model.WebhookSubscription) error
+	FindByID(ctx context.Context, id string) (*model.WebhookSubscription, error)
+	// FindAll 作成した順に返す
+	FindAll(ctx context.Context) ([]*model.WebhookSubscription, error)
+	// FindActive 配信を止めていない購読先を返す
+	FindActive(ctx context.Context) ([]*model.WebhookSubscription, error)
+	Update(ctx context.Context, subscription *model.WebhookSubscription) error
+	// Delete 購読先を配信履歴とともに削除する
+	Delete(ctx context.Context, id string) error
+	// ResetFailures 配信に成功したため、続けて失敗した回数を0に戻す
+	ResetFailures(ctx context.Context, id string) error
+	// RecordFailure 続けて失敗した回数を1つ増やし、threshold回に達した場合は配信を止めてtrueを返す。
+	// 管理者による変更と競合しないよう、回数と状態のみを更新する
+	RecordFailure(ctx context.Context, id string, threshold int, at time.Time) (bool, error)
+}
+
+type WebhookDeliveryRepository interface {
+	// CreateAll 同じイベントを同じ購読先に届ける配信が既にあるものは作成しない。
+	// アウトボックスから同じイベントが複数回渡されても、配信は1つになる
+	CreateAll(ctx context.Context, deliveries []*model.WebhookDelivery) error
+	FindByID(ctx context.Context, id string) (*model.WebhookDelivery, error)
+	FindAll(ctx context.Context, query WebhookDeliveryQuery) (*WebhookDeliveryPage, error)
+	// FindDue 配信を止めていない購読先への配信待ちのうち、次に試す日時がnow以前のものを古い順に最大limit件返す
+	FindDue(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error)
+	Update(ctx context.Context, delivery *model.WebhookDelivery) error
+	CreateAttempt(ctx context.Context, attempt *model.WebhookDeliveryAttempt) error
+	// FindAttempts 配信を試みた記録を古い順に返す
+	FindAttempts(ctx context.Context, deliveryID string) ([]*model.WebhookDeliveryAttempt, error)
+}
+
+// WebhookDeliveryQuery 配信履歴の取得条件。新しい順に並べ、ゼロ値の項目は条件に含めない
+type WebhookDeliveryQuery struct {
+	SubscriptionID string
+	Status         model.WebhookDeliveryStatus
+	EventType      string
+	Limit          int
+	Cursor         *WebhookDeliveryCursor
+}
+
+// WebhookDeliveryPage 配信履歴の取得結果。続きがない場合NextCursorは空になる
+type WebhookDeliveryPage struct {
+	Deliveries []*model.WebhookDelivery
+	NextCursor string
+}
+
+// WebhookDeliveryCursor 配信のIDは作成時刻順に採番されるため、IDのみで次ページの開始位置を表す
+type WebhookDeliveryCursor struct {
+	ID string
+}
+
+// Encode クライアントには内容を意識させない文字列に変換する
+func (c WebhookDeliveryCursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.ID))
+}
+
+// DecodeWebhookDeliveryCursor Encodeした文字列を復元する
+func DecodeWebhookDeliveryCursor(s string) (WebhookDeliveryCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return WebhookDeliveryCursor{}, model.NewValidationError(model.CodeInvalidFormat, "カーソルが不正です")
+	}
+	return WebhookDeliveryCursor{ID: string(b)}, nil
+}
@@ -0,0 +1,50 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/usecase"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// LogAccessDenialRecorder 拒否した操作を1行に1件のJSONとして書き出す
+type LogAccessDenialRecorder struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewLogAccessDenialRecorder(w io.Writer) *LogAccessDenialRecorder {
+	return &LogAccessDenialRecorder{w: w}
+}
+
+type accessDenialEntry struct {
+	Event      string    `json:"event"`
+	ActorID    string    `json:"actor_id"`
+	Role       string    `json:"role"`
+	Permission string    `json:"permission"`
+	TargetID   string    `json:"target_id,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+	DeniedAt   time.Time `json:"denied_at"`
+}
+
+func (r *LogAccessDenialRecorder) RecordDenial(ctx context.Context, denial usecase.AccessDenial) error {
+	line, err := json.Marshal(accessDenialEntry{
+		Event:      "access_denied",
+		ActorID:    denial.ActorID,
+		Role:       string(denial.Role),
+		Permission: string(denial.Permission),
+		TargetID:   denial.TargetID,
+		RequestID:  denial.RequestID,
+		DeniedAt:   denial.DeniedAt.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.w.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,45 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/usecase"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogAccessDenialRecorder(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	recorder := NewLogAccessDenialRecorder(&buf)
+	deniedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Act
+	err := recorder.RecordDenial(context.Background(), usecase.AccessDenial{
+		ActorID:    "member-id",
+		Role:       model.RoleMember,
+		Permission: model.PermissionDeleteUsers,
+		TargetID:   "other-id",
+		RequestID:  "request-id",
+		DeniedAt:   deniedAt,
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	var entry map[string]string
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, map[string]string{
+		"event":      "access_denied",
+		"actor_id":   "member-id",
+		"role":       "member",
+		"permission": "users:delete",
+		"target_id":  "other-id",
+		"request_id": "request-id",
+		"denied_at":  "2025-01-01T00:00:00Z",
+	}, entry)
+	assert.True(t, bytes.HasSuffix(buf.Bytes(), []byte("\n")))
+}
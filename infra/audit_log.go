@@ -0,0 +1,140 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// auditLogRecord audit_logsテーブルの1行。変更内容はJSONの配列として保存する。
+// カーソルの比較がタイムゾーンに左右されないよう、日時はUTCで保存する
+type auditLogRecord struct {
+	ID        string    `gorm:"column:id;primaryKey"`
+	ActorID   string    `gorm:"column:actor_id"`
+	Action    string    `gorm:"column:action"`
+	TargetID  string    `gorm:"column:target_id"`
+	Changes   string    `gorm:"column:changes"`
+	RequestID string    `gorm:"column:request_id"`
+	IP        string    `gorm:"column:ip"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+func (auditLogRecord) TableName() string {
+	return "audit_logs"
+}
+
+type auditChangeRecord struct {
+	Field  string  `json:"field"`
+	Before *string `json:"before"`
+	After  *string `json:"after"`
+}
+
+func newAuditLogRecord(log *model.AuditLog) (*auditLogRecord, error) {
+	changes := make([]auditChangeRecord, len(log.Changes))
+	for i, change := range log.Changes {
+		changes[i] = auditChangeRecord{Field: change.Field, Before: change.Before, After: change.After}
+	}
+	b, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+	return &auditLogRecord{
+		ID:        log.ID,
+		ActorID:   log.ActorID,
+		Action:    string(log.Action),
+		TargetID:  log.TargetID,
+		Changes:   string(b),
+		RequestID: log.RequestID,
+		IP:        log.IP,
+		CreatedAt: log.CreatedAt.UTC(),
+	}, nil
+}
+
+func (r *auditLogRecord) toDomain() (*model.AuditLog, error) {
+	var changes []auditChangeRecord
+	if err := json.Unmarshal([]byte(r.Changes), &changes); err != nil {
+		return nil, err
+	}
+	log := &model.AuditLog{
+		ID:        r.ID,
+		ActorID:   r.ActorID,
+		Action:    model.AuditAction(r.Action),
+		TargetID:  r.TargetID,
+		RequestID: r.RequestID,
+		IP:        r.IP,
+		CreatedAt: r.CreatedAt,
+	}
+	for _, change := range changes {
+		log.Changes = append(log.Changes, model.AuditChange{Field: change.Field, Before: change.Before, After: change.After})
+	}
+	return log, nil
+}
+
+type AuditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) repository.AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+func (r *AuditLogRepository) Create(ctx context.Context, log *model.AuditLog) error {
+	record, err := newAuditLogRecord(log)
+	if err != nil {
+		return err
+	}
+	return conn(ctx, r.db).Create(record).Error
+}
+
+// FindAll 新しい順に取得する。同じ時刻の記録はIDの降順に並べる
+func (r *AuditLogRepository) FindAll(ctx context.Context, query repository.AuditLogQuery) (*repository.AuditLogPage, error) {
+	limit := query.Limit
+	if limit <= 0 || limit > repository.MaxAuditLogLimit {
+		limit = repository.DefaultAuditLogLimit
+	}
+
+	db := conn(ctx, r.db).Model(&auditLogRecord{})
+	if query.ActorID != "" {
+		db = db.Where("actor_id = ?", query.ActorID)
+	}
+	if query.TargetID != "" {
+		db = db.Where("target_id = ?", query.TargetID)
+	}
+	if query.Action != "" {
+		db = db.Where("action = ?", string(query.Action))
+	}
+	if !query.Since.IsZero() {
+		db = db.Where("created_at >= ?", query.Since.UTC())
+	}
+	if !query.Until.IsZero() {
+		db = db.Where("created_at < ?", query.Until.UTC())
+	}
+	if query.Cursor != nil {
+		db = db.Where("(created_at < ? OR (created_at = ? AND id < ?))", query.Cursor.CreatedAt, query.Cursor.CreatedAt, query.Cursor.ID)
+	}
+
+	records := []*auditLogRecord{}
+	// 次ページの有無を判定するため1件多く取得する
+	if err := db.Order("created_at DESC").Order("id DESC").Limit(limit + 1).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	logs := make([]*model.AuditLog, len(records))
+	for i, record := range records {
+		log, err := record.toDomain()
+		if err != nil {
+			return nil, err
+		}
+		logs[i] = log
+	}
+
+	page := &repository.AuditLogPage{Logs: logs}
+	if len(logs) > limit {
+		page.Logs = logs[:limit]
+		page.NextCursor = repository.NewAuditLogCursor(logs[limit-1]).Encode()
+	}
+	return page, nil
+}
@@ -0,0 +1,106 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLogRepository(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newLog := func(id string, actorID string, targetID string, action model.AuditAction, at time.Time) *model.AuditLog {
+		after := "new"
+		return &model.AuditLog{
+			ID:        id,
+			ActorID:   actorID,
+			Action:    action,
+			TargetID:  targetID,
+			Changes:   []model.AuditChange{{Field: "username", After: &after}},
+			RequestID: "req-" + id,
+			IP:        "192.0.2.1",
+			CreatedAt: at,
+		}
+	}
+
+	t.Run("成功: 記録した内容を新しい順に取得できる", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &AuditLogRepository{db: db}
+		assert.NoError(t, repo.Create(context.Background(), newLog("log-1", "", "user-1", model.AuditActionUserCreated, base)))
+		assert.NoError(t, repo.Create(context.Background(), newLog("log-2", "admin", "user-1", model.AuditActionUserSuspended, base.Add(time.Minute))))
+
+		// Act
+		page, err := repo.FindAll(context.Background(), repository.AuditLogQuery{})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, page.Logs, 2)
+		assert.Empty(t, page.NextCursor)
+		assert.Equal(t, "log-2", page.Logs[0].ID)
+		assert.Equal(t, "admin", page.Logs[0].ActorID)
+		assert.Equal(t, model.AuditActionUserSuspended, page.Logs[0].Action)
+		assert.Equal(t, "req-log-2", page.Logs[0].RequestID)
+		assert.Equal(t, "192.0.2.1", page.Logs[0].IP)
+		assert.True(t, base.Add(time.Minute).Equal(page.Logs[0].CreatedAt))
+		assert.Equal(t, "username", page.Logs[0].Changes[0].Field)
+		assert.Nil(t, page.Logs[0].Changes[0].Before)
+		assert.Equal(t, "new", *page.Logs[0].Changes[0].After)
+	})
+
+	t.Run("成功: 条件で絞り込める", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &AuditLogRepository{db: db}
+		assert.NoError(t, repo.Create(context.Background(), newLog("log-1", "", "user-1", model.AuditActionUserCreated, base)))
+		assert.NoError(t, repo.Create(context.Background(), newLog("log-2", "admin", "user-1", model.AuditActionUserSuspended, base.Add(time.Minute))))
+		assert.NoError(t, repo.Create(context.Background(), newLog("log-3", "admin", "user-2", model.AuditActionUserSuspended, base.Add(2*time.Minute))))
+
+		// Act
+		byTarget, err1 := repo.FindAll(context.Background(), repository.AuditLogQuery{TargetID: "user-1"})
+		byActor, err2 := repo.FindAll(context.Background(), repository.AuditLogQuery{ActorID: "admin", Action: model.AuditActionUserSuspended})
+		byPeriod, err3 := repo.FindAll(context.Background(), repository.AuditLogQuery{Since: base.Add(time.Minute), Until: base.Add(2 * time.Minute)})
+
+		// Assert
+		assert.NoError(t, err1)
+		assert.NoError(t, err2)
+		assert.NoError(t, err3)
+		assert.Equal(t, []string{"log-2", "log-1"}, auditLogIDs(byTarget.Logs))
+		assert.Equal(t, []string{"log-3", "log-2"}, auditLogIDs(byActor.Logs))
+		assert.Equal(t, []string{"log-2"}, auditLogIDs(byPeriod.Logs))
+	})
+
+	t.Run("成功: カーソルで続きを取得できる", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &AuditLogRepository{db: db}
+		// 同じ時刻の記録はIDで順序を決める
+		assert.NoError(t, repo.Create(context.Background(), newLog("log-1", "", "user-1", model.AuditActionUserCreated, base)))
+		assert.NoError(t, repo.Create(context.Background(), newLog("log-2", "", "user-1", model.AuditActionUserUpdated, base)))
+		assert.NoError(t, repo.Create(context.Background(), newLog("log-3", "", "user-1", model.AuditActionUserUpdated, base.Add(time.Second))))
+
+		// Act
+		first, err := repo.FindAll(context.Background(), repository.AuditLogQuery{Limit: 2})
+		assert.NoError(t, err)
+		cursor, err := repository.DecodeAuditLogCursor(first.NextCursor)
+		assert.NoError(t, err)
+		second, err := repo.FindAll(context.Background(), repository.AuditLogQuery{Limit: 2, Cursor: &cursor})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"log-3", "log-2"}, auditLogIDs(first.Logs))
+		assert.Equal(t, []string{"log-1"}, auditLogIDs(second.Logs))
+		assert.Empty(t, second.NextCursor)
+	})
+}
+
+func auditLogIDs(logs []*model.AuditLog) []string {
+	ids := make([]string, len(logs))
+	for i, log := range logs {
+		ids[i] = log.ID
+	}
+	return ids
+}
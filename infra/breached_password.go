@@ -0,0 +1,65 @@
+package infra
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// BreachedPasswordList 漏洩したパスワードのSHA-1ハッシュの一覧。Have I Been Pwnedのダウンロード形式
+// （1行に"ハッシュ:件数"、件数は省略可）を読み込み、ハッシュの先頭5文字ごとに残りの部分を保持する
+type BreachedPasswordList struct {
+	ranges map[string][]string
+}
+
+// LoadBreachedPasswordList ファイルから漏洩したパスワードの一覧を読み込む
+func LoadBreachedPasswordList(path string) (*BreachedPasswordList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadBreachedPasswordList(file)
+}
+
+// ReadBreachedPasswordList 空行と"#"で始まる行は無視する
+func ReadBreachedPasswordList(r io.Reader) (*BreachedPasswordList, error) {
+	list := &BreachedPasswordList{ranges: map[string][]string{}}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(text, ":")
+		if !isSHA1Hex(hash) {
+			return nil, fmt.Errorf("invalid SHA-1 hash at line %d", line)
+		}
+		hash = strings.ToUpper(hash)
+		list.ranges[hash[:5]] = append(list.ranges[hash[:5]], hash[5:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Range model.BreachedPasswordsの実装。先頭5文字が一致するハッシュの残りの部分を返す
+func (l *BreachedPasswordList) Range(prefix string) ([]string, error) {
+	return l.ranges[strings.ToUpper(prefix)], nil
+}
+
+func isSHA1Hex(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package infra
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadBreachedPasswordList(t *testing.T) {
+	t.Run("成功: 先頭5文字ごとにハッシュの残りを返す", func(t *testing.T) {
+		// Arrange
+		input := "# comment\n\nCBFDAC6008F9CAB4083784CBD1874F76618D2A97:2254650\ncbfda0000000000000000000000000000000000a\n5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8\n"
+
+		// Act
+		list, err := ReadBreachedPasswordList(strings.NewReader(input))
+
+		// Assert
+		assert.NoError(t, err)
+		suffixes, err := list.Range("cbfda")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"C6008F9CAB4083784CBD1874F76618D2A97", "0000000000000000000000000000000000A"}, suffixes)
+		suffixes, _ = list.Range("00000")
+		assert.Empty(t, suffixes)
+	})
+
+	t.Run("失敗: SHA-1ハッシュでない行", func(t *testing.T) {
+		// Act
+		_, err := ReadBreachedPasswordList(strings.NewReader("password123\n"))
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,58 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+const mysqlErrDuplicateEntry = 1062
+
+// errVersionConflict 条件付きの更新・削除で対象の行が無かった場合のエラー
+var errVersionConflict = model.NewConflictError("ユーザーは他の操作で更新または削除されています", nil)
+
+// translateError GORM・ドライバのエラーをドメインエラーに変換する
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.NewNotFoundError("ユーザーが見つかりません", err)
+	}
+	if isDuplicateKeyError(err) {
+		return duplicateKeyError(err)
+	}
+	return err
+}
+
+// duplicateKeyError 違反した一意インデックスの名前から重複した項目を特定する。
+// MySQLは"for key 'users.idx_users_email_canonical'"のようにインデックス名を含む。SQLiteは式インデックスでは
+// "index 'idx_users_username_lower'"、列のインデックスでは"users.email_canonical"のように列名を含む
+func duplicateKeyError(err error) error {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "idx_users_email"), strings.Contains(message, "users.email_canonical"):
+		return model.NewFieldsConflictError([]model.FieldError{model.FieldEmailTaken}, err)
+	case strings.Contains(message, "idx_users_username"):
+		return model.NewFieldsConflictError([]model.FieldError{model.FieldUsernameTaken}, err)
+	}
+	return model.NewConflictError("ユーザーは既に存在します", err)
+}
+
+func isDuplicateKeyError(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlErrDuplicateEntry
+	}
+
+	// SQLiteはテストでのみ使用するためドライバに依存せずメッセージで判定する
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
@@ -0,0 +1,48 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// LogEventPublisher ドメインイベントを1行に1件のJSONとして書き出す
+type LogEventPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewLogEventPublisher(w io.Writer) *LogEventPublisher {
+	return &LogEventPublisher{w: w}
+}
+
+type eventEntry struct {
+	Event       string          `json:"event"`
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID string          `json:"aggregate_id"`
+	Payload     json.RawMessage `json:"payload"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+}
+
+func (p *LogEventPublisher) Publish(ctx context.Context, message *model.OutboxMessage) error {
+	line, err := json.Marshal(eventEntry{
+		Event:       "domain_event",
+		ID:          message.ID,
+		Type:        message.EventType,
+		AggregateID: message.AggregateID,
+		Payload:     message.Payload,
+		OccurredAt:  message.OccurredAt.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err = p.w.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,34 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogEventPublisher(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	publisher := NewLogEventPublisher(&buf)
+	message, err := model.NewOutboxMessage(testIDs, model.UserDeleted{UserID: "user-id", At: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)})
+	assert.NoError(t, err)
+
+	// Act
+	err = publisher.Publish(context.Background(), &message)
+
+	// Assert
+	assert.NoError(t, err)
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "domain_event", entry["event"])
+	assert.Equal(t, message.ID, entry["id"])
+	assert.Equal(t, "user.deleted", entry["type"])
+	assert.Equal(t, "user-id", entry["aggregate_id"])
+	assert.Equal(t, map[string]interface{}{"user_id": "user-id", "occurred_at": "2025-01-01T00:00:00Z"}, entry["payload"])
+	assert.True(t, bytes.HasSuffix(buf.Bytes(), []byte("\n")))
+}
@@ -0,0 +1,146 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/usecase"
+	"crypto"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 対応する署名アルゴリズム
+const (
+	JWTAlgorithmHS256 = "HS256"
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmEdDSA = "EdDSA"
+)
+
+var errInvalidToken = model.NewUnauthorizedError("アクセストークンが不正です")
+
+type jwtClaims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// JWTConfig JWTIssuerの設定。SigningKeyとVerifyingKeyはHS256では共通鍵、それ以外ではPEM形式の鍵
+type JWTConfig struct {
+	Algorithm    string
+	SigningKey   []byte
+	VerifyingKey []byte
+	Issuer       string
+	TTL          time.Duration
+}
+
+// JWTIssuer JWT形式のアクセストークンを発行・検証する
+type JWTIssuer struct {
+	method       jwt.SigningMethod
+	signingKey   interface{}
+	verifyingKey interface{}
+	issuer       string
+	ttl          time.Duration
+	now          func() time.Time
+}
+
+func NewJWTIssuer(config JWTConfig) (*JWTIssuer, error) {
+	issuer := &JWTIssuer{issuer: config.Issuer, ttl: config.TTL, now: time.Now}
+
+	switch config.Algorithm {
+	case JWTAlgorithmHS256:
+		if len(config.SigningKey) < 32 {
+			return nil, errors.New("HS256の鍵は32バイト以上必要です")
+		}
+		issuer.method = jwt.SigningMethodHS256
+		issuer.signingKey = config.SigningKey
+		issuer.verifyingKey = config.SigningKey
+	case JWTAlgorithmRS256:
+		issuer.method = jwt.SigningMethodRS256
+		if len(config.SigningKey) > 0 {
+			key, err := jwt.ParseRSAPrivateKeyFromPEM(config.SigningKey)
+			if err != nil {
+				return nil, err
+			}
+			issuer.signingKey = key
+			issuer.verifyingKey = &key.PublicKey
+		}
+		if len(config.VerifyingKey) > 0 {
+			key, err := jwt.ParseRSAPublicKeyFromPEM(config.VerifyingKey)
+			if err != nil {
+				return nil, err
+			}
+			issuer.verifyingKey = key
+		}
+	case JWTAlgorithmEdDSA:
+		issuer.method = jwt.SigningMethodEdDSA
+		if len(config.SigningKey) > 0 {
+			key, err := jwt.ParseEdPrivateKeyFromPEM(config.SigningKey)
+			if err != nil {
+				return nil, err
+			}
+			issuer.signingKey = key
+			issuer.verifyingKey = key.(crypto.Signer).Public()
+		}
+		if len(config.VerifyingKey) > 0 {
+			key, err := jwt.ParseEdPublicKeyFromPEM(config.VerifyingKey)
+			if err != nil {
+				return nil, err
+			}
+			issuer.verifyingKey = key
+		}
+	default:
+		return nil, fmt.Errorf("未対応の署名アルゴリズムです: %s", config.Algorithm)
+	}
+	if issuer.verifyingKey == nil {
+		return nil, fmt.Errorf("%sの鍵が設定されていません", config.Algorithm)
+	}
+
+	return issuer, nil
+}
+
+func (j *JWTIssuer) Issue(user *model.User) (*usecase.AccessToken, error) {
+	if j.signingKey == nil {
+		return nil, errors.New("署名用の秘密鍵が設定されていません")
+	}
+	now := j.now()
+	expiresAt := now.Add(j.ttl)
+
+	token, err := jwt.NewWithClaims(j.method, jwtClaims{
+		Role: string(user.Role()),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID(),
+			Issuer:    j.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}).SignedString(j.signingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &usecase.AccessToken{
+		Token:     token,
+		ExpiresAt: time.Unix(expiresAt.Unix(), 0),
+	}, nil
+}
+
+func (j *JWTIssuer) Verify(token string) (*usecase.Claims, error) {
+	var claims jwtClaims
+	// ヘッダーのalgは信用せず、設定したアルゴリズムと一致する場合のみ受け付ける
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return j.verifyingKey, nil
+	}, jwt.WithValidMethods([]string{j.method.Alg()}), jwt.WithExpirationRequired(), jwt.WithTimeFunc(j.now))
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, model.NewUnauthorizedError("アクセストークンの有効期限が切れています")
+	}
+	if err != nil || claims.Subject == "" || claims.Issuer != j.issuer {
+		return nil, errInvalidToken
+	}
+
+	return &usecase.Claims{
+		UserID:    claims.Subject,
+		Role:      model.Role(claims.Role),
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
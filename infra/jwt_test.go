@@ -0,0 +1,163 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func pemEncode(t *testing.T, typ string, der []byte, err error) []byte {
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+}
+
+func testJWTConfigs(t *testing.T) map[string]JWTConfig {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	rsaPrivate, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	rsaPrivatePEM := pemEncode(t, "PRIVATE KEY", rsaPrivate, err)
+	rsaPublic, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	rsaPublicPEM := pemEncode(t, "PUBLIC KEY", rsaPublic, err)
+
+	edPublicKey, edPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	edPrivate, err := x509.MarshalPKCS8PrivateKey(edPrivateKey)
+	edPrivatePEM := pemEncode(t, "PRIVATE KEY", edPrivate, err)
+	edPublic, err := x509.MarshalPKIXPublicKey(edPublicKey)
+	edPublicPEM := pemEncode(t, "PUBLIC KEY", edPublic, err)
+
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	return map[string]JWTConfig{
+		JWTAlgorithmHS256: {Algorithm: JWTAlgorithmHS256, SigningKey: secret, VerifyingKey: secret, Issuer: "test", TTL: time.Minute},
+		JWTAlgorithmRS256: {Algorithm: JWTAlgorithmRS256, SigningKey: rsaPrivatePEM, VerifyingKey: rsaPublicPEM, Issuer: "test", TTL: time.Minute},
+		JWTAlgorithmEdDSA: {Algorithm: JWTAlgorithmEdDSA, SigningKey: edPrivatePEM, VerifyingKey: edPublicPEM, Issuer: "test", TTL: time.Minute},
+	}
+}
+
+func TestJWTIssuer(t *testing.T) {
+	user := model.ReconstructUser(model.UserSnapshot{ID: "test-id", Role: model.RoleAdmin})
+
+	for algorithm, config := range testJWTConfigs(t) {
+		t.Run("成功: "+algorithm+"で発行したトークンを検証できる", func(t *testing.T) {
+			// Arrange
+			issuer, err := NewJWTIssuer(config)
+			assert.NoError(t, err)
+
+			// Act
+			token, err := issuer.Issue(user)
+			assert.NoError(t, err)
+			claims, err := issuer.Verify(token.Token)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, "test-id", claims.UserID)
+			assert.Equal(t, model.RoleAdmin, claims.Role)
+			assert.Equal(t, token.ExpiresAt, claims.ExpiresAt)
+		})
+
+		t.Run("失敗: "+algorithm+"で改ざんされたトークン", func(t *testing.T) {
+			// Arrange
+			issuer, err := NewJWTIssuer(config)
+			assert.NoError(t, err)
+			token, err := issuer.Issue(user)
+			assert.NoError(t, err)
+			other, err := issuer.Issue(model.ReconstructUser(model.UserSnapshot{ID: "other-id", Role: model.RoleMember}))
+			assert.NoError(t, err)
+
+			// Act - 署名を別のトークンのものに差し替える
+			parts := strings.Split(token.Token, ".")
+			otherParts := strings.Split(other.Token, ".")
+			_, err = issuer.Verify(parts[0] + "." + parts[1] + "." + otherParts[2])
+
+			// Assert
+			assert.ErrorIs(t, err, model.ErrUnauthorized)
+		})
+	}
+
+	t.Run("失敗: 有効期限切れ", func(t *testing.T) {
+		// Arrange
+		issuer, err := NewJWTIssuer(testJWTConfigs(t)[JWTAlgorithmHS256])
+		assert.NoError(t, err)
+		token, err := issuer.Issue(user)
+		assert.NoError(t, err)
+		issuer.now = func() time.Time { return time.Now().Add(time.Hour) }
+
+		// Act
+		_, err = issuer.Verify(token.Token)
+
+		// Assert
+		assert.ErrorIs(t, err, model.ErrUnauthorized)
+	})
+
+	t.Run("失敗: 異なるアルゴリズムのトークン", func(t *testing.T) {
+		// Arrange
+		configs := testJWTConfigs(t)
+		hs256, err := NewJWTIssuer(configs[JWTAlgorithmHS256])
+		assert.NoError(t, err)
+		eddsa, err := NewJWTIssuer(configs[JWTAlgorithmEdDSA])
+		assert.NoError(t, err)
+		token, err := hs256.Issue(user)
+		assert.NoError(t, err)
+
+		// Act
+		_, err = eddsa.Verify(token.Token)
+
+		// Assert
+		assert.ErrorIs(t, err, model.ErrUnauthorized)
+	})
+
+	t.Run("失敗: 署名の無いトークン", func(t *testing.T) {
+		// Arrange
+		issuer, err := NewJWTIssuer(testJWTConfigs(t)[JWTAlgorithmHS256])
+		assert.NoError(t, err)
+		token, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.RegisteredClaims{
+			Subject:   "test-id",
+			Issuer:    "test",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		assert.NoError(t, err)
+
+		// Act
+		_, err = issuer.Verify(token)
+
+		// Assert
+		assert.ErrorIs(t, err, model.ErrUnauthorized)
+	})
+
+	t.Run("失敗: 発行者が異なる", func(t *testing.T) {
+		// Arrange
+		config := testJWTConfigs(t)[JWTAlgorithmHS256]
+		issuer, err := NewJWTIssuer(config)
+		assert.NoError(t, err)
+		config.Issuer = "other"
+		other, err := NewJWTIssuer(config)
+		assert.NoError(t, err)
+		token, err := other.Issue(user)
+		assert.NoError(t, err)
+
+		// Act
+		_, err = issuer.Verify(token.Token)
+
+		// Assert
+		assert.ErrorIs(t, err, model.ErrUnauthorized)
+	})
+
+	t.Run("失敗: 不正な設定", func(t *testing.T) {
+		_, err1 := NewJWTIssuer(JWTConfig{Algorithm: JWTAlgorithmHS256, SigningKey: []byte("short")})
+		_, err2 := NewJWTIssuer(JWTConfig{Algorithm: "none"})
+		_, err3 := NewJWTIssuer(JWTConfig{Algorithm: JWTAlgorithmRS256})
+
+		assert.Error(t, err1)
+		assert.Error(t, err2)
+		assert.Error(t, err3)
+	})
+}
@@ -0,0 +1,134 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/usecase"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SMTPConfig Usernameが空の場合は認証しない。サーバーが対応していればSTARTTLSで暗号化する
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPMailer SMTPサーバーを経由してメールを送信する
+type SMTPMailer struct {
+	config SMTPConfig
+}
+
+func NewSMTPMailer(config SMTPConfig) *SMTPMailer {
+	return &SMTPMailer{config: config}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, mail usecase.Mail) error {
+	message, err := formatMail(m.config.From, mail, time.Now())
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+	from, err := addressOf(m.config.From)
+	if err != nil {
+		return err
+	}
+	to, err := addressOf(mail.To)
+	if err != nil {
+		return err
+	}
+
+	// net/smtpはcontextに対応していないため、キャンセルされた場合は送信の完了を待たずに戻る
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port)), auth, from, []string{to}, message)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WriterMailer メールを送信せずに書き出す。開発環境やテストで送信内容を確認するために使う
+type WriterMailer struct {
+	mu   sync.Mutex
+	w    io.Writer
+	from string
+}
+
+func NewWriterMailer(w io.Writer, from string) *WriterMailer {
+	return &WriterMailer{w: w, from: from}
+}
+
+// NewFileMailer メールをファイルに追記する
+func NewFileMailer(path string, from string) (*WriterMailer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterMailer(file, from), nil
+}
+
+func (m *WriterMailer) Send(ctx context.Context, mail usecase.Mail) error {
+	message, err := formatMail(m.from, mail, time.Now())
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err = fmt.Fprintf(m.w, "%s\r\n.\r\n", message)
+	return err
+}
+
+// formatMail 件名はMIMEエンコードし、本文はUTF-8のquoted-printableにする
+func formatMail(from string, m usecase.Mail, now time.Time) ([]byte, error) {
+	if _, err := addressOf(from); err != nil {
+		return nil, err
+	}
+	if _, err := addressOf(m.To); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", m.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write([]byte(m.Body)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// addressOf ヘッダーの改行による差し込みを防ぐため、RFC 5322のアドレスとして解析できるものだけを受け付ける
+func addressOf(address string) (string, error) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", fmt.Errorf("invalid mail address %q: %w", address, err)
+	}
+	return parsed.Address, nil
+}
@@ -0,0 +1,50 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/usecase"
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterMailer_Send(t *testing.T) {
+	t.Run("成功: MIME形式で書き出す", func(t *testing.T) {
+		// Arrange
+		var buf bytes.Buffer
+		mailer := NewWriterMailer(&buf, "no-reply@example.com")
+
+		// Act
+		err := mailer.Send(context.Background(), usecase.Mail{To: "user@example.com", Subject: "メールアドレスの確認", Body: "次のURLを開いてください。\nhttps://example.com/verify?token=abc"})
+
+		// Assert
+		assert.NoError(t, err)
+		message, err := mail.ReadMessage(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, "user@example.com", message.Header.Get("To"))
+		subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+		assert.NoError(t, err)
+		assert.Equal(t, "メールアドレスの確認", subject)
+		body, err := io.ReadAll(quotedprintable.NewReader(message.Body))
+		assert.NoError(t, err)
+		assert.Contains(t, string(body), "https://example.com/verify?token=abc")
+	})
+
+	t.Run("失敗: ヘッダーに改行を含む宛先", func(t *testing.T) {
+		// Arrange
+		var buf bytes.Buffer
+		mailer := NewWriterMailer(&buf, "no-reply@example.com")
+
+		// Act
+		err := mailer.Send(context.Background(), usecase.Mail{To: "user@example.com\r\nBcc: attacker@example.com", Subject: "subject", Body: "body"})
+
+		// Assert
+		assert.Error(t, err)
+		assert.Zero(t, buf.Len())
+	})
+}
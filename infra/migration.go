@@ -0,0 +1,555 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// MigrationsDir 新しいマイグレーションを作成するディレクトリ(リポジトリのルートからの相対パス)
+const MigrationsDir = "infra/migrations"
+
+const migrationLockName = "schema_migrations"
+
+// emailCanonicalRulesSetting email_canonicalを計算した規則を記録するapp_settingsの名前
+const emailCanonicalRulesSetting = "email_canonical_rules"
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration バージョンごとのup/downのSQL
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+	// step upのSQLの後に同じトランザクションで実行するGoの処理。SQLだけでは書けないデータの移行に使う
+	step migrationStep
+}
+
+// migrationStep SQLでは表せない変換をGoのコードで行うマイグレーションの手順。
+// factoryはメールアドレスの正規化など、アプリケーションと同じ設定で値を計算するために使う
+type migrationStep func(ctx context.Context, tx *sql.Tx, factory *model.UserFactory) error
+
+// MigrationStatus マイグレーションの適用状況
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+	// Dirty 適用後にupのSQLが書き換えられている
+	Dirty bool
+	// Missing 適用済みだがファイルが存在しない
+	Missing bool
+}
+
+type appliedMigration struct {
+	version   int64
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// migrationDialect DBごとに異なるSQLとロックの取り方
+type migrationDialect interface {
+	createTable() string
+	lock(ctx context.Context, conn *sql.Conn) error
+	unlock(ctx context.Context, conn *sql.Conn) error
+	// forceUnlock 他のプロセスが取得したものも含めてロックを解放する
+	forceUnlock(ctx context.Context, conn *sql.Conn) error
+}
+
+// Migrator 埋め込まれたSQLでスキーマを管理する。適用状況はschema_migrationsテーブルに記録する
+type Migrator struct {
+	db         *sql.DB
+	dialect    migrationDialect
+	migrations []Migration
+	factory    *model.UserFactory
+	now        func() time.Time
+}
+
+// NewMigrator gormの接続先に応じたSQLを読み込む。対応しているのはmysqlとsqlite。
+// factoryはアプリケーションと同じ設定で作成したものを渡す
+func NewMigrator(db *gorm.DB, factory *model.UserFactory) (*Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	name := db.Dialector.Name()
+	var dialect migrationDialect
+	switch name {
+	case "mysql":
+		dialect = mysqlMigrationDialect{}
+	case "sqlite":
+		dialect = sqliteMigrationDialect{}
+	default:
+		return nil, fmt.Errorf("未対応のデータベースです: %s", name)
+	}
+
+	dir, err := fs.Sub(migrationFiles, "migrations/"+name)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := loadMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: sqlDB, dialect: dialect, migrations: migrations, factory: factory, now: time.Now}, nil
+}
+
+func loadMigrations(dir fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(dir, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		matches := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			return nil, fmt.Errorf("マイグレーションのファイル名が不正です: %s", entry.Name())
+		}
+		version, _ := strconv.ParseInt(matches[1], 10, 64)
+		body, err := fs.ReadFile(dir, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = m
+		}
+		if m.Name != matches[2] {
+			return nil, fmt.Errorf("バージョン%dのマイグレーションが重複しています", version)
+		}
+		if matches[3] == "up" {
+			m.Up = string(body)
+			sum := sha256.Sum256(body)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("バージョン%dのupまたはdownがありません", m.Version)
+		}
+		m.step = migrationSteps[m.Version]
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up 未適用のマイグレーションを全て適用し、適用したものを返す。
+// メールアドレスの正規化の設定が前回から変わっていれば、保存済みのemail_canonicalも計算し直す
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		var err error
+		applied, err = m.up(ctx, conn, len(m.migrations))
+		if err != nil {
+			return err
+		}
+		return m.syncEmailCanonicalRules(ctx, conn)
+	})
+	return applied, err
+}
+
+// Down 最後に適用したものから順にstepsの数だけ取り消し、取り消したものを返す
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		var err error
+		reverted, err = m.down(ctx, conn, steps)
+		return err
+	})
+	return reverted, err
+}
+
+// Redo 最後に適用したマイグレーションを取り消して再度適用する
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	var redone *Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		reverted, err := m.down(ctx, conn, 1)
+		if err != nil || len(reverted) == 0 {
+			return err
+		}
+		if _, err := m.up(ctx, conn, 1); err != nil {
+			return err
+		}
+		redone = &reverted[0]
+		return nil
+	})
+	return redone, err
+}
+
+// Unlock 異常終了したプロセスが残したロックを解放する。実行中のプロセスがいないことを確認してから使う
+func (m *Migrator) Unlock(ctx context.Context) error {
+	return m.withConn(ctx, func(conn *sql.Conn) error {
+		return m.dialect.forceUnlock(ctx, conn)
+	})
+}
+
+// Status 全てのマイグレーションの適用状況をバージョン順に返す
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+			if a, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &a.appliedAt
+				status.Dirty = a.checksum != migration.Checksum
+				delete(applied, migration.Version)
+			}
+			statuses = append(statuses, status)
+		}
+		for _, a := range applied {
+			appliedAt := a.appliedAt
+			statuses = append(statuses, MigrationStatus{Version: a.version, Name: a.name, AppliedAt: &appliedAt, Missing: true})
+		}
+		sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+		return nil
+	})
+	return statuses, err
+}
+
+func (m *Migrator) up(ctx context.Context, conn *sql.Conn, limit int) ([]Migration, error) {
+	applied, err := m.verifiedApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if len(done) == limit {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err := m.exec(ctx, conn, migration.Up, migration.step,
+			"INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+			migration.Version, migration.Name, migration.Checksum, m.now().UTC())
+		if err != nil {
+			return done, fmt.Errorf("マイグレーション%d_%sの適用に失敗しました: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+func (m *Migrator) down(ctx context.Context, conn *sql.Conn, steps int) ([]Migration, error) {
+	applied, err := m.verifiedApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		err := m.exec(ctx, conn, migration.Down, nil, "DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+		if err != nil {
+			return done, fmt.Errorf("マイグレーション%d_%sの取り消しに失敗しました: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// exec マイグレーションのSQLとGoの手順、適用状況の記録を1つのトランザクションで実行する。
+// MySQLのDDLは暗黙的にコミットされるため、途中で失敗した場合は手動での復旧が必要になる
+func (m *Migrator) exec(ctx context.Context, conn *sql.Conn, script string, step migrationStep, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, statement := range splitStatements(script) {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if step != nil {
+		if err := step(ctx, tx, m.factory); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// syncEmailCanonicalRules email_canonicalを計算した規則をapp_settingsに記録し、
+// 現在の設定と異なる場合は計算し直す。記録が無い場合も、どの規則で計算されたか分からないため計算し直す
+func (m *Migrator) syncEmailCanonicalRules(ctx context.Context, conn *sql.Conn) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rules := m.factory.EmailCanonicalRules()
+	var recorded string
+	err = tx.QueryRowContext(ctx, "SELECT value FROM app_settings WHERE name = ?", emailCanonicalRulesSetting).Scan(&recorded)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		_, err = tx.ExecContext(ctx, "INSERT INTO app_settings (name, value) VALUES (?, ?)", emailCanonicalRulesSetting, rules)
+	case err != nil:
+		return err
+	case recorded == rules:
+		return nil
+	default:
+		_, err = tx.ExecContext(ctx, "UPDATE app_settings SET value = ? WHERE name = ?", rules, emailCanonicalRulesSetting)
+	}
+	if err != nil {
+		return err
+	}
+	if err := recanonicalizeUsersEmail(ctx, tx, m.factory); err != nil {
+		return fmt.Errorf("メールアドレスの正規化の設定を反映できません: %w", err)
+	}
+	return tx.Commit()
+}
+
+// verifiedApplied 適用済みのupのSQLが書き換えられていないことを確認する
+func (m *Migrator) verifiedApplied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	for _, migration := range m.migrations {
+		if a, ok := applied[migration.Version]; ok && a.checksum != migration.Checksum {
+			return nil, fmt.Errorf("適用済みのマイグレーション%d_%sが変更されています", migration.Version, migration.Name)
+		}
+	}
+	return applied, nil
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]appliedMigration{}
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[a.version] = a
+	}
+	return applied, rows.Err()
+}
+
+func (m *Migrator) withConn(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, m.dialect.createTable()); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// withLock 複数のプロセスから同時にマイグレーションを実行しないようにロックを取得する
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	return m.withConn(ctx, func(conn *sql.Conn) (err error) {
+		if err := m.dialect.lock(ctx, conn); err != nil {
+			return err
+		}
+		defer func() {
+			if unlockErr := m.dialect.unlock(context.Background(), conn); err == nil {
+				err = unlockErr
+			}
+		}()
+		return fn(conn)
+	})
+}
+
+// splitStatements 1文ずつ実行するため、行末のセミコロンで区切る。文字列リテラル中の改行とセミコロンの組み合わせは使わないこと
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+type mysqlMigrationDialect struct{}
+
+func (mysqlMigrationDialect) createTable() string {
+	return `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    BIGINT       NOT NULL PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    checksum   CHAR(64)     NOT NULL,
+    applied_at DATETIME(3)  NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`
+}
+
+// lock 接続が切れると自動で解放されるGET_LOCKを使う
+func (mysqlMigrationDialect) lock(ctx context.Context, conn *sql.Conn) error {
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 10)", migrationLockName).Scan(&acquired); err != nil {
+		return err
+	}
+	if acquired.Int64 != 1 {
+		return errors.New("他のプロセスがマイグレーションを実行中です")
+	}
+	return nil
+}
+
+func (mysqlMigrationDialect) unlock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", migrationLockName)
+	return err
+}
+
+// forceUnlock GET_LOCKは取得した接続が切れると解放されるため、残ったロックは無い
+func (mysqlMigrationDialect) forceUnlock(ctx context.Context, conn *sql.Conn) error {
+	return nil
+}
+
+type sqliteMigrationDialect struct{}
+
+func (sqliteMigrationDialect) createTable() string {
+	return `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER  NOT NULL PRIMARY KEY,
+    name       TEXT     NOT NULL,
+    checksum   TEXT     NOT NULL,
+    applied_at DATETIME NOT NULL
+)`
+}
+
+// lock SQLiteにはアドバイザリロックが無いため、1行だけ入るテーブルへの挿入でロックを表す。
+// 取得したプロセスとその時刻を記録する。時間のかかるマイグレーションを実行中のロックと区別できないため、
+// 古いロックも自動では取り除かず、異常終了したプロセスが残したものはUnlockで解放する
+func (d sqliteMigrationDialect) lock(ctx context.Context, conn *sql.Conn) error {
+	if err := d.createLockTable(ctx, conn); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, "INSERT INTO schema_migrations_lock (id, holder, locked_at) VALUES (1, ?, ?)", migrationLockHolder(), time.Now().UTC()); err != nil {
+		var holder string
+		var lockedAt time.Time
+		if conn.QueryRowContext(ctx, "SELECT holder, locked_at FROM schema_migrations_lock WHERE id = 1").Scan(&holder, &lockedAt) == nil {
+			return fmt.Errorf("他のプロセスがマイグレーションを実行中です(%s、%sに取得)。異常終了したプロセスのロックであればmigrate unlockで解放してください: %w", holder, lockedAt.Format(time.RFC3339), err)
+		}
+		return fmt.Errorf("他のプロセスがマイグレーションを実行中です: %w", err)
+	}
+	return nil
+}
+
+func (sqliteMigrationDialect) unlock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations_lock WHERE id = 1")
+	return err
+}
+
+func (d sqliteMigrationDialect) forceUnlock(ctx context.Context, conn *sql.Conn) error {
+	if err := d.createLockTable(ctx, conn); err != nil {
+		return err
+	}
+	return d.unlock(ctx, conn)
+}
+
+func (sqliteMigrationDialect) createLockTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+    id        INTEGER  NOT NULL PRIMARY KEY CHECK (id = 1),
+    holder    TEXT     NOT NULL DEFAULT '',
+    locked_at DATETIME NOT NULL
+)`)
+	return err
+}
+
+// migrationLockHolder ロックを取得したプロセスを特定するためのホスト名とプロセスID
+func migrationLockHolder() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// CreateMigration dirの各DB用ディレクトリに次のバージョンのup/downファイルをコメントだけの状態で作成し、作成したパスを返す
+func CreateMigration(dir string, name string) ([]string, error) {
+	if !regexp.MustCompile(`^[a-z0-9_]+$`).MatchString(name) {
+		return nil, errors.New("マイグレーション名は英小文字・数字・アンダースコアで指定してください")
+	}
+
+	dialects := []string{"mysql", "sqlite"}
+	var next int64 = 1
+	for _, dialect := range dialects {
+		entries, err := os.ReadDir(filepath.Join(dir, dialect))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if matches := migrationFileName.FindStringSubmatch(entry.Name()); matches != nil {
+				if version, _ := strconv.ParseInt(matches[1], 10, 64); version >= next {
+					next = version + 1
+				}
+			}
+		}
+	}
+
+	var paths []string
+	for _, dialect := range dialects {
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(dir, dialect, fmt.Sprintf("%04d_%s.%s.sql", next, name, direction))
+			body := fmt.Sprintf("-- %04d_%s (%s, %s)\n", next, name, dialect, direction)
+			if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+				return paths, err
+			}
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,51 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migrationSteps バージョンごとのGoの手順。MySQLとSQLiteで共通のSQLだけを使う
+var migrationSteps = map[int64]migrationStep{
+	12: recanonicalizeUsersEmail,
+}
+
+// recanonicalizeUsersEmail email_canonicalをmodel.UserFactory.CanonicalizeEmailで計算し直す。
+// 0004ではLOWER(email)で埋めたが、SQLではIDNAの変換やUnicodeの小文字化ができず、正規化前に登録された行と食い違うため。
+// 正規化の設定を変えた場合もMigrator.Upから呼ばれる
+func recanonicalizeUsersEmail(ctx context.Context, tx *sql.Tx, factory *model.UserFactory) error {
+	rows, err := tx.QueryContext(ctx, "SELECT id, email, email_canonical FROM users")
+	if err != nil {
+		return err
+	}
+	changed := map[string]string{}
+	for rows.Next() {
+		var id, email, current string
+		if err := rows.Scan(&id, &email, &current); err != nil {
+			rows.Close()
+			return err
+		}
+		if canonical := factory.CanonicalizeEmail(email); canonical != current {
+			changed[id] = canonical
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// 入れ替わるように変わる行があっても一意制約に違反しないよう、一旦メールアドレスにならない値にしてから書き換える
+	for id := range changed {
+		if _, err := tx.ExecContext(ctx, "UPDATE users SET email_canonical = ? WHERE id = ?", "~"+id, id); err != nil {
+			return err
+		}
+	}
+	for id, canonical := range changed {
+		if _, err := tx.ExecContext(ctx, "UPDATE users SET email_canonical = ? WHERE id = ?", canonical, id); err != nil {
+			return fmt.Errorf("ユーザー%sのメールアドレスが他のユーザーと重複します: %w", id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,362 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupMigrator(t *testing.T) (*gorm.DB, *Migrator) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	migrator, err := NewMigrator(db, testUserFactory)
+	assert.NoError(t, err)
+	return db, migrator
+}
+
+func TestMigrator_Up(t *testing.T) {
+	t.Run("成功: 未適用のマイグレーションを順に適用する", func(t *testing.T) {
+		// Arrange
+		db, migrator := setupMigrator(t)
+
+		// Act
+		applied, err := migrator.Up(context.Background())
+		again, errAgain := migrator.Up(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, applied, len(migrator.migrations))
+		for i := 1; i < len(applied); i++ {
+			assert.Less(t, applied[i-1].Version, applied[i].Version)
+		}
+		assert.NoError(t, errAgain)
+		assert.Empty(t, again)
+		assert.True(t, db.Migrator().HasTable("users"))
+		assert.True(t, db.Migrator().HasTable("refresh_tokens"))
+	})
+
+	t.Run("失敗: 適用済みのマイグレーションが変更されている", func(t *testing.T) {
+		// Arrange
+		db, migrator := setupMigrator(t)
+		_, err := migrator.Up(context.Background())
+		assert.NoError(t, err)
+		assert.NoError(t, db.Exec("UPDATE schema_migrations SET checksum = 'modified' WHERE version = 1").Error)
+
+		// Act
+		_, err = migrator.Up(context.Background())
+		statuses, statusErr := migrator.Status(context.Background())
+
+		// Assert
+		assert.ErrorContains(t, err, "変更されています")
+		assert.NoError(t, statusErr)
+		assert.True(t, statuses[0].Dirty)
+	})
+
+	t.Run("失敗: 他のプロセスがロックを取得している", func(t *testing.T) {
+		// Arrange
+		db, migrator := setupMigrator(t)
+		_, err := migrator.Up(context.Background())
+		assert.NoError(t, err)
+		assert.NoError(t, db.Exec("INSERT INTO schema_migrations_lock (id, holder, locked_at) VALUES (1, 'other-host:123', ?)", time.Now().UTC()).Error)
+
+		// Act
+		_, err = migrator.Down(context.Background(), 1)
+
+		// Assert
+		assert.ErrorContains(t, err, "他のプロセスがマイグレーションを実行中です(other-host:123")
+		assert.True(t, db.Migrator().HasTable("refresh_tokens"))
+	})
+
+	t.Run("失敗: 古いロックも実行中のものと区別できないため自動では取り除かない", func(t *testing.T) {
+		// Arrange
+		db, migrator := setupMigrator(t)
+		_, err := migrator.Up(context.Background())
+		assert.NoError(t, err)
+		lockedAt := time.Now().UTC().Add(-24 * time.Hour)
+		assert.NoError(t, db.Exec("INSERT INTO schema_migrations_lock (id, holder, locked_at) VALUES (1, 'long-running-host:123', ?)", lockedAt).Error)
+
+		// Act
+		_, err = migrator.Down(context.Background(), 1)
+
+		// Assert
+		assert.ErrorContains(t, err, "migrate unlock")
+		var holder string
+		assert.NoError(t, db.Raw("SELECT holder FROM schema_migrations_lock WHERE id = 1").Scan(&holder).Error)
+		assert.Equal(t, "long-running-host:123", holder)
+	})
+
+	t.Run("成功: Unlockで残ったロックを解放できる", func(t *testing.T) {
+		// Arrange
+		db, migrator := setupMigrator(t)
+		_, err := migrator.Up(context.Background())
+		assert.NoError(t, err)
+		assert.NoError(t, db.Exec("INSERT INTO schema_migrations_lock (id, holder, locked_at) VALUES (1, 'crashed-host:123', ?)", time.Now().UTC()).Error)
+
+		// Act
+		errUnlock := migrator.Unlock(context.Background())
+		_, err = migrator.Down(context.Background(), 1)
+
+		// Assert
+		assert.NoError(t, errUnlock)
+		assert.NoError(t, err)
+	})
+}
+
+func TestMigrator_DownAndRedo(t *testing.T) {
+	// Arrange
+	db, migrator := setupMigrator(t)
+	_, err := migrator.Up(context.Background())
+	assert.NoError(t, err)
+	last := migrator.migrations[len(migrator.migrations)-1]
+
+	// Act & Assert
+	redone, err := migrator.Redo(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, last.Version, redone.Version)
+
+	reverted, err := migrator.Down(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{last.Version}, versionsOf(reverted))
+
+	statuses, err := migrator.Status(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[len(statuses)-1].AppliedAt)
+
+	reverted, err = migrator.Down(context.Background(), len(migrator.migrations))
+	assert.NoError(t, err)
+	assert.Len(t, reverted, len(migrator.migrations)-1)
+	assert.False(t, db.Migrator().HasTable("users"))
+	assert.False(t, db.Migrator().HasTable("refresh_tokens"))
+}
+
+func TestMigrator_RecanonicalizeUsersEmail(t *testing.T) {
+	t.Run("成功: LOWERで埋めた正規形をGoの正規化で計算し直す", func(t *testing.T) {
+		// Arrange
+		db, migrator := setupMigrator(t)
+		_, err := migrator.Up(context.Background())
+		assert.NoError(t, err)
+		steps := 0
+		for _, migration := range migrator.migrations {
+			if migration.Version >= 12 {
+				steps++
+			}
+		}
+		_, err = migrator.Down(context.Background(), steps)
+		assert.NoError(t, err)
+		now := time.Now().UTC()
+		insert := "INSERT INTO users (id, username, email, email_canonical, password, created_at, updated_at) VALUES (?, ?, ?, ?, 'hash', ?, ?)"
+		assert.NoError(t, db.Exec(insert, "user-1", "user1", "User@Bücher.de", "user@bücher.de", now, now).Error)
+		assert.NoError(t, db.Exec(insert, "user-2", "user2", "Ünïcode@example.com", "Ünïcode@example.com", now, now).Error)
+		assert.NoError(t, db.Exec(insert, "user-3", "user3", "plain@example.com", "plain@example.com", now, now).Error)
+
+		// Act
+		applied, err := migrator.Up(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(12), applied[0].Version)
+		canonicals := map[string]string{}
+		rows, err := db.Raw("SELECT id, email_canonical FROM users").Rows()
+		assert.NoError(t, err)
+		defer rows.Close()
+		for rows.Next() {
+			var id, canonical string
+			assert.NoError(t, rows.Scan(&id, &canonical))
+			canonicals[id] = canonical
+		}
+		assert.Equal(t, map[string]string{
+			"user-1": "user@xn--bcher-kva.de",
+			"user-2": "ünïcode@example.com",
+			"user-3": "plain@example.com",
+		}, canonicals)
+	})
+
+	t.Run("成功: Goの手順は全て対応するSQLのバージョンを持つ", func(t *testing.T) {
+		// Arrange
+		_, migrator := setupMigrator(t)
+
+		// Act
+		attached := 0
+		for _, migration := range migrator.migrations {
+			if migration.step != nil {
+				attached++
+			}
+		}
+
+		// Assert
+		assert.Equal(t, len(migrationSteps), attached)
+	})
+}
+
+func TestMigrator_SyncEmailCanonicalRules(t *testing.T) {
+	plusFactory, err := model.NewUserFactory(testIDs, model.EmailPolicy{CanonicalizePlusAddress: true}, model.DefaultPasswordPolicy())
+	assert.NoError(t, err)
+	emailCanonicals := func(t *testing.T, db *gorm.DB) map[string]string {
+		canonicals := map[string]string{}
+		rows, err := db.Raw("SELECT id, email_canonical FROM users").Rows()
+		assert.NoError(t, err)
+		defer rows.Close()
+		for rows.Next() {
+			var id, canonical string
+			assert.NoError(t, rows.Scan(&id, &canonical))
+			canonicals[id] = canonical
+		}
+		return canonicals
+	}
+	recordedRules := func(t *testing.T, db *gorm.DB) string {
+		var rules string
+		assert.NoError(t, db.Raw("SELECT value FROM app_settings WHERE name = ?", emailCanonicalRulesSetting).Scan(&rules).Error)
+		return rules
+	}
+	insertUser := func(t *testing.T, db *gorm.DB, id string, email string) {
+		now := time.Now().UTC()
+		assert.NoError(t, db.Exec("INSERT INTO users (id, username, email, email_canonical, password, created_at, updated_at) VALUES (?, ?, ?, ?, 'hash', ?, ?)",
+			id, id, email, testUserFactory.CanonicalizeEmail(email), now, now).Error)
+	}
+
+	t.Run("成功: サブアドレスを無視する設定に変えると計算し直し、戻すと元に戻る", func(t *testing.T) {
+		// Arrange
+		db, migrator := setupMigrator(t)
+		_, err := migrator.Up(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, testUserFactory.EmailCanonicalRules(), recordedRules(t, db))
+		insertUser(t, db, "user-1", "Test+News@example.com")
+		insertUser(t, db, "user-2", "other@example.com")
+
+		// Act
+		migrator.factory = plusFactory
+		_, errOn := migrator.Up(context.Background())
+		on := emailCanonicals(t, db)
+		onRules := recordedRules(t, db)
+		migrator.factory = testUserFactory
+		_, errOff := migrator.Up(context.Background())
+		off := emailCanonicals(t, db)
+
+		// Assert
+		assert.NoError(t, errOn)
+		assert.Equal(t, map[string]string{"user-1": "test@example.com", "user-2": "other@example.com"}, on)
+		assert.Equal(t, plusFactory.EmailCanonicalRules(), onRules)
+		assert.NoError(t, errOff)
+		assert.Equal(t, map[string]string{"user-1": "test+news@example.com", "user-2": "other@example.com"}, off)
+		assert.Equal(t, testUserFactory.EmailCanonicalRules(), recordedRules(t, db))
+	})
+
+	t.Run("失敗: 新しい規則で重複するユーザーがいる場合は設定を反映しない", func(t *testing.T) {
+		// Arrange
+		db, migrator := setupMigrator(t)
+		_, err := migrator.Up(context.Background())
+		assert.NoError(t, err)
+		insertUser(t, db, "user-1", "test@example.com")
+		insertUser(t, db, "user-2", "test+news@example.com")
+
+		// Act
+		migrator.factory = plusFactory
+		_, err = migrator.Up(context.Background())
+
+		// Assert
+		assert.Error(t, err)
+		assert.Equal(t, map[string]string{"user-1": "test@example.com", "user-2": "test+news@example.com"}, emailCanonicals(t, db))
+		assert.Equal(t, testUserFactory.EmailCanonicalRules(), recordedRules(t, db))
+	})
+}
+
+func TestMigrator_CreateOneTimeTokens(t *testing.T) {
+	t.Run("成功: 確認トークンと再設定トークンを用途を付けて1つの表に移す", func(t *testing.T) {
+		// Arrange
+		db, migrator := setupMigrator(t)
+		_, err := migrator.Up(context.Background())
+		assert.NoError(t, err)
+		steps := 0
+		for _, migration := range migrator.migrations {
+			if migration.Version >= 13 {
+				steps++
+			}
+		}
+		_, err = migrator.Down(context.Background(), steps)
+		assert.NoError(t, err)
+		now := time.Now().UTC()
+		assert.NoError(t, db.Exec("INSERT INTO users (id, username, email, email_canonical, password, created_at, updated_at) VALUES ('user-1', 'user1', 'user1@example.com', 'user1@example.com', 'hash', ?, ?)", now, now).Error)
+		assert.NoError(t, db.Exec("INSERT INTO email_verification_tokens (id, user_id, email, token_hash, expires_at, created_at) VALUES ('token-1', 'user-1', 'old@example.com', 'hash-1', ?, ?)", now, now).Error)
+		assert.NoError(t, db.Exec("INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, created_at) VALUES ('token-2', 'user-1', 'hash-2', ?, ?)", now, now).Error)
+
+		// Act
+		_, err = migrator.Up(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+		var tokens []model.OneTimeToken
+		assert.NoError(t, db.Order("id").Find(&tokens).Error)
+		assert.Len(t, tokens, 2)
+		assert.Equal(t, model.TokenPurposeEmailVerification, tokens[0].Purpose)
+		assert.Equal(t, "old@example.com", tokens[0].Email)
+		assert.Equal(t, model.TokenPurposePasswordReset, tokens[1].Purpose)
+		assert.Equal(t, "user1@example.com", tokens[1].Email)
+		assert.False(t, db.Migrator().HasTable("email_verification_tokens"))
+		assert.False(t, db.Migrator().HasTable("password_reset_tokens"))
+	})
+}
+
+func versionsOf(migrations []Migration) []int64 {
+	versions := make([]int64, len(migrations))
+	for i, m := range migrations {
+		versions[i] = m.Version
+	}
+	return versions
+}
+
+func TestCreateMigration(t *testing.T) {
+	t.Run("成功: 次のバージョンのファイルを全てのDB用に作成する", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		for _, dialect := range []string{"mysql", "sqlite"} {
+			assert.NoError(t, os.MkdirAll(filepath.Join(dir, dialect), 0o755))
+		}
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "mysql", "0007_create_users.up.sql"), nil, 0o644))
+
+		// Act
+		paths, err := CreateMigration(dir, "add_index")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			filepath.Join(dir, "mysql", "0008_add_index.up.sql"),
+			filepath.Join(dir, "mysql", "0008_add_index.down.sql"),
+			filepath.Join(dir, "sqlite", "0008_add_index.up.sql"),
+			filepath.Join(dir, "sqlite", "0008_add_index.down.sql"),
+		}, paths)
+		migrations, err := loadMigrations(os.DirFS(filepath.Join(dir, "sqlite")))
+		assert.NoError(t, err)
+		assert.Len(t, migrations, 1)
+	})
+
+	t.Run("失敗: 名前が不正", func(t *testing.T) {
+		// Act
+		_, err := CreateMigration(t.TempDir(), "Add Index")
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
+
+func TestSplitStatements(t *testing.T) {
+	// Arrange
+	script := "-- comment\nCREATE TABLE a (\n    id INTEGER\n);\n\nCREATE INDEX idx_a ON a (id);\n"
+
+	// Act
+	statements := splitStatements(script)
+
+	// Assert
+	assert.Equal(t, []string{"CREATE TABLE a (\n    id INTEGER\n);", "CREATE INDEX idx_a ON a (id);"}, statements)
+}
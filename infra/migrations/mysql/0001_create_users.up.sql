@@ -0,0 +1,12 @@
+CREATE TABLE users (
+    id         CHAR(36)     NOT NULL,
+    username   VARCHAR(20)  NOT NULL,
+    email      VARCHAR(254) NOT NULL,
+    password   VARCHAR(255) NOT NULL,
+    role       VARCHAR(20)  NOT NULL DEFAULT 'member',
+    version    INT          NOT NULL DEFAULT 1,
+    created_at DATETIME(3)  NOT NULL,
+    updated_at DATETIME(3)  NOT NULL,
+    PRIMARY KEY (id),
+    KEY idx_users_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,15 @@
+CREATE TABLE refresh_tokens (
+    id         CHAR(36)    NOT NULL,
+    user_id    CHAR(36)    NOT NULL,
+    family_id  CHAR(36)    NOT NULL,
+    token_hash CHAR(64)    NOT NULL,
+    expires_at DATETIME(3) NOT NULL,
+    used_at    DATETIME(3) NULL,
+    revoked_at DATETIME(3) NULL,
+    created_at DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_refresh_tokens_token_hash (token_hash),
+    KEY idx_refresh_tokens_family_id (family_id),
+    KEY idx_refresh_tokens_user_id (user_id),
+    KEY idx_refresh_tokens_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1 @@
+ALTER TABLE users DROP INDEX idx_users_username_lower, DROP INDEX idx_users_email_lower;
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP INDEX idx_users_email_canonical;
+CREATE UNIQUE INDEX idx_users_email_lower ON users ((LOWER(email)));
+ALTER TABLE users DROP COLUMN email_canonical;
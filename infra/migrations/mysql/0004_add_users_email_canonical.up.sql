@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN email_canonical VARCHAR(254) NOT NULL DEFAULT '' AFTER email;
+UPDATE users SET email_canonical = LOWER(email);
+ALTER TABLE users DROP INDEX idx_users_email_lower;
+CREATE UNIQUE INDEX idx_users_email_canonical ON users (email_canonical);
@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN email_verified_at DATETIME(3) NULL AFTER email_canonical;
+-- 確認の仕組みを導入する前に登録されたユーザーは確認済みとして扱う
+UPDATE users SET email_verified_at = created_at;
+CREATE TABLE email_verification_tokens (
+    id         CHAR(36)     NOT NULL,
+    user_id    CHAR(36)     NOT NULL,
+    email      VARCHAR(254) NOT NULL,
+    token_hash CHAR(64)     NOT NULL,
+    expires_at DATETIME(3)  NOT NULL,
+    used_at    DATETIME(3)  NULL,
+    created_at DATETIME(3)  NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_email_verification_tokens_token_hash (token_hash),
+    KEY idx_email_verification_tokens_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
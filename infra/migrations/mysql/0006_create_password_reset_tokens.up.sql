@@ -0,0 +1,11 @@
+CREATE TABLE password_reset_tokens (
+    id         CHAR(36)    NOT NULL,
+    user_id    CHAR(36)    NOT NULL,
+    token_hash CHAR(64)    NOT NULL,
+    expires_at DATETIME(3) NOT NULL,
+    used_at    DATETIME(3) NULL,
+    created_at DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_password_reset_tokens_token_hash (token_hash),
+    KEY idx_password_reset_tokens_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
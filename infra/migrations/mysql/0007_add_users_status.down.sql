@@ -0,0 +1,4 @@
+ALTER TABLE users DROP INDEX idx_users_status;
+ALTER TABLE users DROP COLUMN status_changed_at;
+ALTER TABLE users DROP COLUMN status_reason;
+ALTER TABLE users DROP COLUMN status;
@@ -0,0 +1,4 @@
+-- 列を削除すると論理削除されたユーザーが削除されていないものとして扱われるため、先に完全に削除する
+DELETE FROM users WHERE deleted_at IS NOT NULL;
+ALTER TABLE users DROP INDEX idx_users_deleted_at;
+ALTER TABLE users DROP COLUMN deleted_at;
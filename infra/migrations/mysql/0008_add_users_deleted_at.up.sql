@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN deleted_at DATETIME(3) NULL AFTER updated_at;
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
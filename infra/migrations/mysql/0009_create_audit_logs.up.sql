@@ -0,0 +1,15 @@
+-- 追記のみのテーブル。ユーザーを完全に削除しても記録は残すため外部キーは張らない
+CREATE TABLE audit_logs (
+    id         CHAR(36)     NOT NULL,
+    actor_id   VARCHAR(36)  NOT NULL DEFAULT '',
+    action     VARCHAR(50)  NOT NULL,
+    target_id  CHAR(36)     NOT NULL,
+    changes    JSON         NOT NULL,
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    ip         VARCHAR(45)  NOT NULL DEFAULT '',
+    created_at DATETIME(6)  NOT NULL,
+    PRIMARY KEY (id),
+    KEY idx_audit_logs_created_at (created_at, id),
+    KEY idx_audit_logs_target_id (target_id, created_at),
+    KEY idx_audit_logs_actor_id (actor_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
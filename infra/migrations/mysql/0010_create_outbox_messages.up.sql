@@ -0,0 +1,15 @@
+CREATE TABLE outbox_messages (
+    id              CHAR(36)      NOT NULL,
+    event_type      VARCHAR(50)   NOT NULL,
+    aggregate_id    CHAR(36)      NOT NULL,
+    payload         JSON          NOT NULL,
+    occurred_at     DATETIME(6)   NOT NULL,
+    attempts        INT           NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME(6)   NOT NULL,
+    last_error      VARCHAR(1000) NOT NULL DEFAULT '',
+    published_at    DATETIME(6)   NULL,
+    created_at      DATETIME(6)   NOT NULL,
+    PRIMARY KEY (id),
+    KEY idx_outbox_messages_pending (published_at, next_attempt_at),
+    KEY idx_outbox_messages_aggregate_id (aggregate_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
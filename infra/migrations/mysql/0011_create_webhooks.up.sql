@@ -0,0 +1,43 @@
+-- 購読先を削除する際の配信履歴の削除はリポジトリが同じトランザクションで行う
+CREATE TABLE webhook_subscriptions (
+    id                   CHAR(36)      NOT NULL,
+    url                  VARCHAR(2048) NOT NULL,
+    event_types          VARCHAR(255)  NOT NULL,
+    description          VARCHAR(255)  NOT NULL DEFAULT '',
+    secret               VARCHAR(64)   NOT NULL,
+    active               BOOLEAN       NOT NULL,
+    consecutive_failures INT           NOT NULL DEFAULT 0,
+    disabled_at          DATETIME(3)   NULL,
+    created_at           DATETIME(3)   NOT NULL,
+    updated_at           DATETIME(3)   NOT NULL,
+    PRIMARY KEY (id),
+    KEY idx_webhook_subscriptions_active (active)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+CREATE TABLE webhook_deliveries (
+    id               CHAR(36)      NOT NULL,
+    subscription_id  CHAR(36)      NOT NULL,
+    event_id         CHAR(36)      NOT NULL,
+    event_type       VARCHAR(50)   NOT NULL,
+    payload          JSON          NOT NULL,
+    status           VARCHAR(20)   NOT NULL,
+    attempts         INT           NOT NULL DEFAULT 0,
+    next_attempt_at  DATETIME(6)   NOT NULL,
+    last_status_code INT           NOT NULL DEFAULT 0,
+    last_error       VARCHAR(1000) NOT NULL DEFAULT '',
+    delivered_at     DATETIME(6)   NULL,
+    created_at       DATETIME(6)   NOT NULL,
+    updated_at       DATETIME(6)   NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_webhook_deliveries_subscription_event (subscription_id, event_id),
+    KEY idx_webhook_deliveries_due (status, next_attempt_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+CREATE TABLE webhook_delivery_attempts (
+    id           CHAR(36)      NOT NULL,
+    delivery_id  CHAR(36)      NOT NULL,
+    status_code  INT           NOT NULL DEFAULT 0,
+    error        VARCHAR(1000) NOT NULL DEFAULT '',
+    duration_ms  BIGINT        NOT NULL,
+    attempted_at DATETIME(6)   NOT NULL,
+    PRIMARY KEY (id),
+    KEY idx_webhook_delivery_attempts_delivery_id (delivery_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
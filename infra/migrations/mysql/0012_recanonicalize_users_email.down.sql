@@ -0,0 +1 @@
+-- 計算し直した値も正しい正規形のため、元には戻さない
@@ -0,0 +1,28 @@
+CREATE TABLE email_verification_tokens (
+    id         CHAR(36)     NOT NULL,
+    user_id    CHAR(36)     NOT NULL,
+    email      VARCHAR(254) NOT NULL,
+    token_hash CHAR(64)     NOT NULL,
+    expires_at DATETIME(3)  NOT NULL,
+    used_at    DATETIME(3)  NULL,
+    created_at DATETIME(3)  NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_email_verification_tokens_token_hash (token_hash),
+    KEY idx_email_verification_tokens_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+CREATE TABLE password_reset_tokens (
+    id         CHAR(36)    NOT NULL,
+    user_id    CHAR(36)    NOT NULL,
+    token_hash CHAR(64)    NOT NULL,
+    expires_at DATETIME(3) NOT NULL,
+    used_at    DATETIME(3) NULL,
+    created_at DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_password_reset_tokens_token_hash (token_hash),
+    KEY idx_password_reset_tokens_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+INSERT INTO email_verification_tokens (id, user_id, email, token_hash, expires_at, used_at, created_at)
+SELECT id, user_id, email, token_hash, expires_at, used_at, created_at FROM one_time_tokens WHERE purpose = 'email-verification';
+INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, used_at, created_at)
+SELECT id, user_id, token_hash, expires_at, used_at, created_at FROM one_time_tokens WHERE purpose = 'password-reset';
+DROP TABLE one_time_tokens;
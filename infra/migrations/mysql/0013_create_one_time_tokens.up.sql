@@ -0,0 +1,21 @@
+CREATE TABLE one_time_tokens (
+    id         CHAR(36)     NOT NULL,
+    user_id    CHAR(36)     NOT NULL,
+    purpose    VARCHAR(32)  NOT NULL,
+    email      VARCHAR(254) NOT NULL,
+    token_hash CHAR(64)     NOT NULL,
+    expires_at DATETIME(3)  NOT NULL,
+    used_at    DATETIME(3)  NULL,
+    created_at DATETIME(3)  NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_one_time_tokens_token_hash (token_hash),
+    KEY idx_one_time_tokens_user_id_purpose (user_id, purpose)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+INSERT INTO one_time_tokens (id, user_id, purpose, email, token_hash, expires_at, used_at, created_at)
+SELECT id, user_id, 'email-verification', email, token_hash, expires_at, used_at, created_at FROM email_verification_tokens;
+-- 再設定トークンは発行時のメールアドレスを保持していなかったため、現在のメールアドレスで埋める
+INSERT INTO one_time_tokens (id, user_id, purpose, email, token_hash, expires_at, used_at, created_at)
+SELECT t.id, t.user_id, 'password-reset', COALESCE(u.email, ''), t.token_hash, t.expires_at, t.used_at, t.created_at
+FROM password_reset_tokens t LEFT JOIN users u ON u.id = t.user_id;
+DROP TABLE email_verification_tokens;
+DROP TABLE password_reset_tokens;
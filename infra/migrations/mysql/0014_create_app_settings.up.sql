@@ -0,0 +1,6 @@
+-- アプリケーションの設定のうち、保存済みのデータがどの値で作られたかを記録するもの
+CREATE TABLE app_settings (
+    name  VARCHAR(64)  NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    PRIMARY KEY (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,11 @@
+CREATE TABLE users (
+    id         TEXT     NOT NULL PRIMARY KEY,
+    username   TEXT     NOT NULL,
+    email      TEXT     NOT NULL,
+    password   TEXT     NOT NULL,
+    role       TEXT     NOT NULL DEFAULT 'member',
+    version    INTEGER  NOT NULL DEFAULT 1,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+CREATE INDEX idx_users_created_at ON users (created_at);
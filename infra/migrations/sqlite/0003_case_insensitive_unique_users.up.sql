@@ -0,0 +1,2 @@
+CREATE UNIQUE INDEX idx_users_username_lower ON users (LOWER(username));
+CREATE UNIQUE INDEX idx_users_email_lower ON users (LOWER(email));
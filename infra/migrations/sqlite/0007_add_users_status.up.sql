@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active';
+ALTER TABLE users ADD COLUMN status_reason VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN status_changed_at DATETIME;
+-- メールアドレスが未確認のユーザーは確認待ちとして扱う
+UPDATE users SET status = 'pending' WHERE email_verified_at IS NULL;
+CREATE INDEX idx_users_status ON users (status);
@@ -0,0 +1,14 @@
+-- 追記のみのテーブル。ユーザーを完全に削除しても記録は残すため外部キーは張らない
+CREATE TABLE audit_logs (
+    id         TEXT     NOT NULL PRIMARY KEY,
+    actor_id   TEXT     NOT NULL DEFAULT '',
+    action     TEXT     NOT NULL,
+    target_id  TEXT     NOT NULL,
+    changes    TEXT     NOT NULL,
+    request_id TEXT     NOT NULL DEFAULT '',
+    ip         TEXT     NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL
+);
+CREATE INDEX idx_audit_logs_created_at ON audit_logs (created_at, id);
+CREATE INDEX idx_audit_logs_target_id ON audit_logs (target_id, created_at);
+CREATE INDEX idx_audit_logs_actor_id ON audit_logs (actor_id, created_at);
@@ -0,0 +1,14 @@
+CREATE TABLE outbox_messages (
+    id              TEXT     NOT NULL PRIMARY KEY,
+    event_type      TEXT     NOT NULL,
+    aggregate_id    TEXT     NOT NULL,
+    payload         TEXT     NOT NULL,
+    occurred_at     DATETIME NOT NULL,
+    attempts        INTEGER  NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    last_error      TEXT     NOT NULL DEFAULT '',
+    published_at    DATETIME,
+    created_at      DATETIME NOT NULL
+);
+CREATE INDEX idx_outbox_messages_pending ON outbox_messages (published_at, next_attempt_at);
+CREATE INDEX idx_outbox_messages_aggregate_id ON outbox_messages (aggregate_id);
@@ -0,0 +1,40 @@
+-- 購読先を削除する際の配信履歴の削除はリポジトリが同じトランザクションで行う
+CREATE TABLE webhook_subscriptions (
+    id                   TEXT     NOT NULL PRIMARY KEY,
+    url                  TEXT     NOT NULL,
+    event_types          TEXT     NOT NULL,
+    description          TEXT     NOT NULL DEFAULT '',
+    secret               TEXT     NOT NULL,
+    active               BOOLEAN  NOT NULL,
+    consecutive_failures INTEGER  NOT NULL DEFAULT 0,
+    disabled_at          DATETIME,
+    created_at           DATETIME NOT NULL,
+    updated_at           DATETIME NOT NULL
+);
+CREATE INDEX idx_webhook_subscriptions_active ON webhook_subscriptions (active);
+CREATE TABLE webhook_deliveries (
+    id               TEXT     NOT NULL PRIMARY KEY,
+    subscription_id  TEXT     NOT NULL,
+    event_id         TEXT     NOT NULL,
+    event_type       TEXT     NOT NULL,
+    payload          TEXT     NOT NULL,
+    status           TEXT     NOT NULL,
+    attempts         INTEGER  NOT NULL DEFAULT 0,
+    next_attempt_at  DATETIME NOT NULL,
+    last_status_code INTEGER  NOT NULL DEFAULT 0,
+    last_error       TEXT     NOT NULL DEFAULT '',
+    delivered_at     DATETIME,
+    created_at       DATETIME NOT NULL,
+    updated_at       DATETIME NOT NULL
+);
+CREATE UNIQUE INDEX idx_webhook_deliveries_subscription_event ON webhook_deliveries (subscription_id, event_id);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+CREATE TABLE webhook_delivery_attempts (
+    id           TEXT     NOT NULL PRIMARY KEY,
+    delivery_id  TEXT     NOT NULL,
+    status_code  INTEGER  NOT NULL DEFAULT 0,
+    error        TEXT     NOT NULL DEFAULT '',
+    duration_ms  INTEGER  NOT NULL,
+    attempted_at DATETIME NOT NULL
+);
+CREATE INDEX idx_webhook_delivery_attempts_delivery_id ON webhook_delivery_attempts (delivery_id);
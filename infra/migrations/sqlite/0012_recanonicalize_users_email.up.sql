@@ -0,0 +1 @@
+-- email_canonicalをGoのmodel.CanonicalizeEmailで計算し直す。処理はinfra/migration_step.goのrecanonicalizeUsersEmail
@@ -0,0 +1,26 @@
+CREATE TABLE email_verification_tokens (
+    id         TEXT     NOT NULL PRIMARY KEY,
+    user_id    TEXT     NOT NULL,
+    email      TEXT     NOT NULL,
+    token_hash TEXT     NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at    DATETIME,
+    created_at DATETIME NOT NULL
+);
+CREATE UNIQUE INDEX idx_email_verification_tokens_token_hash ON email_verification_tokens (token_hash);
+CREATE INDEX idx_email_verification_tokens_user_id ON email_verification_tokens (user_id);
+CREATE TABLE password_reset_tokens (
+    id         TEXT     NOT NULL PRIMARY KEY,
+    user_id    TEXT     NOT NULL,
+    token_hash TEXT     NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at    DATETIME,
+    created_at DATETIME NOT NULL
+);
+CREATE UNIQUE INDEX idx_password_reset_tokens_token_hash ON password_reset_tokens (token_hash);
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
+INSERT INTO email_verification_tokens (id, user_id, email, token_hash, expires_at, used_at, created_at)
+SELECT id, user_id, email, token_hash, expires_at, used_at, created_at FROM one_time_tokens WHERE purpose = 'email-verification';
+INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, used_at, created_at)
+SELECT id, user_id, token_hash, expires_at, used_at, created_at FROM one_time_tokens WHERE purpose = 'password-reset';
+DROP TABLE one_time_tokens;
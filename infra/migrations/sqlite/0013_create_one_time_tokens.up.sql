@@ -0,0 +1,20 @@
+CREATE TABLE one_time_tokens (
+    id         TEXT     NOT NULL PRIMARY KEY,
+    user_id    TEXT     NOT NULL,
+    purpose    TEXT     NOT NULL,
+    email      TEXT     NOT NULL,
+    token_hash TEXT     NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at    DATETIME,
+    created_at DATETIME NOT NULL
+);
+CREATE UNIQUE INDEX idx_one_time_tokens_token_hash ON one_time_tokens (token_hash);
+CREATE INDEX idx_one_time_tokens_user_id_purpose ON one_time_tokens (user_id, purpose);
+INSERT INTO one_time_tokens (id, user_id, purpose, email, token_hash, expires_at, used_at, created_at)
+SELECT id, user_id, 'email-verification', email, token_hash, expires_at, used_at, created_at FROM email_verification_tokens;
+-- 再設定トークンは発行時のメールアドレスを保持していなかったため、現在のメールアドレスで埋める
+INSERT INTO one_time_tokens (id, user_id, purpose, email, token_hash, expires_at, used_at, created_at)
+SELECT t.id, t.user_id, 'password-reset', COALESCE(u.email, ''), t.token_hash, t.expires_at, t.used_at, t.created_at
+FROM password_reset_tokens t LEFT JOIN users u ON u.id = t.user_id;
+DROP TABLE email_verification_tokens;
+DROP TABLE password_reset_tokens;
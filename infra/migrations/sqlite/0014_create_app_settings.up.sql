@@ -0,0 +1,5 @@
+-- アプリケーションの設定のうち、保存済みのデータがどの値で作られたかを記録するもの
+CREATE TABLE app_settings (
+    name  TEXT NOT NULL PRIMARY KEY,
+    value TEXT NOT NULL
+);
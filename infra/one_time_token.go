@@ -0,0 +1,49 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OneTimeTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewOneTimeTokenRepository(db *gorm.DB) repository.OneTimeTokenRepository {
+	return &OneTimeTokenRepository{db: db}
+}
+
+func (r *OneTimeTokenRepository) Create(ctx context.Context, token *model.OneTimeToken) error {
+	return conn(ctx, r.db).Create(token).Error
+}
+
+func (r *OneTimeTokenRepository) FindByHash(ctx context.Context, purpose model.TokenPurpose, hash string) (*model.OneTimeToken, error) {
+	token := &model.OneTimeToken{}
+
+	if err := conn(ctx, r.db).Where("purpose = ? AND token_hash = ?", purpose, hash).First(token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.NewNotFoundError("トークンが見つかりません", err)
+		}
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *OneTimeTokenRepository) MarkUsed(ctx context.Context, id string, usedAt time.Time) (bool, error) {
+	result := conn(ctx, r.db).Model(&model.OneTimeToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *OneTimeTokenRepository) DeleteByUserID(ctx context.Context, purpose model.TokenPurpose, userID string) error {
+	return conn(ctx, r.db).Where("purpose = ? AND user_id = ?", purpose, userID).Delete(&model.OneTimeToken{}).Error
+}
@@ -0,0 +1,74 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOneTimeTokenRepository(t *testing.T) {
+	user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Email: "test@example.com"})
+
+	t.Run("成功: ハッシュで取得でき、使用済みにできるのは一度だけ", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &OneTimeTokenRepository{db: db}
+		token, raw, err := model.NewOneTimeToken(testIDs, model.TokenPurposeEmailVerification, user, time.Hour)
+		assert.NoError(t, err)
+		assert.NoError(t, repo.Create(context.Background(), &token))
+
+		// Act
+		found, findErr := repo.FindByHash(context.Background(), model.TokenPurposeEmailVerification, model.HashOneTimeToken(raw))
+		first, err1 := repo.MarkUsed(context.Background(), token.ID, time.Now())
+		second, err2 := repo.MarkUsed(context.Background(), token.ID, time.Now())
+
+		// Assert
+		assert.NoError(t, findErr)
+		assert.Equal(t, "test@example.com", found.Email)
+		assert.Equal(t, model.TokenPurposeEmailVerification, found.Purpose)
+		assert.NoError(t, err1)
+		assert.NoError(t, err2)
+		assert.True(t, first)
+		assert.False(t, second)
+	})
+
+	t.Run("失敗: 用途の異なるトークンは見つからない", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &OneTimeTokenRepository{db: db}
+		token, raw, err := model.NewOneTimeToken(testIDs, model.TokenPurposePasswordReset, user, time.Hour)
+		assert.NoError(t, err)
+		assert.NoError(t, repo.Create(context.Background(), &token))
+
+		// Act
+		_, err = repo.FindByHash(context.Background(), model.TokenPurposeEmailVerification, model.HashOneTimeToken(raw))
+
+		// Assert
+		assert.ErrorIs(t, err, model.ErrNotFound)
+	})
+
+	t.Run("成功: ユーザーの同じ用途のトークンだけを削除する", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &OneTimeTokenRepository{db: db}
+		verification, verificationRaw, err := model.NewOneTimeToken(testIDs, model.TokenPurposeEmailVerification, user, time.Hour)
+		assert.NoError(t, err)
+		assert.NoError(t, repo.Create(context.Background(), &verification))
+		reset, resetRaw, err := model.NewOneTimeToken(testIDs, model.TokenPurposePasswordReset, user, time.Hour)
+		assert.NoError(t, err)
+		assert.NoError(t, repo.Create(context.Background(), &reset))
+
+		// Act
+		err = repo.DeleteByUserID(context.Background(), model.TokenPurposeEmailVerification, "user-id")
+
+		// Assert
+		assert.NoError(t, err)
+		_, err = repo.FindByHash(context.Background(), model.TokenPurposeEmailVerification, model.HashOneTimeToken(verificationRaw))
+		assert.ErrorIs(t, err, model.ErrNotFound)
+		_, err = repo.FindByHash(context.Background(), model.TokenPurposePasswordReset, model.HashOneTimeToken(resetRaw))
+		assert.NoError(t, err)
+	})
+}
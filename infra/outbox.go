@@ -0,0 +1,123 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxOutboxErrorLength last_errorなど失敗の理由を保存する列の文字数の上限
+const maxOutboxErrorLength = 1000
+
+// outboxRecord outbox_messagesテーブルの1行。日時は次に試す日時の比較がタイムゾーンに左右されないようUTCで保存する
+type outboxRecord struct {
+	ID            string     `gorm:"column:id;primaryKey"`
+	EventType     string     `gorm:"column:event_type"`
+	AggregateID   string     `gorm:"column:aggregate_id"`
+	Payload       string     `gorm:"column:payload"`
+	OccurredAt    time.Time  `gorm:"column:occurred_at"`
+	Attempts      int        `gorm:"column:attempts"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at"`
+	LastError     string     `gorm:"column:last_error"`
+	PublishedAt   *time.Time `gorm:"column:published_at"`
+	CreatedAt     time.Time  `gorm:"column:created_at"`
+}
+
+func (outboxRecord) TableName() string {
+	return "outbox_messages"
+}
+
+func newOutboxRecord(message *model.OutboxMessage) *outboxRecord {
+	return &outboxRecord{
+		ID:            message.ID,
+		EventType:     message.EventType,
+		AggregateID:   message.AggregateID,
+		Payload:       string(message.Payload),
+		OccurredAt:    message.OccurredAt.UTC(),
+		Attempts:      message.Attempts,
+		NextAttemptAt: message.NextAttemptAt.UTC(),
+		LastError:     message.LastError,
+		PublishedAt:   message.PublishedAt,
+		CreatedAt:     message.CreatedAt.UTC(),
+	}
+}
+
+func (r *outboxRecord) toDomain() *model.OutboxMessage {
+	return &model.OutboxMessage{
+		ID:            r.ID,
+		EventType:     r.EventType,
+		AggregateID:   r.AggregateID,
+		Payload:       []byte(r.Payload),
+		OccurredAt:    r.OccurredAt,
+		Attempts:      r.Attempts,
+		NextAttemptAt: r.NextAttemptAt,
+		LastError:     r.LastError,
+		PublishedAt:   r.PublishedAt,
+		CreatedAt:     r.CreatedAt,
+	}
+}
+
+// saveEvents 集約が記録したイベントをアウトボックスに書き込む。集約の変更と同じtxで呼び出す
+func saveEvents(tx *gorm.DB, ids model.IDGenerator, events []model.DomainEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	records := make([]*outboxRecord, len(events))
+	for i, event := range events {
+		message, err := model.NewOutboxMessage(ids, event)
+		if err != nil {
+			return err
+		}
+		records[i] = newOutboxRecord(&message)
+	}
+	return tx.Create(records).Error
+}
+
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) repository.OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// FindPending IDは作成時刻順に採番されるため、IDの順に並べて保存した順に返す
+func (r *OutboxRepository) FindPending(ctx context.Context, now time.Time, limit int) ([]*model.OutboxMessage, error) {
+	records := []*outboxRecord{}
+	if err := conn(ctx, r.db).
+		Where("published_at IS NULL AND next_attempt_at <= ?", now.UTC()).
+		Order("id").
+		Limit(limit).
+		Find(&records).Error; err != nil {
+		return nil, err
+	}
+	messages := make([]*model.OutboxMessage, len(records))
+	for i, record := range records {
+		messages[i] = record.toDomain()
+	}
+	return messages, nil
+}
+
+func (r *OutboxRepository) MarkPublished(ctx context.Context, id string, publishedAt time.Time) error {
+	return conn(ctx, r.db).Model(&outboxRecord{}).
+		Where("id = ?", id).
+		Update("published_at", publishedAt.UTC()).Error
+}
+
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id string, attempts int, nextAttemptAt time.Time, lastError string) error {
+	return conn(ctx, r.db).Model(&outboxRecord{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt.UTC(),
+			"last_error":      truncateRunes(lastError, maxOutboxErrorLength),
+		}).Error
+}
+
+func (r *OutboxRepository) DeletePublished(ctx context.Context, publishedBefore time.Time) (int64, error) {
+	result := conn(ctx, r.db).Where("published_at < ?", publishedBefore.UTC()).Delete(&outboxRecord{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,125 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func outboxEventTypes(t *testing.T, db *gorm.DB) []string {
+	var records []outboxRecord
+	assert.NoError(t, db.Order("id").Find(&records).Error)
+	types := make([]string, len(records))
+	for i, record := range records {
+		types[i] = record.EventType
+	}
+	return types
+}
+
+func TestUserRepository_Outbox(t *testing.T) {
+	t.Run("成功: ユーザーの保存と同じトランザクションでイベントを書き込む", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+		factory := newTestUserFactory(t)
+		user, err := factory.NewUser("testuser", "test@example.com", "password123")
+		assert.NoError(t, err)
+
+		// Act
+		_, err = repo.Create(context.Background(), &user)
+		assert.NoError(t, err)
+		email := "new@example.com"
+		assert.NoError(t, user.Change(factory, model.UserChanges{Email: &email}))
+		_, err = repo.Update(context.Background(), &user)
+		assert.NoError(t, err)
+		assert.NoError(t, user.Delete(time.Now()))
+		err = repo.Delete(context.Background(), &user)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Empty(t, user.Events())
+		assert.Equal(t, []string{model.EventTypeUserRegistered, model.EventTypeEmailChanged, model.EventTypeUserDeleted}, outboxEventTypes(t, db))
+	})
+
+	t.Run("失敗: ユーザーを保存できなければイベントも書き込まない", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+		factory := newTestUserFactory(t)
+		user, err := factory.NewUser("testuser", "test@example.com", "password123")
+		assert.NoError(t, err)
+		_, err = repo.Create(context.Background(), &user)
+		assert.NoError(t, err)
+		stale := model.ReconstructUser(model.UserSnapshot{ID: user.ID(), Username: "testuser", Email: "test@example.com", PasswordHash: user.PasswordHash(), Version: 0})
+		assert.NoError(t, stale.ChangePassword(factory, "newpassword1"))
+
+		// Act
+		_, err = repo.Update(context.Background(), stale)
+
+		// Assert
+		assert.ErrorIs(t, err, model.ErrConflict)
+		assert.Len(t, stale.Events(), 1)
+		assert.Equal(t, []string{model.EventTypeUserRegistered}, outboxEventTypes(t, db))
+	})
+}
+
+func TestOutboxRepository(t *testing.T) {
+	newMessage := func(t *testing.T, db *gorm.DB, userID string) *model.OutboxMessage {
+		message, err := model.NewOutboxMessage(testIDs, model.UserDeleted{UserID: userID, At: time.Now()})
+		assert.NoError(t, err)
+		assert.NoError(t, db.Create(newOutboxRecord(&message)).Error)
+		return &message
+	}
+
+	t.Run("成功: 配信時期を迎えた未配信のメッセージを保存した順に取得する", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &OutboxRepository{db: db}
+		first := newMessage(t, db, "user-1")
+		second := newMessage(t, db, "user-2")
+		failed := newMessage(t, db, "user-3")
+		published := newMessage(t, db, "user-4")
+		assert.NoError(t, repo.MarkFailed(context.Background(), failed.ID, 1, time.Now().Add(time.Minute), "connection refused"))
+		assert.NoError(t, repo.MarkPublished(context.Background(), published.ID, time.Now()))
+
+		// Act
+		pending, err := repo.FindPending(context.Background(), time.Now(), 10)
+		later, errLater := repo.FindPending(context.Background(), time.Now().Add(2*time.Minute), 10)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, errLater)
+		assert.Len(t, pending, 2)
+		assert.Equal(t, first.ID, pending[0].ID)
+		assert.Equal(t, second.ID, pending[1].ID)
+		assert.JSONEq(t, string(first.Payload), string(pending[0].Payload))
+		assert.Len(t, later, 3)
+		assert.Equal(t, 1, later[2].Attempts)
+		assert.Equal(t, "connection refused", later[2].LastError)
+	})
+
+	t.Run("成功: 保持期間を過ぎた配信済みのメッセージを削除する", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &OutboxRepository{db: db}
+		old := newMessage(t, db, "user-1")
+		recent := newMessage(t, db, "user-2")
+		newMessage(t, db, "user-3")
+		assert.NoError(t, repo.MarkPublished(context.Background(), old.ID, time.Now().Add(-2*time.Hour)))
+		assert.NoError(t, repo.MarkPublished(context.Background(), recent.ID, time.Now()))
+
+		// Act
+		deleted, err := repo.DeletePublished(context.Background(), time.Now().Add(-time.Hour))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+		var count int64
+		assert.NoError(t, db.Model(&outboxRecord{}).Count(&count).Error)
+		assert.Equal(t, int64(2), count)
+	})
+}
@@ -0,0 +1,60 @@
+package infra
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// MemoryRateLimiter usecase.RateLimiterの実装。キーごとにwindowの間limit回まで受け付ける固定窓方式で、
+// 回数はプロセス内で保持するため、複数台で動かす場合の上限は台数倍になる
+type MemoryRateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu      sync.Mutex
+	windows map[string]rateWindow
+	swept   time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func NewMemoryRateLimiter(limit int, window time.Duration) (*MemoryRateLimiter, error) {
+	if limit <= 0 || window <= 0 {
+		return nil, errors.New("rate limit and window must be positive")
+	}
+	return &MemoryRateLimiter{limit: limit, window: window, windows: map[string]rateWindow{}}, nil
+}
+
+func (l *MemoryRateLimiter) Allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !now.Before(l.swept.Add(l.window)) {
+		l.sweep(now)
+	}
+	w, ok := l.windows[key]
+	if !ok || !now.Before(w.start.Add(l.window)) {
+		w = rateWindow{start: now}
+	}
+	if w.count >= l.limit {
+		return false
+	}
+	w.count++
+	l.windows[key] = w
+	return true
+}
+
+// sweep 期間の過ぎたキーを削除し、送られたキーの数だけメモリが増え続けないようにする。
+// 毎回走査しないよう、削除はwindowごとに1回とする
+func (l *MemoryRateLimiter) sweep(now time.Time) {
+	l.swept = now
+	for key, w := range l.windows {
+		if !now.Before(w.start.Add(l.window)) {
+			delete(l.windows, key)
+		}
+	}
+}
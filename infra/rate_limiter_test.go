@@ -0,0 +1,47 @@
+package infra
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRateLimiter(t *testing.T) {
+	t.Run("成功: キーごとに上限まで受け付け、期間が過ぎると数え直す", func(t *testing.T) {
+		// Arrange
+		limiter, err := NewMemoryRateLimiter(2, time.Hour)
+		assert.NoError(t, err)
+		now := time.Now()
+
+		// Act & Assert
+		assert.True(t, limiter.Allow("a", now))
+		assert.True(t, limiter.Allow("a", now.Add(time.Minute)))
+		assert.False(t, limiter.Allow("a", now.Add(2*time.Minute)))
+		assert.True(t, limiter.Allow("b", now.Add(2*time.Minute)))
+		assert.True(t, limiter.Allow("a", now.Add(time.Hour)))
+	})
+
+	t.Run("成功: 期間の過ぎたキーを削除する", func(t *testing.T) {
+		// Arrange
+		limiter, err := NewMemoryRateLimiter(1, time.Hour)
+		assert.NoError(t, err)
+		now := time.Now()
+		limiter.Allow("a", now)
+
+		// Act
+		limiter.Allow("b", now.Add(time.Hour))
+
+		// Assert
+		assert.NotContains(t, limiter.windows, "a")
+		assert.Contains(t, limiter.windows, "b")
+	})
+
+	t.Run("失敗: 上限や期間が0以下", func(t *testing.T) {
+		_, err1 := NewMemoryRateLimiter(0, time.Hour)
+		_, err2 := NewMemoryRateLimiter(1, 0)
+
+		assert.Error(t, err1)
+		assert.Error(t, err2)
+	})
+}
@@ -0,0 +1,123 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshTokenRepository_Create(t *testing.T) {
+	t.Run("成功: ハッシュでトークンを取得できる", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &RefreshTokenRepository{db: db}
+		token, raw, err := model.NewRefreshToken(testIDs, "user-id", "", time.Hour)
+		assert.NoError(t, err)
+
+		// Act
+		err = repo.Create(context.Background(), &token)
+		result, findErr := repo.FindByHash(context.Background(), model.HashRefreshToken(raw))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, findErr)
+		assert.Equal(t, token.ID, result.ID)
+		assert.Equal(t, token.FamilyID, result.FamilyID)
+		assert.False(t, result.IsUsed())
+	})
+
+	t.Run("失敗: 存在しないハッシュ", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &RefreshTokenRepository{db: db}
+
+		// Act
+		result, err := repo.FindByHash(context.Background(), "unknown")
+
+		// Assert
+		assert.ErrorIs(t, err, model.ErrNotFound)
+		assert.Nil(t, result)
+	})
+}
+
+func TestRefreshTokenRepository_MarkUsed(t *testing.T) {
+	t.Run("成功: 二度目は使用済みとして扱う", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &RefreshTokenRepository{db: db}
+		token, _, err := model.NewRefreshToken(testIDs, "user-id", "", time.Hour)
+		assert.NoError(t, err)
+		assert.NoError(t, repo.Create(context.Background(), &token))
+
+		// Act
+		first, err1 := repo.MarkUsed(context.Background(), token.ID, time.Now())
+		second, err2 := repo.MarkUsed(context.Background(), token.ID, time.Now())
+
+		// Assert
+		assert.NoError(t, err1)
+		assert.NoError(t, err2)
+		assert.True(t, first)
+		assert.False(t, second)
+	})
+}
+
+func TestRefreshTokenRepository_Revoke(t *testing.T) {
+	t.Run("成功: 系列とユーザー単位で失効できる", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &RefreshTokenRepository{db: db}
+		first, raw1, _ := model.NewRefreshToken(testIDs, "user-id", "", time.Hour)
+		rotated, raw2, _ := model.NewRefreshToken(testIDs, "user-id", first.FamilyID, time.Hour)
+		otherDevice, raw3, _ := model.NewRefreshToken(testIDs, "user-id", "", time.Hour)
+		otherUser, raw4, _ := model.NewRefreshToken(testIDs, "other-id", "", time.Hour)
+		for _, token := range []*model.RefreshToken{&first, &rotated, &otherDevice, &otherUser} {
+			assert.NoError(t, repo.Create(context.Background(), token))
+		}
+
+		// Act
+		err := repo.RevokeFamily(context.Background(), first.FamilyID, time.Now())
+
+		// Assert
+		assert.NoError(t, err)
+		for raw, revoked := range map[string]bool{raw1: true, raw2: true, raw3: false, raw4: false} {
+			token, err := repo.FindByHash(context.Background(), model.HashRefreshToken(raw))
+			assert.NoError(t, err)
+			assert.Equal(t, revoked, token.IsRevoked())
+		}
+
+		// Act
+		err = repo.RevokeByUserID(context.Background(), "user-id", time.Now())
+
+		// Assert
+		assert.NoError(t, err)
+		for raw, revoked := range map[string]bool{raw3: true, raw4: false} {
+			token, err := repo.FindByHash(context.Background(), model.HashRefreshToken(raw))
+			assert.NoError(t, err)
+			assert.Equal(t, revoked, token.IsRevoked())
+		}
+	})
+}
+
+func TestRefreshTokenRepository_DeleteExpired(t *testing.T) {
+	t.Run("成功: 期限切れのトークンのみ削除する", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &RefreshTokenRepository{db: db}
+		expired, _, _ := model.NewRefreshToken(testIDs, "user-id", "", -time.Hour)
+		active, raw, _ := model.NewRefreshToken(testIDs, "user-id", "", time.Hour)
+		assert.NoError(t, repo.Create(context.Background(), &expired))
+		assert.NoError(t, repo.Create(context.Background(), &active))
+
+		// Act
+		deleted, err := repo.DeleteExpired(context.Background(), time.Now())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+		_, err = repo.FindByHash(context.Background(), model.HashRefreshToken(raw))
+		assert.NoError(t, err)
+	})
+}
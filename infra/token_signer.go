@@ -0,0 +1,48 @@
+package infra
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+var errInvalidSignature = errors.New("invalid token signature")
+
+// HMACTokenSigner usecase.TokenSignerの実装。"トークン.署名"の形式で、署名は用途とトークンのHMAC-SHA256
+type HMACTokenSigner struct {
+	key []byte
+}
+
+func NewHMACTokenSigner(key []byte) (*HMACTokenSigner, error) {
+	if len(key) < 32 {
+		return nil, errors.New("token signing key must be at least 32 bytes")
+	}
+	return &HMACTokenSigner{key: key}, nil
+}
+
+func (s *HMACTokenSigner) Sign(purpose string, token string) string {
+	return token + "." + base64.RawURLEncoding.EncodeToString(s.mac(purpose, token))
+}
+
+func (s *HMACTokenSigner) Verify(purpose string, signed string) (string, error) {
+	i := strings.LastIndexByte(signed, '.')
+	if i < 0 {
+		return "", errInvalidSignature
+	}
+	token := signed[:i]
+	signature, err := base64.RawURLEncoding.DecodeString(signed[i+1:])
+	if err != nil || !hmac.Equal(signature, s.mac(purpose, token)) {
+		return "", errInvalidSignature
+	}
+	return token, nil
+}
+
+func (s *HMACTokenSigner) mac(purpose string, token string) []byte {
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte(purpose))
+	h.Write([]byte{0})
+	h.Write([]byte(token))
+	return h.Sum(nil)
+}
@@ -0,0 +1,44 @@
+package infra
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHMACTokenSigner(t *testing.T) {
+	signer, err := NewHMACTokenSigner([]byte(strings.Repeat("k", 32)))
+	assert.NoError(t, err)
+	signed := signer.Sign("email-verification", "raw-token")
+
+	t.Run("成功: 署名を検証してトークンを取り出せる", func(t *testing.T) {
+		// Act
+		token, err := signer.Verify("email-verification", signed)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "raw-token", token)
+	})
+
+	t.Run("失敗: 改ざん・用途違い・別の鍵", func(t *testing.T) {
+		other, err := NewHMACTokenSigner([]byte(strings.Repeat("x", 32)))
+		assert.NoError(t, err)
+
+		_, err1 := signer.Verify("email-verification", "other-token"+signed[len("raw-token"):])
+		_, err2 := signer.Verify("password-reset", signed)
+		_, err3 := other.Verify("email-verification", signed)
+		_, err4 := signer.Verify("email-verification", "raw-token")
+
+		assert.Error(t, err1)
+		assert.Error(t, err2)
+		assert.Error(t, err3)
+		assert.Error(t, err4)
+	})
+
+	t.Run("失敗: 短い鍵", func(t *testing.T) {
+		_, err := NewHMACTokenSigner([]byte("short"))
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,74 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionManager_Do(t *testing.T) {
+	newUser := func(id string) *model.User {
+		now := time.Now()
+		return model.ReconstructUser(model.UserSnapshot{ID: id, Username: id, Email: id + "@example.com", PasswordHash: "hashedpassword", CreatedAt: now, UpdatedAt: now})
+	}
+
+	t.Run("成功: fnが成功した場合はコミットされる", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+		txManager := &TransactionManager{db: db}
+		_, err := repo.Create(context.Background(), newUser("user-1"))
+		assert.NoError(t, err)
+
+		// Act
+		err = txManager.Do(context.Background(), func(ctx context.Context) error {
+			user, err := repo.FindByIDForUpdate(ctx, "user-1")
+			if err != nil {
+				return err
+			}
+			if err := user.Rename("renamed"); err != nil {
+				return err
+			}
+			_, err = repo.Update(ctx, user)
+			return err
+		})
+
+		// Assert
+		assert.NoError(t, err)
+		result, err := repo.FindByID(context.Background(), "user-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "renamed", result.Username())
+	})
+
+	t.Run("失敗: fnがエラーを返した場合はロールバックされる", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+		txManager := &TransactionManager{db: db}
+		expectedErr := errors.New("rollback")
+
+		// Act
+		err := txManager.Do(context.Background(), func(ctx context.Context) error {
+			if _, err := repo.Create(ctx, newUser("user-1")); err != nil {
+				return err
+			}
+			return txManager.Do(ctx, func(ctx context.Context) error {
+				if _, err := repo.Create(ctx, newUser("user-2")); err != nil {
+					return err
+				}
+				return expectedErr
+			})
+		})
+
+		// Assert
+		assert.ErrorIs(t, err, expectedErr)
+		_, err = repo.FindByID(context.Background(), "user-1")
+		assert.ErrorIs(t, err, model.ErrNotFound)
+		_, err = repo.FindByID(context.Background(), "user-2")
+		assert.ErrorIs(t, err, model.ErrNotFound)
+	})
+}
@@ -3,54 +3,265 @@ package infra
 import (
 	"api-sample-with-echo-ddd/domain/model"
 	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository struct {
 	db *gorm.DB
+	// ids アウトボックスに書き込むメッセージのID生成器
+	ids model.IDGenerator
+	// factory email_canonicalの計算に使う
+	factory *model.UserFactory
 }
 
-func NewUserRepository(db *gorm.DB) repository.UserRepository {
-	return &UserRepository{db: db}
+func NewUserRepository(db *gorm.DB, ids model.IDGenerator, factory *model.UserFactory) repository.UserRepository {
+	return &UserRepository{db: db, ids: ids, factory: factory}
 }
 
-func (r *UserRepository) Create(user *model.User) (*model.User, error) {
-	if err := r.db.Create(&user).Error; err != nil {
-		return nil, err
+// Create ユーザーと、ユーザーが記録したイベントを1つのトランザクションで保存する
+func (r *UserRepository) Create(ctx context.Context, user *model.User) (*model.User, error) {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(newUserRecord(user, r.factory)).Error; err != nil {
+			return err
+		}
+		return saveEvents(tx, r.ids, user.Events())
+	})
+	if err != nil {
+		return nil, translateError(err)
 	}
+	user.ClearEvents()
 	return user, nil
 }
 
-func (r *UserRepository) FindByID(id string) (*model.User, error) {
-	user := &model.User{ID: id}
+func (r *UserRepository) FindByID(ctx context.Context, id string) (*model.User, error) {
+	record := &userRecord{}
 
-	if err := r.db.First(user).Error; err != nil {
-		return nil, err
+	if err := conn(ctx, r.db).Where("id = ?", id).First(record).Error; err != nil {
+		return nil, translateError(err)
 	}
-	return user, nil
+	return record.toDomain(), nil
+}
+
+func (r *UserRepository) FindByIDForUpdate(ctx context.Context, id string) (*model.User, error) {
+	record := &userRecord{}
+
+	if err := conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(record).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return record.toDomain(), nil
+}
+
+func (r *UserRepository) FindDeletedByIDForUpdate(ctx context.Context, id string) (*model.User, error) {
+	record := &userRecord{}
+
+	if err := conn(ctx, r.db).Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND deleted_at IS NOT NULL", id).First(record).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return record.toDomain(), nil
 }
 
-func (r *UserRepository) FindAll() ([]*model.User, error) {
-	users := []*model.User{}
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	record := &userRecord{}
 
-	if err := r.db.Find(&users).Error; err != nil {
-		return nil, err
+	if err := conn(ctx, r.db).Where("email_canonical = ?", r.factory.CanonicalizeEmail(email)).First(record).Error; err != nil {
+		return nil, translateError(err)
 	}
-	return users, nil
+	return record.toDomain(), nil
 }
 
-func (r *UserRepository) Update(user *model.User) (*model.User, error) {
-	if err := r.db.Save(user).Error; err != nil {
-		return nil, err
+func (r *UserRepository) FindByUsername(ctx context.Context, username string) (*model.User, error) {
+	record := &userRecord{}
+
+	if err := conn(ctx, r.db).Where("LOWER(username) = LOWER(?)", username).First(record).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return record.toDomain(), nil
+}
+
+func (r *UserRepository) FindAll(ctx context.Context, query repository.UserQuery) (*repository.UserPage, error) {
+	limit := query.Limit
+	if limit <= 0 || limit > repository.MaxUserLimit {
+		limit = repository.DefaultUserLimit
+	}
+	field := query.SortField
+	if field == "" {
+		field = repository.UserSortByCreatedAt
+	}
+	column := string(field)
+	order, op := "ASC", ">"
+	if query.Descending {
+		order, op = "DESC", "<"
+	}
+
+	db := conn(ctx, r.db).Model(&userRecord{})
+	if query.IncludeDeleted {
+		db = db.Unscoped()
+	}
+	if query.EmailDomain != "" {
+		db = db.Where("email LIKE ? ESCAPE '!'", "%@"+escapeLike(query.EmailDomain))
+	}
+	if query.UsernamePrefix != "" {
+		db = db.Where("username LIKE ? ESCAPE '!'", escapeLike(query.UsernamePrefix)+"%")
+	}
+	if query.Status != "" {
+		db = db.Where("status = ?", string(query.Status))
+	}
+	if !query.CreatedAfter.IsZero() {
+		db = db.Where("created_at > ?", query.CreatedAfter)
+	}
+	if !query.CreatedBefore.IsZero() {
+		db = db.Where("created_at < ?", query.CreatedBefore)
+	}
+	if query.Cursor != nil {
+		value, err := cursorValue(*query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", column, op, column, op), value, value, query.Cursor.ID)
 	}
 
+	records := []*userRecord{}
+	// 次ページの有無を判定するため1件多く取得する
+	if err := db.Order(column + " " + order).Order("id " + order).Limit(limit + 1).Find(&records).Error; err != nil {
+		return nil, translateError(err)
+	}
+	users := make([]*model.User, len(records))
+	for i, record := range records {
+		users[i] = record.toDomain()
+	}
+
+	page := &repository.UserPage{Users: users}
+	if len(users) > limit {
+		page.Users = users[:limit]
+		page.NextCursor = repository.NewUserCursor(field, users[limit-1]).Encode()
+	}
+	return page, nil
+}
+
+func cursorValue(cursor repository.UserCursor) (interface{}, error) {
+	if cursor.SortField != repository.UserSortByCreatedAt {
+		return cursor.Value, nil
+	}
+	return time.Parse(time.RFC3339Nano, cursor.Value)
+}
+
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// Update 読み込んだ時点のバージョンのままであれば更新し、バージョンを1つ進める。ユーザーが記録したイベントも同じトランザクションで保存する。
+// 論理削除の取り消しも反映するため、論理削除された行も対象にする
+func (r *UserRepository) Update(ctx context.Context, user *model.User) (*model.User, error) {
+	record := newUserRecord(user, r.factory)
+	record.Version++
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&userRecord{}).
+			Where("id = ? AND version = ?", user.ID(), user.Version()).
+			Select("*").
+			Updates(record)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVersionConflict
+		}
+		return saveEvents(tx, r.ids, user.Events())
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	*user = *record.toDomain()
 	return user, nil
 }
 
-func (r *UserRepository) Delete(user *model.User) error {
-	if err := r.db.Delete(user).Error; err != nil {
-		return err
+// ReplacePasswordHash パスワードのハッシュがcurrentHashのままであればnewHashに置き換える。
+// ハッシュ形式の移行であり利用者から見た変更ではないため、バージョンと更新日時は変えない
+func (r *UserRepository) ReplacePasswordHash(ctx context.Context, id string, currentHash string, newHash string) (bool, error) {
+	result := conn(ctx, r.db).Model(&userRecord{}).
+		Where("id = ? AND password = ?", id, currentHash).
+		UpdateColumn("password", newHash)
+	if result.Error != nil {
+		return false, translateError(result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Delete 読み込んだ時点のバージョンのままであれば論理削除し、バージョンを1つ進める。ユーザーが記録したイベントも同じトランザクションで保存する。
+// 削除日時はmodel.User.Deleteで設定した値を使い、未設定の場合は現在時刻にする
+func (r *UserRepository) Delete(ctx context.Context, user *model.User) error {
+	deletedAt := time.Now()
+	if user.IsDeleted() {
+		deletedAt = *user.DeletedAt()
 	}
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&userRecord{}).
+			Where("id = ? AND version = ?", user.ID(), user.Version()).
+			Updates(map[string]interface{}{
+				"deleted_at": deletedAt,
+				"version":    gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVersionConflict
+		}
+		return saveEvents(tx, r.ids, user.Events())
+	})
+	if err != nil {
+		return translateError(err)
+	}
+	user.ClearEvents()
 	return nil
 }
+
+// userTokenTables ユーザーを完全に削除する際に合わせて削除するトークンのテーブル
+var userTokenTables = []string{"refresh_tokens", "one_time_tokens"}
+
+// Purge 論理削除から保持期間を過ぎたユーザーとそのトークンを削除し、ユーザーごとに監査ログを記録する。
+// 削除と記録は1つのトランザクションで行う
+func (r *UserRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var purged int64
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var expired []userRecord
+		if err := tx.Unscoped().Select("id", "deleted_at").Where("deleted_at < ?", deletedBefore).Find(&expired).Error; err != nil {
+			return err
+		}
+		if len(expired) == 0 {
+			return nil
+		}
+		ids := make([]string, len(expired))
+		for i, record := range expired {
+			ids[i] = record.ID
+			log := model.NewPurgeAuditLog(r.ids, record.ID, record.DeletedAt.Time)
+			auditRecord, err := newAuditLogRecord(&log)
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(auditRecord).Error; err != nil {
+				return err
+			}
+		}
+		for _, table := range userTokenTables {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE user_id IN (?)", ids).Error; err != nil {
+				return err
+			}
+		}
+		result := tx.Unscoped().Where("id IN (?)", ids).Delete(&userRecord{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return purged, nil
+}
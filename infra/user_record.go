@@ -0,0 +1,80 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// userRecord usersテーブルの1行。GORMの規約はこの型に閉じ込め、ドメインのUserには持ち込まない。
+// スキーマの正はinfra/migrationsのSQLで、タグはそれに合わせて記述している。
+// DeletedAtにより、Unscopedを指定しない限り論理削除された行は検索・更新の対象にならない
+type userRecord struct {
+	ID              string         `gorm:"column:id;type:char(36);primaryKey"`
+	Username        string         `gorm:"column:username;size:20;not null;uniqueIndex:idx_users_username_lower,expression:LOWER(username)"`
+	Email           string         `gorm:"column:email;size:254;not null"`
+	EmailCanonical  string         `gorm:"column:email_canonical;size:254;not null;uniqueIndex:idx_users_email_canonical"`
+	EmailVerifiedAt *time.Time     `gorm:"column:email_verified_at"`
+	Password        string         `gorm:"column:password;size:255;not null"`
+	Role            string         `gorm:"column:role;size:20;not null"`
+	Status          string         `gorm:"column:status;size:20;not null;index:idx_users_status"`
+	StatusReason    string         `gorm:"column:status_reason;size:255;not null"`
+	StatusChangedAt *time.Time     `gorm:"column:status_changed_at"`
+	Version         int            `gorm:"column:version;not null"`
+	CreatedAt       time.Time      `gorm:"column:created_at;not null;index:idx_users_created_at"`
+	UpdatedAt       time.Time      `gorm:"column:updated_at;not null"`
+	DeletedAt       gorm.DeletedAt `gorm:"column:deleted_at;index:idx_users_deleted_at"`
+}
+
+func (userRecord) TableName() string {
+	return "users"
+}
+
+func newUserRecord(user *model.User, factory *model.UserFactory) *userRecord {
+	return &userRecord{
+		ID:              user.ID(),
+		Username:        user.Username(),
+		Email:           user.Email(),
+		EmailCanonical:  factory.CanonicalizeEmail(user.Email()),
+		EmailVerifiedAt: user.EmailVerifiedAt(),
+		Password:        user.PasswordHash(),
+		Role:            string(user.Role()),
+		Status:          string(user.Status()),
+		StatusReason:    user.StatusReason(),
+		StatusChangedAt: user.StatusChangedAt(),
+		Version:         user.Version(),
+		CreatedAt:       user.CreatedAt(),
+		UpdatedAt:       user.UpdatedAt(),
+		DeletedAt:       deletedAtOf(user.DeletedAt()),
+	}
+}
+
+func deletedAtOf(t *time.Time) gorm.DeletedAt {
+	if t == nil {
+		return gorm.DeletedAt{}
+	}
+	return gorm.DeletedAt{Time: *t, Valid: true}
+}
+
+func (r *userRecord) toDomain() *model.User {
+	var deletedAt *time.Time
+	if r.DeletedAt.Valid {
+		deletedAt = &r.DeletedAt.Time
+	}
+	return model.ReconstructUser(model.UserSnapshot{
+		ID:              r.ID,
+		Username:        r.Username,
+		Email:           r.Email,
+		EmailVerifiedAt: r.EmailVerifiedAt,
+		PasswordHash:    r.Password,
+		Role:            model.Role(r.Role),
+		Status:          model.UserStatus(r.Status),
+		StatusReason:    r.StatusReason,
+		StatusChangedAt: r.StatusChangedAt,
+		Version:         r.Version,
+		CreatedAt:       r.CreatedAt,
+		UpdatedAt:       r.UpdatedAt,
+		DeletedAt:       deletedAt,
+	})
+}
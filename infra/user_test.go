@@ -2,6 +2,8 @@ package infra
 
 import (
 	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
 	"testing"
 	"time"
 
@@ -16,42 +18,68 @@ func setupTestDB() *gorm.DB {
 		panic("failed to connect database")
 	}
 	
-	err = db.AutoMigrate(&model.User{})
+	// :memory:は接続ごとに別のデータベースになるため、接続を1つに限定する
+	sqlDB, err := db.DB()
 	if err != nil {
+		panic("failed to get database")
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	migrator, err := NewMigrator(db, testUserFactory)
+	if err != nil {
+		panic("failed to load migrations")
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
 		panic("failed to migrate database")
 	}
 	
 	return db
 }
 
+var testIDs = model.NewUUIDv7Generator()
+
+var testUserFactory = func() *model.UserFactory {
+	factory, err := model.NewUserFactory(testIDs, model.EmailPolicy{}, model.DefaultPasswordPolicy())
+	if err != nil {
+		panic(err)
+	}
+	return factory
+}()
+
+func newTestUserFactory(t *testing.T) *model.UserFactory {
+	factory, err := model.NewUserFactory(testIDs, model.EmailPolicy{}, model.DefaultPasswordPolicy())
+	assert.NoError(t, err)
+	return factory
+}
+
 func TestUserRepository_Create(t *testing.T) {
 	t.Run("成功: ユーザーを作成できる", func(t *testing.T) {
 		// Arrange
 		db := setupTestDB()
-		repo := &UserRepository{db: db}
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
 		
 		now := time.Now()
-		user := &model.User{
-			ID:        "test-id",
-			Username:  "testuser",
-			Email:     "test@example.com",
-			Password:  "hashedpassword",
-			CreatedAt: now,
-			UpdatedAt: now,
-		}
+		user := model.ReconstructUser(model.UserSnapshot{
+			ID:           "test-id",
+			Username:     "testuser",
+			Email:        "test@example.com",
+			PasswordHash: "hashedpassword",
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
 
 		// Act
-		result, err := repo.Create(user)
+		result, err := repo.Create(context.Background(), user)
 
 		// Assert
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
-		assert.Equal(t, "test-id", result.ID)
-		assert.Equal(t, "testuser", result.Username)
-		assert.Equal(t, "test@example.com", result.Email)
+		assert.Equal(t, "test-id", result.ID())
+		assert.Equal(t, "testuser", result.Username())
+		assert.Equal(t, "test@example.com", result.Email())
 
 		// データベースに保存されていることを確認
-		var savedUser model.User
+		var savedUser userRecord
 		err = db.First(&savedUser, "id = ?", "test-id").Error
 		assert.NoError(t, err)
 		assert.Equal(t, "testuser", savedUser.Username)
@@ -60,33 +88,174 @@ func TestUserRepository_Create(t *testing.T) {
 	t.Run("失敗: 重複したIDでの作成", func(t *testing.T) {
 		// Arrange
 		db := setupTestDB()
-		repo := &UserRepository{db: db}
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
 		
 		now := time.Now()
-		user1 := &model.User{
-			ID:        "duplicate-id",
-			Username:  "user1",
-			Email:     "user1@example.com",
-			Password:  "password1",
-			CreatedAt: now,
-			UpdatedAt: now,
-		}
-		user2 := &model.User{
-			ID:        "duplicate-id",
-			Username:  "user2",
-			Email:     "user2@example.com",
-			Password:  "password2",
-			CreatedAt: now,
-			UpdatedAt: now,
-		}
+		user1 := model.ReconstructUser(model.UserSnapshot{
+			ID:           "duplicate-id",
+			Username:     "user1",
+			Email:        "user1@example.com",
+			PasswordHash: "password1",
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+		user2 := model.ReconstructUser(model.UserSnapshot{
+			ID:           "duplicate-id",
+			Username:     "user2",
+			Email:        "user2@example.com",
+			PasswordHash: "password2",
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+
+		// Act
+		_, err1 := repo.Create(context.Background(), user1)
+		_, err2 := repo.Create(context.Background(), user2)
+
+		// Assert
+		assert.NoError(t, err1)
+		assert.ErrorIs(t, err2, model.ErrConflict)
+	})
+}
+
+func TestUserRepository_Unique(t *testing.T) {
+	newUser := func(id string, username string, email string) *model.User {
+		now := time.Now()
+		return model.ReconstructUser(model.UserSnapshot{
+			ID:           id,
+			Username:     username,
+			Email:        email,
+			PasswordHash: "hashedpassword",
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+	}
+
+	type TestCase struct {
+		name          string
+		duplicate     *model.User
+		expectedField model.FieldError
+	}
+	testCases := []TestCase{
+		{"失敗: メールアドレスは大文字・小文字を区別せず重複とする", newUser("id-2", "other", "Test@Example.com"), model.FieldEmailTaken},
+		{"失敗: ユーザー名は大文字・小文字を区別せず重複とする", newUser("id-2", "TestUser", "other@example.com"), model.FieldUsernameTaken},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			db := setupTestDB()
+			repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+			_, err := repo.Create(context.Background(), newUser("id-1", "testuser", "test@example.com"))
+			assert.NoError(t, err)
+
+			// Act
+			_, err = repo.Create(context.Background(), tc.duplicate)
+
+			// Assert
+			assert.ErrorIs(t, err, model.ErrConflict)
+			var domainErr *model.Error
+			assert.ErrorAs(t, err, &domainErr)
+			assert.Equal(t, []model.FieldError{tc.expectedField}, domainErr.Fields)
+		})
+	}
+
+	t.Run("失敗: サブアドレスを無視する設定ではサブアドレス違いも重複とする", func(t *testing.T) {
+		// Arrange
+		factory, err := model.NewUserFactory(testIDs, model.EmailPolicy{CanonicalizePlusAddress: true}, model.DefaultPasswordPolicy())
+		assert.NoError(t, err)
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: factory}
+		_, err = repo.Create(context.Background(), newUser("id-1", "testuser", "test@example.com"))
+		assert.NoError(t, err)
+
+		// Act
+		_, err = repo.Create(context.Background(), newUser("id-2", "other", "Test+news@example.com"))
+		found, findErr := repo.FindByEmail(context.Background(), "test+other@example.com")
+
+		// Assert
+		assert.ErrorIs(t, err, model.ErrConflict)
+		assert.NoError(t, findErr)
+		assert.Equal(t, "id-1", found.ID())
+	})
+
+	t.Run("成功: サブアドレス違いは別のメールアドレスとする", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+		_, err := repo.Create(context.Background(), newUser("id-1", "testuser", "test@example.com"))
+		assert.NoError(t, err)
+
+		// Act
+		_, err = repo.Create(context.Background(), newUser("id-2", "other", "Test+news@example.com"))
+		found, findErr := repo.FindByEmail(context.Background(), "test+NEWS@example.com")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, findErr)
+		assert.Equal(t, "id-2", found.ID())
+	})
+
+	t.Run("成功: 大文字・小文字を区別せずに検索できる", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+		_, err := repo.Create(context.Background(), newUser("id-1", "testuser", "test@example.com"))
+		assert.NoError(t, err)
 
 		// Act
-		_, err1 := repo.Create(user1)
-		_, err2 := repo.Create(user2)
+		byEmail, err1 := repo.FindByEmail(context.Background(), "TEST@example.COM")
+		byUsername, err2 := repo.FindByUsername(context.Background(), "TestUser")
 
 		// Assert
 		assert.NoError(t, err1)
-		assert.Error(t, err2)
+		assert.NoError(t, err2)
+		assert.Equal(t, "id-1", byEmail.ID())
+		assert.Equal(t, "id-1", byUsername.ID())
+	})
+}
+
+func TestUserRepository_ReplacePasswordHash(t *testing.T) {
+	newUser := func() *model.User {
+		now := time.Now()
+		return model.ReconstructUser(model.UserSnapshot{ID: "id-1", Username: "testuser", Email: "test@example.com", PasswordHash: "old-hash", Version: 1, CreatedAt: now, UpdatedAt: now})
+	}
+
+	t.Run("成功: ハッシュが一致すれば置き換え、バージョンは変えない", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+		_, err := repo.Create(context.Background(), newUser())
+		assert.NoError(t, err)
+
+		// Act
+		replaced, err := repo.ReplacePasswordHash(context.Background(), "id-1", "old-hash", "new-hash")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, replaced)
+		result, err := repo.FindByID(context.Background(), "id-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "new-hash", result.PasswordHash())
+		assert.Equal(t, 1, result.Version())
+	})
+
+	t.Run("成功: 他の操作でハッシュが変わっていれば置き換えない", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+		_, err := repo.Create(context.Background(), newUser())
+		assert.NoError(t, err)
+
+		// Act
+		replaced, err := repo.ReplacePasswordHash(context.Background(), "id-1", "stale-hash", "new-hash")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.False(t, replaced)
+		result, err := repo.FindByID(context.Background(), "id-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "old-hash", result.PasswordHash())
 	})
 }
 
@@ -94,42 +263,71 @@ func TestUserRepository_FindByID(t *testing.T) {
 	t.Run("成功: ユーザーを取得できる", func(t *testing.T) {
 		// Arrange
 		db := setupTestDB()
-		repo := &UserRepository{db: db}
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
 		
 		now := time.Now()
-		user := &model.User{
-			ID:        "test-id",
-			Username:  "testuser",
-			Email:     "test@example.com",
-			Password:  "hashedpassword",
-			CreatedAt: now,
-			UpdatedAt: now,
-		}
-		db.Create(user)
+		user := model.ReconstructUser(model.UserSnapshot{
+			ID:           "test-id",
+			Username:     "testuser",
+			Email:        "test@example.com",
+			PasswordHash: "hashedpassword",
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+		db.Create(newUserRecord(user, testUserFactory))
 
 		// Act
-		result, err := repo.FindByID("test-id")
+		result, err := repo.FindByID(context.Background(), "test-id")
 
 		// Assert
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
-		assert.Equal(t, "test-id", result.ID)
-		assert.Equal(t, "testuser", result.Username)
-		assert.Equal(t, "test@example.com", result.Email)
+		assert.Equal(t, "test-id", result.ID())
+		assert.Equal(t, "testuser", result.Username())
+		assert.Equal(t, "test@example.com", result.Email())
 	})
 
 	t.Run("失敗: 存在しないIDでの取得", func(t *testing.T) {
 		// Arrange
 		db := setupTestDB()
-		repo := &UserRepository{db: db}
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
 
 		// Act
-		result, err := repo.FindByID("nonexistent-id")
+		result, err := repo.FindByID(context.Background(), "nonexistent-id")
 
 		// Assert
 		assert.Error(t, err)
 		assert.Nil(t, result)
-		assert.Contains(t, err.Error(), "record not found")
+		assert.ErrorIs(t, err, model.ErrNotFound)
+	})
+}
+
+func TestUserRepository_FindByEmail(t *testing.T) {
+	t.Run("成功: メールアドレスでユーザーを取得できる", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+		createTestUsers(t, db)
+
+		// Act
+		result, err := repo.FindByEmail(context.Background(), "bob@example.com")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "id-3", result.ID())
+	})
+
+	t.Run("失敗: 存在しないメールアドレス", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+
+		// Act
+		result, err := repo.FindByEmail(context.Background(), "unknown@example.com")
+
+		// Assert
+		assert.ErrorIs(t, err, model.ErrNotFound)
+		assert.Nil(t, result)
 	})
 }
 
@@ -137,42 +335,43 @@ func TestUserRepository_FindAll(t *testing.T) {
 	t.Run("成功: 全ユーザーを取得できる", func(t *testing.T) {
 		// Arrange
 		db := setupTestDB()
-		repo := &UserRepository{db: db}
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
 		
 		now := time.Now()
 		users := []*model.User{
-			{
-				ID:        "user1",
-				Username:  "testuser1",
-				Email:     "user1@example.com",
-				Password:  "password1",
-				CreatedAt: now,
-				UpdatedAt: now,
-			},
-			{
-				ID:        "user2",
-				Username:  "testuser2",
-				Email:     "user2@example.com",
-				Password:  "password2",
-				CreatedAt: now,
-				UpdatedAt: now,
-			},
+			model.ReconstructUser(model.UserSnapshot{
+				ID:           "user1",
+				Username:     "testuser1",
+				Email:        "user1@example.com",
+				PasswordHash: "password1",
+				CreatedAt:    now,
+				UpdatedAt:    now,
+			}),
+			model.ReconstructUser(model.UserSnapshot{
+				ID:           "user2",
+				Username:     "testuser2",
+				Email:        "user2@example.com",
+				PasswordHash: "password2",
+				CreatedAt:    now,
+				UpdatedAt:    now,
+			}),
 		}
 
 		for _, user := range users {
-			db.Create(user)
+			db.Create(newUserRecord(user, testUserFactory))
 		}
 
 		// Act
-		result, err := repo.FindAll()
+		result, err := repo.FindAll(context.Background(), repository.UserQuery{})
 
 		// Assert
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
-		assert.Len(t, result, 2)
+		assert.Len(t, result.Users, 2)
+		assert.Empty(t, result.NextCursor)
 		
 		// ユーザー名でソートして比較
-		usernames := []string{result[0].Username, result[1].Username}
+		usernames := []string{result.Users[0].Username(), result.Users[1].Username()}
 		assert.Contains(t, usernames, "testuser1")
 		assert.Contains(t, usernames, "testuser2")
 	})
@@ -180,15 +379,117 @@ func TestUserRepository_FindAll(t *testing.T) {
 	t.Run("成功: ユーザーが存在しない場合は空のスライスを返す", func(t *testing.T) {
 		// Arrange
 		db := setupTestDB()
-		repo := &UserRepository{db: db}
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
 
 		// Act
-		result, err := repo.FindAll()
+		result, err := repo.FindAll(context.Background(), repository.UserQuery{})
 
 		// Assert
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
-		assert.Len(t, result, 0)
+		assert.Len(t, result.Users, 0)
+	})
+}
+
+func createTestUsers(t *testing.T, db *gorm.DB) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	users := []*model.User{
+		model.ReconstructUser(model.UserSnapshot{ID: "id-1", Username: "carol", Email: "carol@example.com", CreatedAt: base.Add(1 * time.Hour)}),
+		model.ReconstructUser(model.UserSnapshot{ID: "id-2", Username: "alice", Email: "alice@test.com", CreatedAt: base.Add(2 * time.Hour)}),
+		model.ReconstructUser(model.UserSnapshot{ID: "id-3", Username: "bob", Email: "bob@example.com", Status: model.UserStatusSuspended, CreatedAt: base.Add(3 * time.Hour)}),
+		model.ReconstructUser(model.UserSnapshot{ID: "id-4", Username: "alex", Email: "alex@example.com", CreatedAt: base.Add(4 * time.Hour)}),
+		model.ReconstructUser(model.UserSnapshot{ID: "id-5", Username: "dave", Email: "dave@test.com", CreatedAt: base.Add(5 * time.Hour)}),
+	}
+	for _, user := range users {
+		record := newUserRecord(user, testUserFactory)
+		record.UpdatedAt = record.CreatedAt
+		assert.NoError(t, db.Create(record).Error)
+	}
+}
+
+func usernamesOf(users []*model.User) []string {
+	usernames := make([]string, len(users))
+	for i, user := range users {
+		usernames[i] = user.Username()
+	}
+	return usernames
+}
+
+func TestUserRepository_FindAll_Query(t *testing.T) {
+	t.Run("成功: カーソルで全ページを順に取得できる", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+		createTestUsers(t, db)
+
+		// Act
+		var usernames []string
+		query := repository.UserQuery{Limit: 2}
+		for pages := 0; ; pages++ {
+			page, err := repo.FindAll(context.Background(), query)
+			assert.NoError(t, err)
+			usernames = append(usernames, usernamesOf(page.Users)...)
+			if page.NextCursor == "" {
+				break
+			}
+			cursor, err := repository.DecodeUserCursor(page.NextCursor)
+			assert.NoError(t, err)
+			query.Cursor = &cursor
+			assert.Less(t, pages, 3)
+		}
+
+		// Assert
+		assert.Equal(t, []string{"carol", "alice", "bob", "alex", "dave"}, usernames)
+	})
+
+	t.Run("成功: ユーザー名の降順で取得できる", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+		createTestUsers(t, db)
+
+		// Act
+		first, err := repo.FindAll(context.Background(), repository.UserQuery{Limit: 3, SortField: repository.UserSortByUsername, Descending: true})
+		assert.NoError(t, err)
+		cursor, err := repository.DecodeUserCursor(first.NextCursor)
+		assert.NoError(t, err)
+		second, err := repo.FindAll(context.Background(), repository.UserQuery{Limit: 3, SortField: repository.UserSortByUsername, Descending: true, Cursor: &cursor})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"dave", "carol", "bob"}, usernamesOf(first.Users))
+		assert.Equal(t, []string{"alice", "alex"}, usernamesOf(second.Users))
+		assert.Empty(t, second.NextCursor)
+	})
+
+	t.Run("成功: 条件で絞り込める", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+		createTestUsers(t, db)
+		base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		// Act
+		byDomain, err1 := repo.FindAll(context.Background(), repository.UserQuery{EmailDomain: "example.com"})
+		byPrefix, err2 := repo.FindAll(context.Background(), repository.UserQuery{UsernamePrefix: "al"})
+		byCreatedAt, err3 := repo.FindAll(context.Background(), repository.UserQuery{
+			CreatedAfter:  base.Add(1 * time.Hour),
+			CreatedBefore: base.Add(4 * time.Hour),
+		})
+		escaped, err4 := repo.FindAll(context.Background(), repository.UserQuery{UsernamePrefix: "a%"})
+		byStatus, err5 := repo.FindAll(context.Background(), repository.UserQuery{Status: model.UserStatusSuspended})
+
+		// Assert
+		assert.NoError(t, err1)
+		assert.NoError(t, err2)
+		assert.NoError(t, err3)
+		assert.NoError(t, err4)
+		assert.NoError(t, err5)
+		assert.Equal(t, []string{"carol", "bob", "alex"}, usernamesOf(byDomain.Users))
+		assert.Equal(t, []string{"alice", "alex"}, usernamesOf(byPrefix.Users))
+		assert.Equal(t, []string{"alice", "bob"}, usernamesOf(byCreatedAt.Users))
+		assert.Empty(t, escaped.Users)
+		assert.Equal(t, []string{"bob"}, usernamesOf(byStatus.Users))
 	})
 }
 
@@ -196,68 +497,103 @@ func TestUserRepository_Update(t *testing.T) {
 	t.Run("成功: ユーザーを更新できる", func(t *testing.T) {
 		// Arrange
 		db := setupTestDB()
-		repo := &UserRepository{db: db}
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
 		
 		now := time.Now()
-		user := &model.User{
-			ID:        "test-id",
-			Username:  "testuser",
-			Email:     "test@example.com",
-			Password:  "hashedpassword",
-			CreatedAt: now,
-			UpdatedAt: now,
-		}
-		db.Create(user)
+		user := model.ReconstructUser(model.UserSnapshot{
+			ID:           "test-id",
+			Username:     "testuser",
+			Email:        "test@example.com",
+			PasswordHash: "hashedpassword",
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+		db.Create(newUserRecord(user, testUserFactory))
 
 		// ユーザー情報を更新
-		user.Username = "updateduser"
-		user.Email = "updated@example.com"
-		user.UpdatedAt = time.Now()
+		assert.NoError(t, user.Rename("updateduser"))
+		assert.NoError(t, user.ChangeEmail(newTestUserFactory(t), "updated@example.com"))
 
 		// Act
-		result, err := repo.Update(user)
+		result, err := repo.Update(context.Background(), user)
 
 		// Assert
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
-		assert.Equal(t, "test-id", result.ID)
-		assert.Equal(t, "updateduser", result.Username)
-		assert.Equal(t, "updated@example.com", result.Email)
+		assert.Equal(t, "test-id", result.ID())
+		assert.Equal(t, "updateduser", result.Username())
+		assert.Equal(t, "updated@example.com", result.Email())
 
 		// データベースで更新されていることを確認
-		var updatedUser model.User
+		var updatedUser userRecord
 		err = db.First(&updatedUser, "id = ?", "test-id").Error
 		assert.NoError(t, err)
 		assert.Equal(t, "updateduser", updatedUser.Username)
 		assert.Equal(t, "updated@example.com", updatedUser.Email)
 	})
 
-	t.Run("成功: 存在しないユーザーの更新（新規作成）", func(t *testing.T) {
+	t.Run("失敗: バージョンが古い場合は更新しない", func(t *testing.T) {
 		// Arrange
 		db := setupTestDB()
-		repo := &UserRepository{db: db}
-		
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+
 		now := time.Now()
-		user := &model.User{
-			ID:        "nonexistent-id",
-			Username:  "testuser",
-			Email:     "test@example.com",
-			Password:  "hashedpassword",
-			CreatedAt: now,
-			UpdatedAt: now,
-		}
+		user := model.ReconstructUser(model.UserSnapshot{
+			ID:           "test-id",
+			Username:     "testuser",
+			Email:        "test@example.com",
+			PasswordHash: "hashedpassword",
+			Version:      1,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+		db.Create(newUserRecord(user, testUserFactory))
+		stale := *user
+
+		_, err := repo.Update(context.Background(), user)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, user.Version())
 
 		// Act
-		result, err := repo.Update(user)
+		assert.NoError(t, stale.Rename("staleuser"))
+		result, err := repo.Update(context.Background(), &stale)
 
-		// Assert - GORMのSaveは存在しないレコードに対して新規作成を行うため、エラーは発生しない
+		// Assert
+		assert.ErrorIs(t, err, model.ErrConflict)
+		assert.Nil(t, result)
+
+		var savedUser userRecord
+		err = db.First(&savedUser, "id = ?", "test-id").Error
 		assert.NoError(t, err)
-		assert.NotNil(t, result)
+		assert.Equal(t, "testuser", savedUser.Username)
+		assert.Equal(t, 2, savedUser.Version)
+	})
+
+	t.Run("失敗: 存在しないユーザーは更新しない", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+
+		now := time.Now()
+		user := model.ReconstructUser(model.UserSnapshot{
+			ID:           "nonexistent-id",
+			Username:     "testuser",
+			Email:        "test@example.com",
+			PasswordHash: "hashedpassword",
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+
+		// Act
+		result, err := repo.Update(context.Background(), user)
+
+		// Assert
+		assert.ErrorIs(t, err, model.ErrConflict)
+		assert.Nil(t, result)
 
-		// 新規作成されたことを確認
-		var savedUser model.User
+		var savedUser userRecord
 		err = db.First(&savedUser, "id = ?", "nonexistent-id").Error
-		assert.NoError(t, err)
+		assert.Error(t, err)
 	})
 }
 
@@ -265,52 +601,52 @@ func TestUserRepository_Delete(t *testing.T) {
 	t.Run("成功: ユーザーを削除できる", func(t *testing.T) {
 		// Arrange
 		db := setupTestDB()
-		repo := &UserRepository{db: db}
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
 		
 		now := time.Now()
-		user := &model.User{
-			ID:        "test-id",
-			Username:  "testuser",
-			Email:     "test@example.com",
-			Password:  "hashedpassword",
-			CreatedAt: now,
-			UpdatedAt: now,
-		}
-		db.Create(user)
+		user := model.ReconstructUser(model.UserSnapshot{
+			ID:           "test-id",
+			Username:     "testuser",
+			Email:        "test@example.com",
+			PasswordHash: "hashedpassword",
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+		db.Create(newUserRecord(user, testUserFactory))
 
 		// Act
-		err := repo.Delete(user)
+		err := repo.Delete(context.Background(), user)
 
 		// Assert
 		assert.NoError(t, err)
 
 		// データベースから削除されていることを確認
-		var deletedUser model.User
+		var deletedUser userRecord
 		err = db.First(&deletedUser, "id = ?", "test-id").Error
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "record not found")
 	})
 
-	t.Run("成功: 存在しないユーザーの削除（エラーなし）", func(t *testing.T) {
+	t.Run("失敗: 存在しないユーザーは削除できない", func(t *testing.T) {
 		// Arrange
 		db := setupTestDB()
-		repo := &UserRepository{db: db}
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
 		
 		now := time.Now()
-		user := &model.User{
-			ID:        "nonexistent-id",
-			Username:  "testuser",
-			Email:     "test@example.com",
-			Password:  "hashedpassword",
-			CreatedAt: now,
-			UpdatedAt: now,
-		}
+		user := model.ReconstructUser(model.UserSnapshot{
+			ID:           "nonexistent-id",
+			Username:     "testuser",
+			Email:        "test@example.com",
+			PasswordHash: "hashedpassword",
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
 
 		// Act
-		err := repo.Delete(user)
+		err := repo.Delete(context.Background(), user)
 
-		// Assert - GORMのDeleteは存在しないレコードに対してもエラーを返さない
-		assert.NoError(t, err)
+		// Assert
+		assert.ErrorIs(t, err, model.ErrConflict)
 	})
 }
 
@@ -318,32 +654,32 @@ func TestUserRepository_ConcurrentAccess(t *testing.T) {
 	t.Run("成功: 並行アクセスでの整合性", func(t *testing.T) {
 		// Arrange
 		db := setupTestDB()
-		repo := &UserRepository{db: db}
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
 		
 		now := time.Now()
-		user := &model.User{
-			ID:        "concurrent-test-id",
-			Username:  "testuser",
-			Email:     "test@example.com",
-			Password:  "hashedpassword",
-			CreatedAt: now,
-			UpdatedAt: now,
-		}
-		_, err := repo.Create(user)
+		user := model.ReconstructUser(model.UserSnapshot{
+			ID:           "concurrent-test-id",
+			Username:     "testuser",
+			Email:        "test@example.com",
+			PasswordHash: "hashedpassword",
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+		_, err := repo.Create(context.Background(), user)
 		assert.NoError(t, err)
 
 		// Act - 複数のgoroutineで同時にアクセス
 		done := make(chan bool, 2)
 		
 		go func() {
-			_, err := repo.FindByID("concurrent-test-id")
+			_, err := repo.FindByID(context.Background(), "concurrent-test-id")
 			assert.NoError(t, err)
 			done <- true
 		}()
 
 		go func() {
-			user.Username = "updated-concurrent"
-			_, err := repo.Update(user)
+			assert.NoError(t, user.Rename("updated-concurrent"))
+			_, err := repo.Update(context.Background(), user)
 			assert.NoError(t, err)
 			done <- true
 		}()
@@ -353,9 +689,133 @@ func TestUserRepository_ConcurrentAccess(t *testing.T) {
 		<-done
 
 		// Assert
-		var finalUser model.User
+		var finalUser userRecord
 		err = db.First(&finalUser, "id = ?", "concurrent-test-id").Error
 		assert.NoError(t, err)
 		assert.Equal(t, "updated-concurrent", finalUser.Username)
 	})
-}
\ No newline at end of file
+}
+func TestUserRepository_SoftDelete(t *testing.T) {
+	newUser := func(id string, username string) *model.User {
+		now := time.Now()
+		return model.ReconstructUser(model.UserSnapshot{
+			ID:           id,
+			Username:     username,
+			Email:        username + "@example.com",
+			PasswordHash: "hashedpassword",
+			Version:      1,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+	}
+
+	t.Run("成功: 論理削除したユーザーは既定では検索されず、復元できる", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+		user := newUser("test-id", "testuser")
+		_, err := repo.Create(context.Background(), user)
+		assert.NoError(t, err)
+
+		// Act
+		deleteErr := repo.Delete(context.Background(), user)
+		_, findErr := repo.FindByID(context.Background(), "test-id")
+		_, findByEmailErr := repo.FindByEmail(context.Background(), "testuser@example.com")
+		page, _ := repo.FindAll(context.Background(), repository.UserQuery{})
+		withDeleted, _ := repo.FindAll(context.Background(), repository.UserQuery{IncludeDeleted: true})
+		deleted, findDeletedErr := repo.FindDeletedByIDForUpdate(context.Background(), "test-id")
+
+		// Assert
+		assert.NoError(t, deleteErr)
+		assert.ErrorIs(t, findErr, model.ErrNotFound)
+		assert.ErrorIs(t, findByEmailErr, model.ErrNotFound)
+		assert.Empty(t, page.Users)
+		assert.Len(t, withDeleted.Users, 1)
+		assert.NoError(t, findDeletedErr)
+		assert.True(t, deleted.IsDeleted())
+		assert.Equal(t, 2, deleted.Version())
+
+		assert.NoError(t, deleted.Restore(time.Now()))
+		_, err = repo.Update(context.Background(), deleted)
+		assert.NoError(t, err)
+		restored, err := repo.FindByID(context.Background(), "test-id")
+		assert.NoError(t, err)
+		assert.False(t, restored.IsDeleted())
+		assert.Equal(t, 3, restored.Version())
+	})
+
+	t.Run("失敗: 削除されていないユーザーは削除済みとして検索されない", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+		_, err := repo.Create(context.Background(), newUser("test-id", "testuser"))
+		assert.NoError(t, err)
+
+		// Act
+		_, err = repo.FindDeletedByIDForUpdate(context.Background(), "test-id")
+
+		// Assert
+		assert.ErrorIs(t, err, model.ErrNotFound)
+	})
+
+	t.Run("成功: 保持期間を過ぎたユーザーをトークンとともに完全に削除し、監査ログを記録する", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+		tokenRepo := &RefreshTokenRepository{db: db}
+		expired := newUser("expired-id", "expired")
+		recent := newUser("recent-id", "recent")
+		active := newUser("active-id", "active")
+		for _, user := range []*model.User{expired, recent, active} {
+			_, err := repo.Create(context.Background(), user)
+			assert.NoError(t, err)
+			token, _, err := model.NewRefreshToken(testIDs, user.ID(), "", time.Hour)
+			assert.NoError(t, err)
+			assert.NoError(t, tokenRepo.Create(context.Background(), &token))
+		}
+		assert.NoError(t, repo.Delete(context.Background(), expired))
+		assert.NoError(t, repo.Delete(context.Background(), recent))
+		deletedAt := time.Now().Add(-48 * time.Hour).UTC()
+		assert.NoError(t, db.Exec("UPDATE users SET deleted_at = ? WHERE id = ?", deletedAt, "expired-id").Error)
+
+		// Act
+		purged, err := repo.Purge(context.Background(), time.Now().Add(-24*time.Hour))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+		var users []string
+		assert.NoError(t, db.Table("users").Order("id").Pluck("id", &users).Error)
+		assert.Equal(t, []string{"active-id", "recent-id"}, users)
+		var tokenOwners []string
+		assert.NoError(t, db.Table("refresh_tokens").Order("user_id").Pluck("user_id", &tokenOwners).Error)
+		assert.Equal(t, []string{"active-id", "recent-id"}, tokenOwners)
+		page, err := (&AuditLogRepository{db: db}).FindAll(context.Background(), repository.AuditLogQuery{Limit: 10, Action: model.AuditActionUserPurged})
+		assert.NoError(t, err)
+		if assert.Len(t, page.Logs, 1) {
+			log := page.Logs[0]
+			assert.Equal(t, "expired-id", log.TargetID)
+			assert.Empty(t, log.ActorID)
+			if assert.Len(t, log.Changes, 1) {
+				assert.Equal(t, "deleted_at", log.Changes[0].Field)
+				recorded, err := time.Parse(time.RFC3339Nano, *log.Changes[0].Before)
+				assert.NoError(t, err)
+				assert.WithinDuration(t, deletedAt, recorded, time.Millisecond)
+				assert.Nil(t, log.Changes[0].After)
+			}
+		}
+	})
+
+	t.Run("成功: 保持期間を過ぎたユーザーがいない場合は何もしない", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := &UserRepository{db: db, ids: testIDs, factory: testUserFactory}
+
+		// Act
+		purged, err := repo.Purge(context.Background(), time.Now())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), purged)
+	})
+}
@@ -0,0 +1,381 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// webhookSubscriptionRecord webhook_subscriptionsテーブルの1行。購読するイベントの種類はカンマ区切りで保存する
+type webhookSubscriptionRecord struct {
+	ID                  string     `gorm:"column:id;primaryKey"`
+	URL                 string     `gorm:"column:url"`
+	EventTypes          string     `gorm:"column:event_types"`
+	Description         string     `gorm:"column:description"`
+	Secret              string     `gorm:"column:secret"`
+	Active              bool       `gorm:"column:active"`
+	ConsecutiveFailures int        `gorm:"column:consecutive_failures"`
+	DisabledAt          *time.Time `gorm:"column:disabled_at"`
+	CreatedAt           time.Time  `gorm:"column:created_at"`
+	UpdatedAt           time.Time  `gorm:"column:updated_at"`
+}
+
+func (webhookSubscriptionRecord) TableName() string {
+	return "webhook_subscriptions"
+}
+
+func newWebhookSubscriptionRecord(s *model.WebhookSubscription) *webhookSubscriptionRecord {
+	return &webhookSubscriptionRecord{
+		ID:                  s.ID,
+		URL:                 s.URL,
+		EventTypes:          strings.Join(s.EventTypes, ","),
+		Description:         s.Description,
+		Secret:              s.Secret,
+		Active:              s.Active,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+		DisabledAt:          s.DisabledAt,
+		CreatedAt:           s.CreatedAt,
+		UpdatedAt:           s.UpdatedAt,
+	}
+}
+
+func (r *webhookSubscriptionRecord) toDomain() *model.WebhookSubscription {
+	return &model.WebhookSubscription{
+		ID:                  r.ID,
+		URL:                 r.URL,
+		EventTypes:          strings.Split(r.EventTypes, ","),
+		Description:         r.Description,
+		Secret:              r.Secret,
+		Active:              r.Active,
+		ConsecutiveFailures: r.ConsecutiveFailures,
+		DisabledAt:          r.DisabledAt,
+		CreatedAt:           r.CreatedAt,
+		UpdatedAt:           r.UpdatedAt,
+	}
+}
+
+// webhookDeliveryRecord webhook_deliveriesテーブルの1行。次に試す日時の比較がタイムゾーンに左右されないよう、日時はUTCで保存する
+type webhookDeliveryRecord struct {
+	ID             string     `gorm:"column:id;primaryKey"`
+	SubscriptionID string     `gorm:"column:subscription_id"`
+	EventID        string     `gorm:"column:event_id"`
+	EventType      string     `gorm:"column:event_type"`
+	Payload        string     `gorm:"column:payload"`
+	Status         string     `gorm:"column:status"`
+	Attempts       int        `gorm:"column:attempts"`
+	NextAttemptAt  time.Time  `gorm:"column:next_attempt_at"`
+	LastStatusCode int        `gorm:"column:last_status_code"`
+	LastError      string     `gorm:"column:last_error"`
+	DeliveredAt    *time.Time `gorm:"column:delivered_at"`
+	CreatedAt      time.Time  `gorm:"column:created_at"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at"`
+}
+
+func (webhookDeliveryRecord) TableName() string {
+	return "webhook_deliveries"
+}
+
+func newWebhookDeliveryRecord(d *model.WebhookDelivery) *webhookDeliveryRecord {
+	record := &webhookDeliveryRecord{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Payload:        string(d.Payload),
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt.UTC(),
+		LastStatusCode: d.LastStatusCode,
+		LastError:      truncateRunes(d.LastError, maxOutboxErrorLength),
+		CreatedAt:      d.CreatedAt.UTC(),
+		UpdatedAt:      d.UpdatedAt.UTC(),
+	}
+	if d.DeliveredAt != nil {
+		deliveredAt := d.DeliveredAt.UTC()
+		record.DeliveredAt = &deliveredAt
+	}
+	return record
+}
+
+func (r *webhookDeliveryRecord) toDomain() *model.WebhookDelivery {
+	return &model.WebhookDelivery{
+		ID:             r.ID,
+		SubscriptionID: r.SubscriptionID,
+		EventID:        r.EventID,
+		EventType:      r.EventType,
+		Payload:        []byte(r.Payload),
+		Status:         model.WebhookDeliveryStatus(r.Status),
+		Attempts:       r.Attempts,
+		NextAttemptAt:  r.NextAttemptAt,
+		LastStatusCode: r.LastStatusCode,
+		LastError:      r.LastError,
+		DeliveredAt:    r.DeliveredAt,
+		CreatedAt:      r.CreatedAt,
+		UpdatedAt:      r.UpdatedAt,
+	}
+}
+
+// webhookDeliveryAttemptRecord webhook_delivery_attemptsテーブルの1行。所要時間はミリ秒で保存する
+type webhookDeliveryAttemptRecord struct {
+	ID          string    `gorm:"column:id;primaryKey"`
+	DeliveryID  string    `gorm:"column:delivery_id"`
+	StatusCode  int       `gorm:"column:status_code"`
+	Error       string    `gorm:"column:error"`
+	DurationMs  int64     `gorm:"column:duration_ms"`
+	AttemptedAt time.Time `gorm:"column:attempted_at"`
+}
+
+func (webhookDeliveryAttemptRecord) TableName() string {
+	return "webhook_delivery_attempts"
+}
+
+func truncateRunes(s string, max int) string {
+	if runes := []rune(s); len(runes) > max {
+		return string(runes[:max])
+	}
+	return s
+}
+
+func errWebhookNotFound(err error) error {
+	return model.NewNotFoundError("Webhookが見つかりません", err)
+}
+
+type WebhookSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookSubscriptionRepository(db *gorm.DB) repository.WebhookSubscriptionRepository {
+	return &WebhookSubscriptionRepository{db: db}
+}
+
+func (r *WebhookSubscriptionRepository) Create(ctx context.Context, subscription *model.WebhookSubscription) error {
+	return conn(ctx, r.db).Create(newWebhookSubscriptionRecord(subscription)).Error
+}
+
+func (r *WebhookSubscriptionRepository) FindByID(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	record := &webhookSubscriptionRecord{}
+
+	if err := conn(ctx, r.db).Where("id = ?", id).First(record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errWebhookNotFound(err)
+		}
+		return nil, err
+	}
+	return record.toDomain(), nil
+}
+
+func (r *WebhookSubscriptionRepository) FindAll(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	return r.find(conn(ctx, r.db))
+}
+
+func (r *WebhookSubscriptionRepository) FindActive(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	return r.find(conn(ctx, r.db).Where("active = ?", true))
+}
+
+func (r *WebhookSubscriptionRepository) find(db *gorm.DB) ([]*model.WebhookSubscription, error) {
+	records := []*webhookSubscriptionRecord{}
+	if err := db.Order("id").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	subscriptions := make([]*model.WebhookSubscription, len(records))
+	for i, record := range records {
+		subscriptions[i] = record.toDomain()
+	}
+	return subscriptions, nil
+}
+
+func (r *WebhookSubscriptionRepository) Update(ctx context.Context, subscription *model.WebhookSubscription) error {
+	result := conn(ctx, r.db).Model(&webhookSubscriptionRecord{}).
+		Where("id = ?", subscription.ID).
+		Select("*").
+		Updates(newWebhookSubscriptionRecord(subscription))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errWebhookNotFound(nil)
+	}
+	return nil
+}
+
+// Delete 配信を試みた記録、配信、購読先の順に1つのトランザクションで削除する
+func (r *WebhookSubscriptionRepository) Delete(ctx context.Context, id string) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		deliveries := tx.Model(&webhookDeliveryRecord{}).Select("id").Where("subscription_id = ?", id)
+		if err := tx.Where("delivery_id IN (?)", deliveries).Delete(&webhookDeliveryAttemptRecord{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subscription_id = ?", id).Delete(&webhookDeliveryRecord{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ?", id).Delete(&webhookSubscriptionRecord{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errWebhookNotFound(nil)
+		}
+		return nil
+	})
+}
+
+func (r *WebhookSubscriptionRepository) ResetFailures(ctx context.Context, id string) error {
+	return conn(ctx, r.db).Model(&webhookSubscriptionRecord{}).
+		Where("id = ? AND consecutive_failures > 0", id).
+		UpdateColumn("consecutive_failures", 0).Error
+}
+
+// RecordFailure 回数の加算と配信の停止をそれぞれ条件付きの更新で行い、同時に記録されても回数を取りこぼさない
+func (r *WebhookSubscriptionRepository) RecordFailure(ctx context.Context, id string, threshold int, at time.Time) (bool, error) {
+	var disabled bool
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&webhookSubscriptionRecord{}).
+			Where("id = ?", id).
+			UpdateColumn("consecutive_failures", gorm.Expr("consecutive_failures + 1")).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&webhookSubscriptionRecord{}).
+			Where("id = ? AND active = ? AND consecutive_failures >= ?", id, true, threshold).
+			Updates(map[string]interface{}{"active": false, "disabled_at": at, "updated_at": at})
+		disabled = result.RowsAffected == 1
+		return result.Error
+	})
+	return disabled, err
+}
+
+type WebhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookDeliveryRepository(db *gorm.DB) repository.WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{db: db}
+}
+
+func (r *WebhookDeliveryRepository) CreateAll(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	records := make([]*webhookDeliveryRecord, len(deliveries))
+	for i, delivery := range deliveries {
+		records[i] = newWebhookDeliveryRecord(delivery)
+	}
+	return conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(records).Error
+}
+
+func (r *WebhookDeliveryRepository) FindByID(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	record := &webhookDeliveryRecord{}
+
+	if err := conn(ctx, r.db).Where("id = ?", id).First(record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.NewNotFoundError("配信が見つかりません", err)
+		}
+		return nil, err
+	}
+	return record.toDomain(), nil
+}
+
+func (r *WebhookDeliveryRepository) FindAll(ctx context.Context, query repository.WebhookDeliveryQuery) (*repository.WebhookDeliveryPage, error) {
+	limit := query.Limit
+	if limit <= 0 || limit > repository.MaxWebhookDeliveryLimit {
+		limit = repository.DefaultWebhookDeliveryLimit
+	}
+
+	db := conn(ctx, r.db).Model(&webhookDeliveryRecord{})
+	if query.SubscriptionID != "" {
+		db = db.Where("subscription_id = ?", query.SubscriptionID)
+	}
+	if query.Status != "" {
+		db = db.Where("status = ?", string(query.Status))
+	}
+	if query.EventType != "" {
+		db = db.Where("event_type = ?", query.EventType)
+	}
+	if query.Cursor != nil {
+		db = db.Where("id < ?", query.Cursor.ID)
+	}
+
+	records := []*webhookDeliveryRecord{}
+	// 次ページの有無を判定するため1件多く取得する
+	if err := db.Order("id DESC").Limit(limit + 1).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	deliveries := make([]*model.WebhookDelivery, len(records))
+	for i, record := range records {
+		deliveries[i] = record.toDomain()
+	}
+
+	page := &repository.WebhookDeliveryPage{Deliveries: deliveries}
+	if len(deliveries) > limit {
+		page.Deliveries = deliveries[:limit]
+		page.NextCursor = repository.WebhookDeliveryCursor{ID: deliveries[limit-1].ID}.Encode()
+	}
+	return page, nil
+}
+
+func (r *WebhookDeliveryRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	active := conn(ctx, r.db).Model(&webhookSubscriptionRecord{}).Select("id").Where("active = ?", true)
+	records := []*webhookDeliveryRecord{}
+	if err := conn(ctx, r.db).
+		Where("status = ? AND next_attempt_at <= ? AND subscription_id IN (?)", string(model.WebhookDeliveryPending), now.UTC(), active).
+		Order("next_attempt_at").
+		Order("id").
+		Limit(limit).
+		Find(&records).Error; err != nil {
+		return nil, err
+	}
+	deliveries := make([]*model.WebhookDelivery, len(records))
+	for i, record := range records {
+		deliveries[i] = record.toDomain()
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookDeliveryRepository) Update(ctx context.Context, delivery *model.WebhookDelivery) error {
+	result := conn(ctx, r.db).Model(&webhookDeliveryRecord{}).
+		Where("id = ?", delivery.ID).
+		Select("*").
+		Updates(newWebhookDeliveryRecord(delivery))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.NewNotFoundError("配信が見つかりません", nil)
+	}
+	return nil
+}
+
+func (r *WebhookDeliveryRepository) CreateAttempt(ctx context.Context, attempt *model.WebhookDeliveryAttempt) error {
+	return conn(ctx, r.db).Create(&webhookDeliveryAttemptRecord{
+		ID:          attempt.ID,
+		DeliveryID:  attempt.DeliveryID,
+		StatusCode:  attempt.StatusCode,
+		Error:       truncateRunes(attempt.Error, maxOutboxErrorLength),
+		DurationMs:  attempt.Duration.Milliseconds(),
+		AttemptedAt: attempt.AttemptedAt.UTC(),
+	}).Error
+}
+
+func (r *WebhookDeliveryRepository) FindAttempts(ctx context.Context, deliveryID string) ([]*model.WebhookDeliveryAttempt, error) {
+	records := []*webhookDeliveryAttemptRecord{}
+	if err := conn(ctx, r.db).Where("delivery_id = ?", deliveryID).Order("attempted_at").Order("id").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	attempts := make([]*model.WebhookDeliveryAttempt, len(records))
+	for i, record := range records {
+		attempts[i] = &model.WebhookDeliveryAttempt{
+			ID:          record.ID,
+			DeliveryID:  record.DeliveryID,
+			StatusCode:  record.StatusCode,
+			Error:       record.Error,
+			Duration:    time.Duration(record.DurationMs) * time.Millisecond,
+			AttemptedAt: record.AttemptedAt,
+		}
+	}
+	return attempts, nil
+}
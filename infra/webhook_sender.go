@@ -0,0 +1,92 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// maxWebhookResponseLength エラーの理由として残す応答本文のバイト数の上限
+const maxWebhookResponseLength = 512
+
+// HTTPWebhookSender 配信の本文をJSONとしてPOSTする。リダイレクトには従わず、2xx以外の応答は失敗として扱う
+type HTTPWebhookSender struct {
+	client *http.Client
+}
+
+// errWebhookAddressNotAllowed 接続先が内部のネットワークのアドレスだった
+var errWebhookAddressNotAllowed = errors.New("webhook destination address is not allowed")
+
+// NewHTTPWebhookSender 名前解決した後の接続先のアドレスをmodel.IsWebhookAddressAllowedで確認し、
+// 購読の登録後にDNSの応答を内部のアドレスに変えられた場合も接続しない
+func NewHTTPWebhookSender(timeout time.Duration) *HTTPWebhookSender {
+	return newHTTPWebhookSender(timeout, model.IsWebhookAddressAllowed)
+}
+
+func newHTTPWebhookSender(timeout time.Duration, allowed func(ip net.IP) bool) *HTTPWebhookSender {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		// Controlは名前解決後、実際に接続するアドレスごとに呼ばれる
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !allowed(ip) {
+				return fmt.Errorf("%w: %s", errWebhookAddressNotAllowed, host)
+			}
+			return nil
+		},
+	}
+	return &HTTPWebhookSender{client: &http.Client{
+		Timeout: timeout,
+		// 環境変数のプロキシを経由すると接続先のアドレスを確認できないため使わない
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+func (s *HTTPWebhookSender) Send(ctx context.Context, subscription *model.WebhookSubscription, delivery *model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "api-sample-with-echo-ddd-webhook")
+	// 再送しても同じ値になるよう、配信ではなくイベントのIDを使う
+	req.Header.Set(model.WebhookHeaderID, delivery.EventID)
+	req.Header.Set(model.WebhookHeaderEvent, delivery.EventType)
+	req.Header.Set(model.WebhookHeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(model.WebhookHeaderSignature, model.SignWebhook(subscription.Secret, timestamp, delivery.Payload))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxWebhookResponseLength))
+	if err != nil {
+		return res.StatusCode, err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("unexpected status %d: %s", res.StatusCode, bytes.TrimSpace(body))
+	}
+	return res.StatusCode, nil
+}
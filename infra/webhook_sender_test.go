@@ -0,0 +1,202 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"api-sample-with-echo-ddd/usecase"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// webhookReceiver 受け取った配信の署名を検証し、statusesの順に応答する。statusesを使い切った後は200を返す
+type webhookReceiver struct {
+	mu       sync.Mutex
+	secret   string
+	statuses []int
+	received []string
+	invalid  int
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	body, _ := io.ReadAll(req.Body)
+	if !model.VerifyWebhookSignature(r.secret, req.Header.Get(model.WebhookHeaderSignature), req.Header.Get(model.WebhookHeaderTimestamp), body, time.Now(), 5*time.Minute) {
+		r.invalid++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	r.received = append(r.received, req.Header.Get(model.WebhookHeaderID))
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+// newTestWebhookSender httptestのサーバーはループバックで待ち受けるため、全てのアドレスへの送信を許可する
+func newTestWebhookSender() *HTTPWebhookSender {
+	return newHTTPWebhookSender(time.Second, func(net.IP) bool { return true })
+}
+
+func TestHTTPWebhookSender_Send(t *testing.T) {
+	t.Run("成功: 署名とイベントの情報をヘッダーに付けて送信する", func(t *testing.T) {
+		// Arrange
+		var header http.Header
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			header = req.Header
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+		subscription := &model.WebhookSubscription{URL: server.URL, Secret: "whsec_secret"}
+		delivery := &model.WebhookDelivery{EventID: "event-1", EventType: model.EventTypeUserDeleted, Payload: []byte(`{}`)}
+
+		// Act
+		status, err := newTestWebhookSender().Send(context.Background(), subscription, delivery)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, status)
+		assert.Equal(t, "application/json", header.Get("Content-Type"))
+		assert.Equal(t, "event-1", header.Get(model.WebhookHeaderID))
+		assert.Equal(t, model.EventTypeUserDeleted, header.Get(model.WebhookHeaderEvent))
+		assert.True(t, model.VerifyWebhookSignature("whsec_secret", header.Get(model.WebhookHeaderSignature), header.Get(model.WebhookHeaderTimestamp), delivery.Payload, time.Now(), time.Minute))
+	})
+
+	t.Run("失敗: 2xx以外の応答はリダイレクトも含めて失敗として扱う", func(t *testing.T) {
+		// Arrange
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/moved" {
+				http.Redirect(w, req, "/", http.StatusFound)
+				return
+			}
+			http.Error(w, "maintenance", http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		sender := newTestWebhookSender()
+		delivery := &model.WebhookDelivery{Payload: []byte(`{}`)}
+
+		// Act
+		status, err := sender.Send(context.Background(), &model.WebhookSubscription{URL: server.URL}, delivery)
+		redirectStatus, redirectErr := sender.Send(context.Background(), &model.WebhookSubscription{URL: server.URL + "/moved"}, delivery)
+
+		// Assert
+		assert.EqualError(t, err, "unexpected status 503: maintenance")
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		assert.Error(t, redirectErr)
+		assert.Equal(t, http.StatusFound, redirectStatus)
+	})
+
+	t.Run("失敗: 内部のネットワークのアドレスには接続しない", func(t *testing.T) {
+		// Arrange
+		requested := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			requested = true
+		}))
+		defer server.Close()
+
+		// Act
+		status, err := NewHTTPWebhookSender(time.Second).Send(context.Background(), &model.WebhookSubscription{URL: server.URL}, &model.WebhookDelivery{Payload: []byte(`{}`)})
+
+		// Assert
+		assert.ErrorIs(t, err, errWebhookAddressNotAllowed)
+		assert.Zero(t, status)
+		assert.False(t, requested)
+	})
+
+	t.Run("失敗: 応答が無い", func(t *testing.T) {
+		// Arrange
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+		server.Close()
+
+		// Act
+		status, err := newTestWebhookSender().Send(context.Background(), &model.WebhookSubscription{URL: server.URL}, &model.WebhookDelivery{})
+
+		// Assert
+		assert.Error(t, err)
+		assert.Zero(t, status)
+	})
+}
+
+func TestWebhookDispatcher_Receiver(t *testing.T) {
+	config := usecase.WebhookDispatcherConfig{BatchSize: 10, MaxAttempts: 3, DisableAfterFailures: 2}
+
+	// publish ユーザーのイベントをアウトボックスから購読先への配信にする
+	publish := func(t *testing.T, publisher usecase.EventPublisher) string {
+		message, err := model.NewOutboxMessage(testIDs, model.UserRegistered{UserID: "user-1", Username: "testuser", Email: "test@example.com", At: time.Now()})
+		assert.NoError(t, err)
+		assert.NoError(t, publisher.Publish(context.Background(), &message))
+		return message.ID
+	}
+
+	t.Run("成功: 失敗した配信を再送し、受信側で署名を検証できる", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		subscriptionRepo, deliveryRepo := NewWebhookSubscriptionRepository(db), NewWebhookDeliveryRepository(db)
+		subscription := createWebhookSubscription(t, db, model.EventTypeUserRegistered)
+		receiver := &webhookReceiver{secret: subscription.Secret, statuses: []int{http.StatusInternalServerError}}
+		server := httptest.NewServer(receiver)
+		defer server.Close()
+		subscription.URL = server.URL
+		assert.NoError(t, subscriptionRepo.Update(context.Background(), subscription))
+		eventID := publish(t, usecase.NewWebhookEventPublisher(subscriptionRepo, deliveryRepo, testIDs))
+		dispatcher := usecase.NewWebhookDispatcher(subscriptionRepo, deliveryRepo, newTestWebhookSender(), testIDs, config)
+
+		// Act
+		first, err := dispatcher.Dispatch(context.Background())
+		assert.NoError(t, err)
+		second, err := dispatcher.Dispatch(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 0, first)
+		assert.Equal(t, 1, second)
+		assert.Equal(t, []string{eventID, eventID}, receiver.received)
+		assert.Zero(t, receiver.invalid)
+		page, err := deliveryRepo.FindAll(context.Background(), repository.WebhookDeliveryQuery{SubscriptionID: subscription.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, model.WebhookDeliverySucceeded, page.Deliveries[0].Status)
+		attempts, err := deliveryRepo.FindAttempts(context.Background(), page.Deliveries[0].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{http.StatusInternalServerError, http.StatusOK}, []int{attempts[0].StatusCode, attempts[1].StatusCode})
+	})
+
+	t.Run("成功: 続けて失敗した購読先への配信を止める", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		subscriptionRepo, deliveryRepo := NewWebhookSubscriptionRepository(db), NewWebhookDeliveryRepository(db)
+		subscription := createWebhookSubscription(t, db, model.EventTypeUserRegistered)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusGone)
+		}))
+		defer server.Close()
+		subscription.URL = server.URL
+		assert.NoError(t, subscriptionRepo.Update(context.Background(), subscription))
+		publisher := usecase.NewWebhookEventPublisher(subscriptionRepo, deliveryRepo, testIDs)
+		for i := 0; i < 3; i++ {
+			publish(t, publisher)
+		}
+		dispatcher := usecase.NewWebhookDispatcher(subscriptionRepo, deliveryRepo, newTestWebhookSender(), testIDs, config)
+
+		// Act
+		_, err := dispatcher.Dispatch(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+		found, err := subscriptionRepo.FindByID(context.Background(), subscription.ID)
+		assert.NoError(t, err)
+		assert.False(t, found.Active)
+		var attempts int64
+		assert.NoError(t, db.Model(&webhookDeliveryAttemptRecord{}).Count(&attempts).Error)
+		assert.Equal(t, int64(2), attempts, "止めた後は残りの配信を送らない")
+	})
+}
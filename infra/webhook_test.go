@@ -0,0 +1,239 @@
+package infra
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func createWebhookSubscription(t *testing.T, db *gorm.DB, eventTypes ...string) *model.WebhookSubscription {
+	subscription, err := model.NewWebhookSubscription(testIDs, "https://example.com/hooks", eventTypes, "")
+	assert.NoError(t, err)
+	assert.NoError(t, NewWebhookSubscriptionRepository(db).Create(context.Background(), &subscription))
+	return &subscription
+}
+
+func newWebhookDelivery(t *testing.T, subscription *model.WebhookSubscription) *model.WebhookDelivery {
+	message, err := model.NewOutboxMessage(testIDs, model.UserDeleted{UserID: "user-1", At: time.Now()})
+	assert.NoError(t, err)
+	delivery, err := model.NewWebhookDelivery(testIDs, subscription, &message)
+	assert.NoError(t, err)
+	return &delivery
+}
+
+func TestWebhookSubscriptionRepository(t *testing.T) {
+	t.Run("成功: 購読するイベントの種類を含めて保存し、取得する", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := NewWebhookSubscriptionRepository(db)
+		subscription := createWebhookSubscription(t, db, model.EventTypeUserRegistered, model.EventTypeUserDeleted)
+
+		// Act
+		found, err := repo.FindByID(context.Background(), subscription.ID)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{model.EventTypeUserRegistered, model.EventTypeUserDeleted}, found.EventTypes)
+		assert.Equal(t, subscription.Secret, found.Secret)
+		assert.True(t, found.Active)
+	})
+
+	t.Run("失敗: 存在しない購読先", func(t *testing.T) {
+		// Arrange
+		repo := NewWebhookSubscriptionRepository(setupTestDB())
+
+		// Act
+		_, err := repo.FindByID(context.Background(), "missing")
+		updateErr := repo.Update(context.Background(), &model.WebhookSubscription{ID: "missing", EventTypes: []string{model.EventTypeUserDeleted}})
+		deleteErr := repo.Delete(context.Background(), "missing")
+
+		// Assert
+		assert.ErrorIs(t, err, model.ErrNotFound)
+		assert.ErrorIs(t, updateErr, model.ErrNotFound)
+		assert.ErrorIs(t, deleteErr, model.ErrNotFound)
+	})
+
+	t.Run("成功: 配信を止めた購読先はFindActiveで返さない", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := NewWebhookSubscriptionRepository(db)
+		active := createWebhookSubscription(t, db, model.EventTypeUserDeleted)
+		disabled := createWebhookSubscription(t, db, model.EventTypeUserDeleted)
+		disabled.Disable(time.Now())
+		assert.NoError(t, repo.Update(context.Background(), disabled))
+
+		// Act
+		subscriptions, err := repo.FindActive(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, subscriptions, 1)
+		assert.Equal(t, active.ID, subscriptions[0].ID)
+	})
+
+	t.Run("成功: 続けて失敗した回数が上限に達したら配信を止め、成功したら数え直す", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := NewWebhookSubscriptionRepository(db)
+		subscription := createWebhookSubscription(t, db, model.EventTypeUserDeleted)
+		ctx := context.Background()
+
+		// Act
+		first, err := repo.RecordFailure(ctx, subscription.ID, 2, time.Now())
+		assert.NoError(t, err)
+		assert.NoError(t, repo.ResetFailures(ctx, subscription.ID))
+		second, err := repo.RecordFailure(ctx, subscription.ID, 2, time.Now())
+		assert.NoError(t, err)
+		third, err := repo.RecordFailure(ctx, subscription.ID, 2, time.Now())
+		assert.NoError(t, err)
+		fourth, err := repo.RecordFailure(ctx, subscription.ID, 2, time.Now())
+		assert.NoError(t, err)
+
+		// Assert
+		assert.False(t, first)
+		assert.False(t, second)
+		assert.True(t, third)
+		assert.False(t, fourth, "既に止めた購読先は改めて止めない")
+		found, err := repo.FindByID(ctx, subscription.ID)
+		assert.NoError(t, err)
+		assert.False(t, found.Active)
+		assert.NotNil(t, found.DisabledAt)
+		assert.Equal(t, 3, found.ConsecutiveFailures)
+	})
+
+	t.Run("成功: 購読先を配信履歴とともに削除する", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := NewWebhookSubscriptionRepository(db)
+		deliveryRepo := NewWebhookDeliveryRepository(db)
+		subscription := createWebhookSubscription(t, db, model.EventTypeUserDeleted)
+		other := createWebhookSubscription(t, db, model.EventTypeUserDeleted)
+		delivery, otherDelivery := newWebhookDelivery(t, subscription), newWebhookDelivery(t, other)
+		assert.NoError(t, deliveryRepo.CreateAll(context.Background(), []*model.WebhookDelivery{delivery, otherDelivery}))
+		for _, d := range []*model.WebhookDelivery{delivery, otherDelivery} {
+			attempt := model.NewWebhookDeliveryAttempt(testIDs, d, 500, nil, time.Millisecond, time.Now())
+			assert.NoError(t, deliveryRepo.CreateAttempt(context.Background(), &attempt))
+		}
+
+		// Act
+		err := repo.Delete(context.Background(), subscription.ID)
+
+		// Assert
+		assert.NoError(t, err)
+		var deliveries, attempts int64
+		assert.NoError(t, db.Model(&webhookDeliveryRecord{}).Count(&deliveries).Error)
+		assert.NoError(t, db.Model(&webhookDeliveryAttemptRecord{}).Count(&attempts).Error)
+		assert.Equal(t, int64(1), deliveries)
+		assert.Equal(t, int64(1), attempts)
+	})
+}
+
+func TestWebhookDeliveryRepository(t *testing.T) {
+	t.Run("成功: 同じイベントを同じ購読先に届ける配信は1つだけ作成する", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := NewWebhookDeliveryRepository(db)
+		subscription := createWebhookSubscription(t, db, model.EventTypeUserDeleted)
+		delivery := newWebhookDelivery(t, subscription)
+		duplicate := *delivery
+		duplicate.ID = "duplicate"
+
+		// Act
+		assert.NoError(t, repo.CreateAll(context.Background(), []*model.WebhookDelivery{delivery}))
+		err := repo.CreateAll(context.Background(), []*model.WebhookDelivery{&duplicate})
+
+		// Assert
+		assert.NoError(t, err)
+		var count int64
+		assert.NoError(t, db.Model(&webhookDeliveryRecord{}).Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("成功: 配信を止めていない購読先への配信のうち、次に試す日時を迎えたものを取得する", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := NewWebhookDeliveryRepository(db)
+		active := createWebhookSubscription(t, db, model.EventTypeUserDeleted)
+		disabled := createWebhookSubscription(t, db, model.EventTypeUserDeleted)
+		disabled.Disable(time.Now())
+		assert.NoError(t, NewWebhookSubscriptionRepository(db).Update(context.Background(), disabled))
+		due, later, succeeded := newWebhookDelivery(t, active), newWebhookDelivery(t, active), newWebhookDelivery(t, active)
+		later.Fail(500, "unexpected status 500", 5, time.Now().Add(time.Hour), time.Now())
+		succeeded.Succeed(200, time.Now())
+		assert.NoError(t, repo.CreateAll(context.Background(), []*model.WebhookDelivery{due, later, succeeded, newWebhookDelivery(t, disabled)}))
+
+		// Act
+		deliveries, err := repo.FindDue(context.Background(), time.Now(), 10)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, deliveries, 1)
+		assert.Equal(t, due.ID, deliveries[0].ID)
+		assert.Equal(t, due.Payload, deliveries[0].Payload)
+	})
+
+	t.Run("成功: 結果を更新し、試みた記録を古い順に取得する", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := NewWebhookDeliveryRepository(db)
+		delivery := newWebhookDelivery(t, createWebhookSubscription(t, db, model.EventTypeUserDeleted))
+		assert.NoError(t, repo.CreateAll(context.Background(), []*model.WebhookDelivery{delivery}))
+		now := time.Now()
+		failed := model.NewWebhookDeliveryAttempt(testIDs, delivery, 503, assert.AnError, 120*time.Millisecond, now)
+		succeeded := model.NewWebhookDeliveryAttempt(testIDs, delivery, 200, nil, 80*time.Millisecond, now.Add(time.Second))
+		delivery.Succeed(200, now.Add(time.Second))
+
+		// Act
+		assert.NoError(t, repo.CreateAttempt(context.Background(), &failed))
+		assert.NoError(t, repo.CreateAttempt(context.Background(), &succeeded))
+		err := repo.Update(context.Background(), delivery)
+
+		// Assert
+		assert.NoError(t, err)
+		found, err := repo.FindByID(context.Background(), delivery.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, model.WebhookDeliverySucceeded, found.Status)
+		assert.Equal(t, 200, found.LastStatusCode)
+		assert.NotNil(t, found.DeliveredAt)
+		attempts, err := repo.FindAttempts(context.Background(), delivery.ID)
+		assert.NoError(t, err)
+		assert.Len(t, attempts, 2)
+		assert.Equal(t, assert.AnError.Error(), attempts[0].Error)
+		assert.Equal(t, 120*time.Millisecond, attempts[0].Duration)
+		assert.Equal(t, 200, attempts[1].StatusCode)
+	})
+
+	t.Run("成功: 状態で絞り込み、新しい順にページングする", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB()
+		repo := NewWebhookDeliveryRepository(db)
+		subscription := createWebhookSubscription(t, db, model.EventTypeUserDeleted)
+		var deliveries []*model.WebhookDelivery
+		for i := 0; i < 3; i++ {
+			deliveries = append(deliveries, newWebhookDelivery(t, subscription))
+		}
+		deliveries[1].Succeed(200, time.Now())
+		assert.NoError(t, repo.CreateAll(context.Background(), deliveries))
+		query := repository.WebhookDeliveryQuery{SubscriptionID: subscription.ID, Status: model.WebhookDeliveryPending, Limit: 1}
+
+		// Act
+		first, err := repo.FindAll(context.Background(), query)
+		assert.NoError(t, err)
+		cursor, err := repository.DecodeWebhookDeliveryCursor(first.NextCursor)
+		assert.NoError(t, err)
+		query.Cursor = &cursor
+		second, err := repo.FindAll(context.Background(), query)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, deliveries[2].ID, first.Deliveries[0].ID)
+		assert.Len(t, second.Deliveries, 1)
+		assert.Equal(t, deliveries[0].ID, second.Deliveries[0].ID)
+		assert.Empty(t, second.NextCursor)
+	})
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"api-sample-with-echo-ddd/usecase"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+type AuditLogHandler interface {
+	// GetByUser パスパラメータidのユーザーに対する操作の記録を返す。削除されたユーザーの記録も返す
+	GetByUser(c echo.Context) error
+	GetAll(c echo.Context) error
+}
+
+type auditLogHandler struct {
+	auditLogUsecase usecase.AuditLogUseCase
+}
+
+func NewAuditLogHandler(auditLogUsecase usecase.AuditLogUseCase) AuditLogHandler {
+	return &auditLogHandler{auditLogUsecase: auditLogUsecase}
+}
+
+type resAuditChange struct {
+	Field  string  `json:"field"`
+	Before *string `json:"before"`
+	After  *string `json:"after"`
+}
+
+type resAuditLog struct {
+	ID        string           `json:"id"`
+	ActorID   string           `json:"actor_id,omitempty"`
+	Action    string           `json:"action"`
+	TargetID  string           `json:"target_id"`
+	Changes   []resAuditChange `json:"changes"`
+	RequestID string           `json:"request_id,omitempty"`
+	IP        string           `json:"ip,omitempty"`
+	CreatedAt string           `json:"created_at"`
+}
+
+func newResAuditLog(log *model.AuditLog) resAuditLog {
+	changes := make([]resAuditChange, len(log.Changes))
+	for i, change := range log.Changes {
+		changes[i] = resAuditChange{Field: change.Field, Before: change.Before, After: change.After}
+	}
+	return resAuditLog{
+		ID:        log.ID,
+		ActorID:   log.ActorID,
+		Action:    string(log.Action),
+		TargetID:  log.TargetID,
+		Changes:   changes,
+		RequestID: log.RequestID,
+		IP:        log.IP,
+		CreatedAt: log.CreatedAt.Format(time.RFC3339Nano),
+	}
+}
+
+func (h *auditLogHandler) GetByUser(c echo.Context) error {
+	id, err := model.ParseUserID(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	query, err := bindAuditLogQuery(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	query.TargetID = id.String()
+	return h.findAll(c, query)
+}
+
+func (h *auditLogHandler) GetAll(c echo.Context) error {
+	query, err := bindAuditLogQuery(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	if targetID := c.QueryParam("target_id"); targetID != "" {
+		id, err := model.ParseUserID(targetID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+		}
+		query.TargetID = id.String()
+	}
+	return h.findAll(c, query)
+}
+
+func (h *auditLogHandler) findAll(c echo.Context, query repository.AuditLogQuery) error {
+	page, err := h.auditLogUsecase.FindAll(c.Request().Context(), query)
+	if err != nil {
+		return err
+	}
+
+	if page.NextCursor != "" {
+		next := *c.Request().URL
+		params := next.Query()
+		params.Set("cursor", page.NextCursor)
+		next.RawQuery = params.Encode()
+		c.Response().Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+		c.Response().Header().Set("X-Next-Cursor", page.NextCursor)
+	}
+
+	resLogs := make([]resAuditLog, len(page.Logs))
+	for i, log := range page.Logs {
+		resLogs[i] = newResAuditLog(log)
+	}
+	return c.JSON(http.StatusOK, resLogs)
+}
+
+// bindAuditLogQuery クエリパラメータから監査ログの取得条件を組み立てる
+func bindAuditLogQuery(c echo.Context) (repository.AuditLogQuery, error) {
+	query := repository.AuditLogQuery{Limit: repository.DefaultAuditLogLimit}
+
+	if actorID := c.QueryParam("actor_id"); actorID != "" {
+		id, err := model.ParseUserID(actorID)
+		if err != nil {
+			return query, err
+		}
+		query.ActorID = id.String()
+	}
+	if action := c.QueryParam("action"); action != "" {
+		parsed, err := model.ParseAuditAction(action)
+		if err != nil {
+			return query, err
+		}
+		query.Action = parsed
+	}
+	if limit := c.QueryParam("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > repository.MaxAuditLogLimit {
+			return query, fmt.Errorf("limitは1以上%d以下で指定してください", repository.MaxAuditLogLimit)
+		}
+		query.Limit = n
+	}
+	for _, param := range []struct {
+		name   string
+		target *time.Time
+	}{
+		{"since", &query.Since},
+		{"until", &query.Until},
+	} {
+		if value := c.QueryParam(param.name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return query, fmt.Errorf("%sはRFC 3339形式で指定してください", param.name)
+			}
+			*param.target = t
+		}
+	}
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		decoded, err := repository.DecodeAuditLogCursor(cursor)
+		if err != nil {
+			return query, err
+		}
+		query.Cursor = &decoded
+	}
+
+	return query, nil
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAuditLogUseCase is a mock implementation of AuditLogUseCase
+type MockAuditLogUseCase struct {
+	mock.Mock
+}
+
+func (m *MockAuditLogUseCase) FindAll(ctx context.Context, query repository.AuditLogQuery) (*repository.AuditLogPage, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.AuditLogPage), args.Error(1)
+}
+
+const auditTargetID = "0190a8b0-0000-7000-8000-000000000001"
+
+func TestAuditLogHandler_GetByUser(t *testing.T) {
+	t.Run("成功: ユーザーの監査ログを返す", func(t *testing.T) {
+		mockUseCase := new(MockAuditLogUseCase)
+		handler := NewAuditLogHandler(mockUseCase)
+
+		before, after := "olduser", "newuser"
+		page := &repository.AuditLogPage{
+			Logs: []*model.AuditLog{{
+				ID:        "log-id",
+				ActorID:   auditTargetID,
+				Action:    model.AuditActionUserUpdated,
+				TargetID:  auditTargetID,
+				Changes:   []model.AuditChange{{Field: "username", Before: &before, After: &after}},
+				CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			}},
+			NextCursor: "next",
+		}
+		mockUseCase.On("FindAll", repository.AuditLogQuery{Limit: 10, TargetID: auditTargetID, Action: model.AuditActionUserUpdated}).Return(page, nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/users/"+auditTargetID+"/audit?limit=10&action=user.updated", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(auditTargetID)
+
+		err := handler.GetByUser(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "next", rec.Header().Get("X-Next-Cursor"))
+		assert.Contains(t, rec.Body.String(), `"action":"user.updated"`)
+		assert.Contains(t, rec.Body.String(), `"changes":[{"field":"username","before":"olduser","after":"newuser"}]`)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("失敗: 不正なIDは400", func(t *testing.T) {
+		mockUseCase := new(MockAuditLogUseCase)
+		handler := NewAuditLogHandler(mockUseCase)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/users/invalid/audit", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("invalid")
+
+		err := handler.GetByUser(c)
+
+		he, ok := err.(*echo.HTTPError)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusBadRequest, he.Code)
+		mockUseCase.AssertNotCalled(t, "FindAll", mock.Anything)
+	})
+}
+
+func TestAuditLogHandler_GetAll(t *testing.T) {
+	t.Run("成功: 条件を指定して取得できる", func(t *testing.T) {
+		mockUseCase := new(MockAuditLogUseCase)
+		handler := NewAuditLogHandler(mockUseCase)
+
+		since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		mockUseCase.On("FindAll", repository.AuditLogQuery{Limit: repository.DefaultAuditLogLimit, ActorID: auditTargetID, TargetID: auditTargetID, Since: since}).
+			Return(&repository.AuditLogPage{Logs: []*model.AuditLog{}}, nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/audit?actor_id="+auditTargetID+"&target_id="+auditTargetID+"&since=2024-01-01T00:00:00Z", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler.GetAll(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "[]\n", rec.Body.String())
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("失敗: 不正な条件は400", func(t *testing.T) {
+		for _, query := range []string{"action=unknown", "limit=0", "since=yesterday", "cursor=invalid", "target_id=invalid"} {
+			mockUseCase := new(MockAuditLogUseCase)
+			handler := NewAuditLogHandler(mockUseCase)
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/audit?"+query, nil)
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			err := handler.GetAll(c)
+
+			he, ok := err.(*echo.HTTPError)
+			assert.True(t, ok, query)
+			assert.Equal(t, http.StatusBadRequest, he.Code, query)
+		}
+	})
+}
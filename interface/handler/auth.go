@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/interface/middleware"
+	"api-sample-with-echo-ddd/usecase"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+type AuthHandler interface {
+	Login(c echo.Context) error
+	Refresh(c echo.Context) error
+	Logout(c echo.Context) error
+	LogoutAll(c echo.Context) error
+}
+
+type authHandler struct {
+	authUsecase usecase.AuthUseCase
+}
+
+func NewAuthHandler(authUsecase usecase.AuthUseCase) AuthHandler {
+	return &authHandler{authUsecase: authUsecase}
+}
+
+type reqLogin struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type reqRefreshToken struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type resToken struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+}
+
+func newResToken(tokens *usecase.TokenPair) resToken {
+	return resToken{
+		AccessToken:      tokens.AccessToken.Token,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(time.Until(tokens.AccessToken.ExpiresAt).Seconds()),
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresIn: int64(time.Until(tokens.RefreshExpiresAt).Seconds()),
+	}
+}
+
+func (h *authHandler) Login(c echo.Context) error {
+	var reqLogin reqLogin
+	if err := c.Bind(&reqLogin); err != nil {
+		return err
+	}
+
+	tokens, err := h.authUsecase.Login(c.Request().Context(), reqLogin.Email, reqLogin.Password)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newResToken(tokens))
+}
+
+func (h *authHandler) Refresh(c echo.Context) error {
+	var reqRefreshToken reqRefreshToken
+	if err := c.Bind(&reqRefreshToken); err != nil {
+		return err
+	}
+
+	tokens, err := h.authUsecase.Refresh(c.Request().Context(), reqRefreshToken.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newResToken(tokens))
+}
+
+func (h *authHandler) Logout(c echo.Context) error {
+	var reqRefreshToken reqRefreshToken
+	if err := c.Bind(&reqRefreshToken); err != nil {
+		return err
+	}
+
+	if err := h.authUsecase.Logout(c.Request().Context(), reqRefreshToken.RefreshToken); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *authHandler) LogoutAll(c echo.Context) error {
+	claims := middleware.ClaimsFrom(c)
+	if claims == nil {
+		return model.NewUnauthorizedError("認証が必要です")
+	}
+
+	if err := h.authUsecase.LogoutAll(c.Request().Context(), claims.UserID); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
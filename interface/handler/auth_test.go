@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/interface/middleware"
+	"api-sample-with-echo-ddd/usecase"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAuthUseCase is a mock implementation of AuthUseCase
+type MockAuthUseCase struct {
+	mock.Mock
+}
+
+func (m *MockAuthUseCase) Login(ctx context.Context, email string, password string) (*usecase.TokenPair, error) {
+	args := m.Called(email, password)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.TokenPair), args.Error(1)
+}
+
+func (m *MockAuthUseCase) Refresh(ctx context.Context, refreshToken string) (*usecase.TokenPair, error) {
+	args := m.Called(refreshToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.TokenPair), args.Error(1)
+}
+
+func (m *MockAuthUseCase) Logout(ctx context.Context, refreshToken string) error {
+	args := m.Called(refreshToken)
+	return args.Error(0)
+}
+
+func (m *MockAuthUseCase) LogoutAll(ctx context.Context, userID string) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func newTestTokenPair() *usecase.TokenPair {
+	return &usecase.TokenPair{
+		AccessToken:      &usecase.AccessToken{Token: "token", ExpiresAt: time.Now().Add(15 * time.Minute)},
+		RefreshToken:     "refresh-token",
+		RefreshExpiresAt: time.Now().Add(24 * time.Hour),
+	}
+}
+
+func TestAuthHandler_Login(t *testing.T) {
+	t.Run("成功: アクセストークンを返す", func(t *testing.T) {
+		mockUseCase := new(MockAuthUseCase)
+		handler := NewAuthHandler(mockUseCase)
+
+		mockUseCase.On("Login", "test@example.com", "password123").Return(newTestTokenPair(), nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"email":"test@example.com","password":"password123"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler.Login(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response resToken
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "token", response.AccessToken)
+		assert.Equal(t, "Bearer", response.TokenType)
+		assert.InDelta(t, 900, response.ExpiresIn, 2)
+		assert.Equal(t, "refresh-token", response.RefreshToken)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("失敗: 認証エラー", func(t *testing.T) {
+		mockUseCase := new(MockAuthUseCase)
+		handler := NewAuthHandler(mockUseCase)
+
+		mockUseCase.On("Login", "test@example.com", "wrong").Return(nil, model.NewUnauthorizedError("メールアドレスまたはパスワードが正しくありません"))
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"email":"test@example.com","password":"wrong"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler.Login(c)
+
+		assert.Error(t, err)
+		HTTPErrorHandler(err, c)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		mockUseCase.AssertExpectations(t)
+	})
+}
+
+func TestAuthHandler_Refresh(t *testing.T) {
+	t.Run("成功: 新しいトークンを返す", func(t *testing.T) {
+		mockUseCase := new(MockAuthUseCase)
+		handler := NewAuthHandler(mockUseCase)
+
+		mockUseCase.On("Refresh", "old-refresh-token").Return(newTestTokenPair(), nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/auth/refresh", strings.NewReader(`{"refresh_token":"old-refresh-token"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler.Refresh(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response resToken
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "refresh-token", response.RefreshToken)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("失敗: 不正なトークン", func(t *testing.T) {
+		mockUseCase := new(MockAuthUseCase)
+		handler := NewAuthHandler(mockUseCase)
+
+		mockUseCase.On("Refresh", "reused").Return(nil, model.NewUnauthorizedError("リフレッシュトークンが不正です"))
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/auth/refresh", strings.NewReader(`{"refresh_token":"reused"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler.Refresh(c)
+
+		assert.Error(t, err)
+		HTTPErrorHandler(err, c)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestAuthHandler_Logout(t *testing.T) {
+	t.Run("成功: ログアウトできる", func(t *testing.T) {
+		mockUseCase := new(MockAuthUseCase)
+		handler := NewAuthHandler(mockUseCase)
+
+		mockUseCase.On("Logout", "refresh-token").Return(nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/auth/logout", strings.NewReader(`{"refresh_token":"refresh-token"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler.Logout(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("成功: 全ての端末からログアウトできる", func(t *testing.T) {
+		mockUseCase := new(MockAuthUseCase)
+		handler := NewAuthHandler(mockUseCase)
+
+		mockUseCase.On("LogoutAll", "user-id").Return(nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/auth/logout/all", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer token")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		verifier := stubTokenVerifier{claims: &usecase.Claims{UserID: "user-id", Role: model.RoleMember}}
+
+		err := middleware.Authenticate(verifier, stubAccountStatusChecker{})(handler.LogoutAll)(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("失敗: 未認証で全ての端末からログアウト", func(t *testing.T) {
+		mockUseCase := new(MockAuthUseCase)
+		handler := NewAuthHandler(mockUseCase)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/auth/logout/all", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler.LogoutAll(c)
+
+		assert.ErrorIs(t, err, model.ErrUnauthorized)
+		mockUseCase.AssertNotCalled(t, "LogoutAll", mock.Anything)
+	})
+}
+
+type stubTokenVerifier struct {
+	claims *usecase.Claims
+}
+
+func (v stubTokenVerifier) Verify(token string) (*usecase.Claims, error) {
+	return v.claims, nil
+}
+
+type stubAccountStatusChecker struct {
+	err error
+}
+
+func (c stubAccountStatusChecker) CheckStatus(ctx context.Context, userID string) (*model.User, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return model.ReconstructUser(model.UserSnapshot{ID: userID, Role: model.RoleMember}), nil
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"api-sample-with-echo-ddd/usecase"
+	"net/http"
+
+	"github.com/labstack/echo"
+)
+
+type EmailVerificationHandler interface {
+	Verify(c echo.Context) error
+	Resend(c echo.Context) error
+}
+
+type emailVerificationHandler struct {
+	emailVerificationUsecase usecase.EmailVerificationUseCase
+}
+
+func NewEmailVerificationHandler(emailVerificationUsecase usecase.EmailVerificationUseCase) EmailVerificationHandler {
+	return &emailVerificationHandler{emailVerificationUsecase: emailVerificationUsecase}
+}
+
+type reqVerifyEmail struct {
+	Token string `json:"token"`
+}
+
+type reqResendVerification struct {
+	Email string `json:"email"`
+}
+
+func (h *emailVerificationHandler) Verify(c echo.Context) error {
+	var reqVerifyEmail reqVerifyEmail
+	if err := c.Bind(&reqVerifyEmail); err != nil {
+		return err
+	}
+
+	user, err := h.emailVerificationUsecase.Verify(c.Request().Context(), reqVerifyEmail.Token)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(HeaderETag, etagOf(user))
+	return c.JSON(http.StatusOK, newResUser(user))
+}
+
+// Resend 登録の有無を推測されないよう、送信の有無に関わらず202を返す
+func (h *emailVerificationHandler) Resend(c echo.Context) error {
+	var reqResendVerification reqResendVerification
+	if err := c.Bind(&reqResendVerification); err != nil {
+		return err
+	}
+
+	if err := h.emailVerificationUsecase.Resend(c.Request().Context(), reqResendVerification.Email); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
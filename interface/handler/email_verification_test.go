@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockEmailVerificationUseCase is a mock implementation of EmailVerificationUseCase
+type MockEmailVerificationUseCase struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationUseCase) Send(ctx context.Context, user *model.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationUseCase) Verify(ctx context.Context, token string) (*model.User, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockEmailVerificationUseCase) Resend(ctx context.Context, email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func TestEmailVerificationHandler_Verify(t *testing.T) {
+	t.Run("成功: 確認済みのユーザーを返す", func(t *testing.T) {
+		mockUseCase := new(MockEmailVerificationUseCase)
+		handler := NewEmailVerificationHandler(mockUseCase)
+
+		verifiedAt := time.Now()
+		user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Username: "testuser", Email: "test@example.com", EmailVerifiedAt: &verifiedAt, Version: 2})
+		mockUseCase.On("Verify", "signed-token").Return(user, nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/user/verify", strings.NewReader(`{"token":"signed-token"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler.Verify(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `"2"`, rec.Header().Get(HeaderETag))
+		var response resUser
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.True(t, response.EmailVerified)
+	})
+}
+
+func TestEmailVerificationHandler_Resend(t *testing.T) {
+	t.Run("成功: 202を返す", func(t *testing.T) {
+		mockUseCase := new(MockEmailVerificationUseCase)
+		handler := NewEmailVerificationHandler(mockUseCase)
+
+		mockUseCase.On("Resend", "test@example.com").Return(nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/user/verify/resend", strings.NewReader(`{"email":"test@example.com"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler.Resend(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		mockUseCase.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo"
+)
+
+// MIMEApplicationProblemJSON RFC 7807のエラーレスポンス形式
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+type problem struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance"`
+	Errors   []problemField `json:"errors,omitempty"`
+}
+
+type problemField struct {
+	Pointer string `json:"pointer"`
+	Code    string `json:"code"`
+	Detail  string `json:"detail"`
+}
+
+type problemType struct {
+	uri    string
+	title  string
+	status int
+}
+
+var problemTypes = map[model.ErrorKind]problemType{
+	model.ErrorKindValidation:         {"/problems/validation-error", "入力内容に誤りがあります", http.StatusUnprocessableEntity},
+	model.ErrorKindNotFound:           {"/problems/not-found", "リソースが見つかりません", http.StatusNotFound},
+	model.ErrorKindConflict:           {"/problems/conflict", "リソースが競合しています", http.StatusConflict},
+	model.ErrorKindUnauthorized:       {"/problems/unauthorized", "認証に失敗しました", http.StatusUnauthorized},
+	model.ErrorKindForbidden:          {"/problems/forbidden", "権限がありません", http.StatusForbidden},
+	model.ErrorKindPreconditionFailed: {"/problems/precondition-failed", "前提条件を満たしていません", http.StatusPreconditionFailed},
+}
+
+// HTTPErrorHandler ハンドラーが返したエラーをproblem+jsonレスポンスに変換する
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	p := problemOf(err)
+	p.Instance = c.Request().URL.Path
+	if p.Status == http.StatusInternalServerError {
+		c.Logger().Error(err)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(p.Status)
+	} else {
+		var body []byte
+		body, err = json.Marshal(p)
+		if err == nil {
+			err = c.Blob(p.Status, MIMEApplicationProblemJSON, body)
+		}
+	}
+	if err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+func problemOf(err error) problem {
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		p := problem{Type: "about:blank", Title: http.StatusText(he.Code), Status: he.Code}
+		if message, ok := he.Message.(string); ok {
+			p.Detail = message
+		}
+		return p
+	}
+
+	var de *model.Error
+	if errors.As(err, &de) {
+		if t, ok := problemTypes[de.Kind]; ok {
+			p := problem{Type: t.uri, Title: t.title, Status: t.status, Detail: de.Message}
+			for _, f := range de.Fields {
+				p.Errors = append(p.Errors, problemField{Pointer: "/" + f.Field, Code: f.Code, Detail: f.Message})
+			}
+			return p
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(http.StatusServiceUnavailable),
+			Status: http.StatusServiceUnavailable,
+			Detail: "リクエストがタイムアウトしました",
+		}
+	}
+
+	return problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusInternalServerError),
+		Status: http.StatusInternalServerError,
+		Detail: "サーバー内部でエラーが発生しました",
+	}
+}
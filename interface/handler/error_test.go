@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	type TestCase struct {
+		name            string
+		err             error
+		expectedStatus  int
+		expectedMessage string
+	}
+	testCases := []TestCase{
+		{"バリデーションエラー", model.NewValidationError(model.CodeInvalidFormat, "メールアドレスが不正です"), http.StatusUnprocessableEntity, "メールアドレスが不正です"},
+		{"存在しない", model.NewNotFoundError("ユーザーが見つかりません", nil), http.StatusNotFound, "ユーザーが見つかりません"},
+		{"重複", model.NewConflictError("ユーザーは既に存在します", nil), http.StatusConflict, "ユーザーは既に存在します"},
+		{"項目の重複", model.NewFieldsConflictError([]model.FieldError{model.FieldEmailTaken}, nil), http.StatusConflict, "メールアドレスは既に使用されています"},
+		{"未認証", model.NewUnauthorizedError("認証が必要です"), http.StatusUnauthorized, "認証が必要です"},
+		{"権限なし", model.NewForbiddenError("権限がありません"), http.StatusForbidden, "権限がありません"},
+		{"ラップされたエラー", fmt.Errorf("wrapped: %w", model.NewNotFoundError("ユーザーが見つかりません", nil)), http.StatusNotFound, "ユーザーが見つかりません"},
+		{"Echoのエラー", echo.NewHTTPError(http.StatusBadRequest, "不正なリクエストです"), http.StatusBadRequest, "不正なリクエストです"},
+		{"タイムアウト", fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusServiceUnavailable, "リクエストがタイムアウトしました"},
+		{"想定外のエラー", errors.New("dial tcp: connection refused"), http.StatusInternalServerError, "サーバー内部でエラーが発生しました"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			HTTPErrorHandler(tc.err, c)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+
+			var response problem
+			err := json.Unmarshal(rec.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, response.Status)
+			assert.Equal(t, tc.expectedMessage, response.Detail)
+			assert.Equal(t, "/api/users", response.Instance)
+			assert.NotEmpty(t, response.Type)
+			assert.NotEmpty(t, response.Title)
+		})
+	}
+
+	t.Run("項目ごとのエラーを返す", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/users", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		factory, err := model.NewUserFactory(model.NewUUIDv7Generator(), model.EmailPolicy{}, model.DefaultPasswordPolicy())
+		assert.NoError(t, err)
+		_, err = factory.NewUser("ab", "invalid-email", "short")
+		HTTPErrorHandler(err, c)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+		var response problem
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "/problems/validation-error", response.Type)
+		assert.Equal(t, []problemField{
+			{Pointer: "/username", Code: model.CodeInvalidLength, Detail: "ユーザー名は3文字以上20文字以下で入力してください"},
+			{Pointer: "/email", Code: model.CodeInvalidFormat, Detail: "メールアドレスが不正です"},
+			{Pointer: "/password", Code: model.CodeTooShort, Detail: "パスワードは8文字以上で入力してください"},
+			{Pointer: "/password", Code: model.CodeMissingNumber, Detail: "パスワードは数字を含む必要があります"},
+		}, response.Errors)
+	})
+}
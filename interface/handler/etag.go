@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+const (
+	HeaderETag    = "ETag"
+	HeaderIfMatch = "If-Match"
+)
+
+// etagOf ユーザーのバージョンを強いETagとして表す
+func etagOf(user *model.User) string {
+	return `"` + strconv.Itoa(user.Version()) + `"`
+}
+
+// parseIfMatch If-Matchヘッダーが指すバージョンを返す。ヘッダーが無い場合と"*"の場合は0
+// 弱いETagや複数のETagは強い比較で一致させられないため、不一致として扱う
+func parseIfMatch(c echo.Context) (int, error) {
+	value := strings.TrimSpace(c.Request().Header.Get(HeaderIfMatch))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	unquoted, ok := strings.CutPrefix(value, `"`)
+	if ok {
+		unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	}
+	version, err := strconv.Atoi(unquoted)
+	if !ok || err != nil || version <= 0 {
+		return 0, model.NewPreconditionFailedError("If-Matchヘッダーが現在のユーザーと一致しません")
+	}
+	return version, nil
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	type TestCase struct {
+		name            string
+		ifMatch         string
+		expectedVersion int
+		expectedError   error
+	}
+	testCases := []TestCase{
+		{"ヘッダーなし", "", 0, nil},
+		{"ワイルドカード", "*", 0, nil},
+		{"強いETag", `"12"`, 12, nil},
+		{"弱いETag", `W/"12"`, 0, model.ErrPreconditionFailed},
+		{"複数のETag", `"1", "2"`, 0, model.ErrPreconditionFailed},
+		{"引用符なし", "12", 0, model.ErrPreconditionFailed},
+		{"数値以外", `"abc"`, 0, model.ErrPreconditionFailed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPut, "/", nil)
+			if tc.ifMatch != "" {
+				req.Header.Set(HeaderIfMatch, tc.ifMatch)
+			}
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			version, err := parseIfMatch(c)
+
+			assert.Equal(t, tc.expectedVersion, version)
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+		})
+	}
+}
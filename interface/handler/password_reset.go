@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"api-sample-with-echo-ddd/usecase"
+	"net/http"
+
+	"github.com/labstack/echo"
+)
+
+type PasswordResetHandler interface {
+	Forgot(c echo.Context) error
+	Reset(c echo.Context) error
+}
+
+type passwordResetHandler struct {
+	passwordResetUsecase usecase.PasswordResetUseCase
+}
+
+func NewPasswordResetHandler(passwordResetUsecase usecase.PasswordResetUseCase) PasswordResetHandler {
+	return &passwordResetHandler{passwordResetUsecase: passwordResetUsecase}
+}
+
+type reqForgotPassword struct {
+	Email string `json:"email"`
+}
+
+type reqResetPassword struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// Forgot 登録の有無を推測されないよう、送信の有無に関わらず202を返す
+func (h *passwordResetHandler) Forgot(c echo.Context) error {
+	var reqForgotPassword reqForgotPassword
+	if err := c.Bind(&reqForgotPassword); err != nil {
+		return err
+	}
+
+	if err := h.passwordResetUsecase.Forgot(c.Request().Context(), reqForgotPassword.Email); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
+
+func (h *passwordResetHandler) Reset(c echo.Context) error {
+	var reqResetPassword reqResetPassword
+	if err := c.Bind(&reqResetPassword); err != nil {
+		return err
+	}
+
+	if err := h.passwordResetUsecase.Reset(c.Request().Context(), reqResetPassword.Token, reqResetPassword.Password); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPasswordResetUseCase is a mock implementation of PasswordResetUseCase
+type MockPasswordResetUseCase struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetUseCase) Forgot(ctx context.Context, email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetUseCase) Reset(ctx context.Context, token string, password string) error {
+	args := m.Called(token, password)
+	return args.Error(0)
+}
+
+func TestPasswordResetHandler_Forgot(t *testing.T) {
+	t.Run("成功: 202を返す", func(t *testing.T) {
+		mockUseCase := new(MockPasswordResetUseCase)
+		handler := NewPasswordResetHandler(mockUseCase)
+
+		mockUseCase.On("Forgot", "unknown@example.com").Return(nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/auth/password/forgot", strings.NewReader(`{"email":"unknown@example.com"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler.Forgot(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		mockUseCase.AssertExpectations(t)
+	})
+}
+
+func TestPasswordResetHandler_Reset(t *testing.T) {
+	t.Run("成功: 204を返す", func(t *testing.T) {
+		mockUseCase := new(MockPasswordResetUseCase)
+		handler := NewPasswordResetHandler(mockUseCase)
+
+		mockUseCase.On("Reset", "signed-token", "newpassword1").Return(nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/auth/password/reset", strings.NewReader(`{"token":"signed-token","password":"newpassword1"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler.Reset(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+
+	t.Run("失敗: 不正なトークンはバリデーションエラー", func(t *testing.T) {
+		mockUseCase := new(MockPasswordResetUseCase)
+		handler := NewPasswordResetHandler(mockUseCase)
+
+		mockUseCase.On("Reset", "invalid", "newpassword1").Return(model.NewValidationError(model.CodeInvalidToken, "再設定トークンが不正か有効期限が切れています"))
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/auth/password/reset", strings.NewReader(`{"token":"invalid","password":"newpassword1"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler.Reset(c)
+
+		assert.ErrorIs(t, err, model.ErrValidation)
+	})
+}
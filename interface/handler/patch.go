@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+const (
+	// MIMEApplicationMergePatchJSON RFC 7396 JSON Merge Patch
+	MIMEApplicationMergePatchJSON = "application/merge-patch+json"
+	// MIMEApplicationJSONPatchJSON RFC 6902 JSON Patch
+	MIMEApplicationJSONPatchJSON = "application/json-patch+json"
+)
+
+type patchOperation struct {
+	Op    string           `json:"op"`
+	Path  string           `json:"path"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// bindPatch Content-Typeに応じてリクエストボディを変更内容に変換する
+func bindPatch(c echo.Context) (model.UserChanges, error) {
+	mediaType, _, err := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if err != nil {
+		return model.UserChanges{}, echo.ErrUnsupportedMediaType
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return model.UserChanges{}, err
+	}
+
+	switch mediaType {
+	case MIMEApplicationMergePatchJSON, echo.MIMEApplicationJSON:
+		return parseMergePatch(body)
+	case MIMEApplicationJSONPatchJSON:
+		return parseJSONPatch(body)
+	default:
+		return model.UserChanges{}, echo.ErrUnsupportedMediaType
+	}
+}
+
+func parseMergePatch(body []byte) (model.UserChanges, error) {
+	var patch map[string]*json.RawMessage
+	if err := json.Unmarshal(body, &patch); err != nil {
+		return model.UserChanges{}, echo.NewHTTPError(http.StatusBadRequest, "パッチの形式が不正です").SetInternal(err)
+	}
+
+	var changes model.UserChanges
+	for member, value := range patch {
+		if err := setChange(&changes, member, value); err != nil {
+			return model.UserChanges{}, err
+		}
+	}
+	return changes, nil
+}
+
+func parseJSONPatch(body []byte) (model.UserChanges, error) {
+	var operations []patchOperation
+	if err := json.Unmarshal(body, &operations); err != nil {
+		return model.UserChanges{}, echo.NewHTTPError(http.StatusBadRequest, "パッチの形式が不正です").SetInternal(err)
+	}
+
+	var changes model.UserChanges
+	for _, operation := range operations {
+		if operation.Op != "add" && operation.Op != "replace" && operation.Op != "test" {
+			return model.UserChanges{}, echo.NewHTTPError(http.StatusUnprocessableEntity, "サポートされていない操作です: "+operation.Op)
+		}
+		member, err := parsePointer(operation.Path)
+		if err != nil {
+			return model.UserChanges{}, err
+		}
+		if operation.Value == nil {
+			return model.UserChanges{}, echo.NewHTTPError(http.StatusBadRequest, "valueが指定されていません: "+operation.Path)
+		}
+		if operation.Op == "test" {
+			err = setTest(&changes, member, operation.Value)
+		} else {
+			err = setChange(&changes, member, operation.Value)
+		}
+		if err != nil {
+			return model.UserChanges{}, err
+		}
+	}
+	return changes, nil
+}
+
+// parsePointer RFC 6901のJSON Pointerを参照先のメンバー名に変換する。
+// ユーザーの項目は全てトップレベルにあるため、入れ子の参照は変更できない項目として扱う
+func parsePointer(pointer string) (string, error) {
+	if len(pointer) < 2 || pointer[0] != '/' || strings.Contains(pointer[1:], "/") {
+		return "", echo.NewHTTPError(http.StatusUnprocessableEntity, "変更できない項目です: "+pointer)
+	}
+	token := pointer[1:]
+	for i := 0; i < len(token); i++ {
+		if token[i] == '~' && (i+1 == len(token) || token[i+1] != '0' && token[i+1] != '1') {
+			return "", echo.NewHTTPError(http.StatusBadRequest, "パスの形式が不正です: "+pointer)
+		}
+	}
+	// "~01"を"/"ではなく"~1"に戻すため、左から順に置き換える
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(token), nil
+}
+
+// setTest testの操作を変更内容に加える。同じパッチで先に変更した項目は変更後の値と比較し、
+// それ以外は変更前の値との比較をユーザーの変更時に行う
+func setTest(changes *model.UserChanges, member string, raw *json.RawMessage) error {
+	var changed *string
+	var expected **string
+	switch member {
+	case "username":
+		changed, expected = changes.Username, &changes.ExpectedUsername
+	case "email":
+		changed, expected = changes.Email, &changes.ExpectedEmail
+	default:
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "比較できない項目です: "+member)
+	}
+
+	var value string
+	if err := json.Unmarshal(*raw, &value); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "文字列を指定してください: "+member).SetInternal(err)
+	}
+	if changed != nil {
+		if *changed != value {
+			return model.NewConflictError("値が一致しません: "+member, nil)
+		}
+		return nil
+	}
+	if *expected != nil && **expected != value {
+		return model.NewConflictError("値が一致しません: "+member, nil)
+	}
+	*expected = &value
+	return nil
+}
+
+func setChange(changes *model.UserChanges, member string, raw *json.RawMessage) error {
+	var target **string
+	switch member {
+	case "username":
+		target = &changes.Username
+	case "email":
+		target = &changes.Email
+	case "password":
+		target = &changes.Password
+	default:
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "変更できない項目です: "+member)
+	}
+
+	// nullは項目の削除を意味するが、ユーザーの項目は削除できない
+	if raw == nil || string(*raw) == "null" {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "削除できない項目です: "+member)
+	}
+
+	var value string
+	if err := json.Unmarshal(*raw, &value); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "文字列を指定してください: "+member).SetInternal(err)
+	}
+	*target = &value
+	return nil
+}
@@ -1,8 +1,14 @@
 package handler
 
 import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
 	"api-sample-with-echo-ddd/usecase"
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/labstack/echo"
@@ -13,7 +19,13 @@ type UserHandler interface {
 	Get(c echo.Context) error
 	GetAll(c echo.Context) error
 	Put(c echo.Context) error
+	Patch(c echo.Context) error
 	Delete(c echo.Context) error
+	Suspend(c echo.Context) error
+	Reinstate(c echo.Context) error
+	Deactivate(c echo.Context) error
+	Restore(c echo.Context) error
+	AssignRole(c echo.Context) error
 }
 
 type userHandler struct {
@@ -30,104 +42,323 @@ type reqUser struct {
 	Password string `json:"password"`
 }
 
+type reqStatus struct {
+	Reason string `json:"reason"`
+}
+
+type reqRole struct {
+	Role string `json:"role"`
+}
+
 type resUser struct {
-	ID        string `json:"id"`
-	Name      string `json:"username"`
-	Email     string `json:"email"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID            string `json:"id"`
+	Name          string `json:"username"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Role          string `json:"role"`
+	Status        string `json:"status"`
+	StatusReason  string `json:"status_reason,omitempty"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+	DeletedAt     string `json:"deleted_at,omitempty"`
+}
+
+func newResUser(user *model.User) resUser {
+	res := resUser{
+		ID:            user.ID(),
+		Name:          user.Username(),
+		Email:         user.Email(),
+		EmailVerified: user.IsEmailVerified(),
+		Role:          string(user.Role()),
+		Status:        string(user.Status()),
+		StatusReason:  user.StatusReason(),
+		CreatedAt:     user.CreatedAt().Format(time.RFC3339),
+		UpdatedAt:     user.UpdatedAt().Format(time.RFC3339),
+	}
+	if user.IsDeleted() {
+		res.DeletedAt = user.DeletedAt().Format(time.RFC3339)
+	}
+	return res
 }
 
 func (h *userHandler) Post(c echo.Context) error {
 	var reqUser reqUser
 	if err := c.Bind(&reqUser); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return err
 	}
 
-	user, err := h.userUsecase.Create(reqUser.Name, reqUser.Email, reqUser.Password)
+	user, err := h.userUsecase.Create(c.Request().Context(), reqUser.Name, reqUser.Email, reqUser.Password)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
-	}
-
-	resUser := resUser{
-		ID:        user.ID,
-		Name:      user.Username,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: user.UpdatedAt.Format(time.RFC3339),
+		return err
 	}
 
-	return c.JSON(http.StatusCreated, resUser)
+	c.Response().Header().Set(HeaderETag, etagOf(user))
+	return c.JSON(http.StatusCreated, newResUser(user))
 }
 
 func (h *userHandler) Get(c echo.Context) error {
-	id := c.Param("id")
-	user, err := h.userUsecase.FindByID(id)
+	id, err := model.ParseUserID(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
-	resUser := resUser{
-		ID:        user.ID,
-		Name:      user.Username,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: user.UpdatedAt.Format(time.RFC3339),
+	user, err := h.userUsecase.FindByID(c.Request().Context(), id.String())
+	if err != nil {
+		return err
 	}
 
-	return c.JSON(http.StatusOK, resUser)
+	c.Response().Header().Set(HeaderETag, etagOf(user))
+	return c.JSON(http.StatusOK, newResUser(user))
 }
 
 func (h *userHandler) GetAll(c echo.Context) error {
-	users, err := h.userUsecase.FindAll()
+	query, err := bindUserQuery(c)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
-	resUsers := make([]resUser, len(users))
-	for i, user := range users {
-		resUsers[i] = resUser{
-			ID:        user.ID,
-			Name:      user.Username,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt.Format(time.RFC3339),
-			UpdatedAt: user.UpdatedAt.Format(time.RFC3339),
-		}
+	page, err := h.userUsecase.FindAll(c.Request().Context(), query)
+	if err != nil {
+		return err
+	}
+
+	if page.NextCursor != "" {
+		next := *c.Request().URL
+		params := next.Query()
+		params.Set("cursor", page.NextCursor)
+		next.RawQuery = params.Encode()
+		c.Response().Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+		c.Response().Header().Set("X-Next-Cursor", page.NextCursor)
+	}
+
+	resUsers := make([]resUser, len(page.Users))
+	for i, user := range page.Users {
+		resUsers[i] = newResUser(user)
 	}
 
 	return c.JSON(http.StatusOK, resUsers)
 }
 
+// bindUserQuery クエリパラメータから一覧の取得条件を組み立てる
+func bindUserQuery(c echo.Context) (repository.UserQuery, error) {
+	query := repository.UserQuery{
+		Limit:          repository.DefaultUserLimit,
+		SortField:      repository.UserSortByCreatedAt,
+		EmailDomain:    c.QueryParam("email_domain"),
+		UsernamePrefix: c.QueryParam("username_prefix"),
+	}
+
+	if status := c.QueryParam("status"); status != "" {
+		parsed, err := model.ParseUserStatus(status)
+		if err != nil {
+			return query, err
+		}
+		query.Status = parsed
+	}
+	if includeDeleted := c.QueryParam("include_deleted"); includeDeleted != "" {
+		include, err := strconv.ParseBool(includeDeleted)
+		if err != nil {
+			return query, errors.New("include_deletedはtrueまたはfalseで指定してください")
+		}
+		query.IncludeDeleted = include
+	}
+	if limit := c.QueryParam("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > repository.MaxUserLimit {
+			return query, fmt.Errorf("limitは1以上%d以下で指定してください", repository.MaxUserLimit)
+		}
+		query.Limit = n
+	}
+	if sort := c.QueryParam("sort"); sort != "" {
+		field, err := repository.ParseUserSortField(sort)
+		if err != nil {
+			return query, err
+		}
+		query.SortField = field
+	}
+	switch c.QueryParam("order") {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return query, errors.New("orderはascまたはdescで指定してください")
+	}
+	for _, param := range []struct {
+		name   string
+		target *time.Time
+	}{
+		{"created_after", &query.CreatedAfter},
+		{"created_before", &query.CreatedBefore},
+	} {
+		if value := c.QueryParam(param.name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return query, fmt.Errorf("%sはRFC 3339形式で指定してください", param.name)
+			}
+			*param.target = t
+		}
+	}
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		decoded, err := repository.DecodeUserCursor(cursor)
+		if err != nil {
+			return query, err
+		}
+		if decoded.SortField != query.SortField {
+			return query, errors.New("カーソルと並び替え項目が一致しません")
+		}
+		query.Cursor = &decoded
+	}
+
+	return query, nil
+}
+
 func (h *userHandler) Put(c echo.Context) error {
-	id := c.Param("id")
+	id, err := model.ParseUserID(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	version, err := parseIfMatch(c)
+	if err != nil {
+		return err
+	}
 
 	var reqUser reqUser
 	if err := c.Bind(&reqUser); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return err
+	}
+
+	user, err := h.userUsecase.Update(c.Request().Context(), id.String(), version, reqUser.Name, reqUser.Email, reqUser.Password)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(HeaderETag, etagOf(user))
+	return c.JSON(http.StatusOK, newResUser(user))
+}
+
+func (h *userHandler) Patch(c echo.Context) error {
+	id, err := model.ParseUserID(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
-	user, err := h.userUsecase.Update(id, reqUser.Name, reqUser.Email, reqUser.Password)
+	version, err := parseIfMatch(c)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
 	}
 
-	resUser := resUser{
-		ID:        user.ID,
-		Name:      user.Username,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: user.UpdatedAt.Format(time.RFC3339),
+	changes, err := bindPatch(c)
+	if err != nil {
+		return err
 	}
 
-	return c.JSON(http.StatusOK, resUser)
+	user, err := h.userUsecase.Patch(c.Request().Context(), id.String(), version, changes)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(HeaderETag, etagOf(user))
+	return c.JSON(http.StatusOK, newResUser(user))
 }
 
 func (h *userHandler) Delete(c echo.Context) error {
-	id := c.Param("id")
-	err := h.userUsecase.Delete(id)
+	id, err := model.ParseUserID(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	version, err := parseIfMatch(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.userUsecase.Delete(c.Request().Context(), id.String(), version); err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "User deleted successfully"})
 }
+
+func (h *userHandler) Suspend(c echo.Context) error {
+	return h.changeStatus(c, h.userUsecase.Suspend)
+}
+
+func (h *userHandler) Reinstate(c echo.Context) error {
+	return h.changeStatus(c, h.userUsecase.Reinstate)
+}
+
+func (h *userHandler) Deactivate(c echo.Context) error {
+	return h.changeStatus(c, h.userUsecase.Deactivate)
+}
+
+func (h *userHandler) Restore(c echo.Context) error {
+	id, err := model.ParseUserID(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	version, err := parseIfMatch(c)
+	if err != nil {
+		return err
+	}
+
+	user, err := h.userUsecase.Restore(c.Request().Context(), id.String(), version)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(HeaderETag, etagOf(user))
+	return c.JSON(http.StatusOK, newResUser(user))
+}
+
+func (h *userHandler) AssignRole(c echo.Context) error {
+	id, err := model.ParseUserID(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	version, err := parseIfMatch(c)
+	if err != nil {
+		return err
+	}
+
+	var reqRole reqRole
+	if err := c.Bind(&reqRole); err != nil {
+		return err
+	}
+
+	user, err := h.userUsecase.AssignRole(c.Request().Context(), id.String(), version, reqRole.Role)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(HeaderETag, etagOf(user))
+	return c.JSON(http.StatusOK, newResUser(user))
+}
+
+// changeStatus 状態を変更するリクエストに共通する処理。理由はリクエストボディのreasonで受け取る
+func (h *userHandler) changeStatus(c echo.Context, change func(ctx context.Context, id string, version int, reason string) (*model.User, error)) error {
+	id, err := model.ParseUserID(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	version, err := parseIfMatch(c)
+	if err != nil {
+		return err
+	}
+
+	var reqStatus reqStatus
+	if err := c.Bind(&reqStatus); err != nil {
+		return err
+	}
+
+	user, err := change(c.Request().Context(), id.String(), version, reqStatus.Reason)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(HeaderETag, etagOf(user))
+	return c.JSON(http.StatusOK, newResUser(user))
+}
@@ -2,7 +2,9 @@ package handler
 
 import (
 	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -16,12 +18,17 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+const (
+	testUserID        = "0190a8e4-7c3b-7d2e-9f4a-1b2c3d4e5f60"
+	nonexistentUserID = "0190a8e4-7c3b-7d2e-9f4a-000000000000"
+)
+
 // MockUserUseCase is a mock implementation of UserUseCase
 type MockUserUseCase struct {
 	mock.Mock
 }
 
-func (m *MockUserUseCase) Create(username string, email string, password string) (*model.User, error) {
+func (m *MockUserUseCase) Create(ctx context.Context, username string, email string, password string) (*model.User, error) {
 	args := m.Called(username, email, password)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -29,7 +36,7 @@ func (m *MockUserUseCase) Create(username string, email string, password string)
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserUseCase) FindByID(id string) (*model.User, error) {
+func (m *MockUserUseCase) FindByID(ctx context.Context, id string) (*model.User, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -37,37 +44,88 @@ func (m *MockUserUseCase) FindByID(id string) (*model.User, error) {
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserUseCase) FindAll() ([]*model.User, error) {
-	args := m.Called()
-	return args.Get(0).([]*model.User), args.Error(1)
+func (m *MockUserUseCase) FindAll(ctx context.Context, query repository.UserQuery) (*repository.UserPage, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.UserPage), args.Error(1)
 }
 
-func (m *MockUserUseCase) Update(id string, username string, email string, password string) (*model.User, error) {
-	args := m.Called(id, username, email, password)
+func (m *MockUserUseCase) Update(ctx context.Context, id string, version int, username string, email string, password string) (*model.User, error) {
+	args := m.Called(id, version, username, email, password)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserUseCase) Delete(id string) error {
-	args := m.Called(id)
+func (m *MockUserUseCase) Patch(ctx context.Context, id string, version int, changes model.UserChanges) (*model.User, error) {
+	args := m.Called(id, version, changes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) Delete(ctx context.Context, id string, version int) error {
+	args := m.Called(id, version)
 	return args.Error(0)
 }
 
+func (m *MockUserUseCase) Restore(ctx context.Context, id string, version int) (*model.User, error) {
+	args := m.Called(id, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) AssignRole(ctx context.Context, id string, version int, role string) (*model.User, error) {
+	args := m.Called(id, version, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) Suspend(ctx context.Context, id string, version int, reason string) (*model.User, error) {
+	args := m.Called(id, version, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) Reinstate(ctx context.Context, id string, version int, reason string) (*model.User, error) {
+	args := m.Called(id, version, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) Deactivate(ctx context.Context, id string, version int, reason string) (*model.User, error) {
+	args := m.Called(id, version, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
 func TestUserHandler_Post(t *testing.T) {
 	t.Run("成功: ユーザーを作成できる", func(t *testing.T) {
 		mockUseCase := new(MockUserUseCase)
 		handler := NewUserHandler(mockUseCase)
 
 		now := time.Now()
-		user := &model.User{
-			ID:        "test-id",
+		user := model.ReconstructUser(model.UserSnapshot{
+			ID:        testUserID,
 			Username:  "testuser",
 			Email:     "test@example.com",
 			CreatedAt: now,
 			UpdatedAt: now,
-		}
+		})
 
 		mockUseCase.On("Create", "testuser", "test@example.com", "password123").Return(user, nil)
 
@@ -92,7 +150,7 @@ func TestUserHandler_Post(t *testing.T) {
 		var response resUser
 		err = json.Unmarshal(rec.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.Equal(t, "test-id", response.ID)
+		assert.Equal(t, testUserID, response.ID)
 		assert.Equal(t, "testuser", response.Name)
 		assert.Equal(t, "test@example.com", response.Email)
 		mockUseCase.AssertExpectations(t)
@@ -110,7 +168,8 @@ func TestUserHandler_Post(t *testing.T) {
 
 		err := handler.Post(c)
 
-		assert.NoError(t, err)
+		assert.Error(t, err)
+		HTTPErrorHandler(err, c)
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 	})
 
@@ -118,7 +177,7 @@ func TestUserHandler_Post(t *testing.T) {
 		mockUseCase := new(MockUserUseCase)
 		handler := NewUserHandler(mockUseCase)
 
-		mockUseCase.On("Create", "testuser", "test@example.com", "password123").Return(nil, errors.New("validation error"))
+		mockUseCase.On("Create", "testuser", "test@example.com", "password123").Return(nil, model.NewValidationError(model.CodeInvalidLength, "ユーザー名は3文字以上20文字以下で入力してください"))
 
 		requestBody := map[string]string{
 			"username": "testuser",
@@ -135,8 +194,9 @@ func TestUserHandler_Post(t *testing.T) {
 
 		err := handler.Post(c)
 
-		assert.NoError(t, err)
-		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Error(t, err)
+		HTTPErrorHandler(err, c)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
 		mockUseCase.AssertExpectations(t)
 	})
 }
@@ -147,32 +207,34 @@ func TestUserHandler_Get(t *testing.T) {
 		handler := NewUserHandler(mockUseCase)
 
 		now := time.Now()
-		user := &model.User{
-			ID:        "test-id",
+		user := model.ReconstructUser(model.UserSnapshot{
+			ID:        testUserID,
 			Username:  "testuser",
 			Email:     "test@example.com",
+			Version:   3,
 			CreatedAt: now,
 			UpdatedAt: now,
-		}
+		})
 
-		mockUseCase.On("FindByID", "test-id").Return(user, nil)
+		mockUseCase.On("FindByID", testUserID).Return(user, nil)
 
 		e := echo.New()
-		req := httptest.NewRequest(http.MethodGet, "/api/users/test-id", nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/users/"+testUserID, nil)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 		c.SetParamNames("id")
-		c.SetParamValues("test-id")
+		c.SetParamValues(testUserID)
 
 		err := handler.Get(c)
 
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `"3"`, rec.Header().Get(HeaderETag))
 
 		var response resUser
 		err = json.Unmarshal(rec.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.Equal(t, "test-id", response.ID)
+		assert.Equal(t, testUserID, response.ID)
 		assert.Equal(t, "testuser", response.Name)
 		assert.Equal(t, "test@example.com", response.Email)
 		mockUseCase.AssertExpectations(t)
@@ -182,23 +244,69 @@ func TestUserHandler_Get(t *testing.T) {
 		mockUseCase := new(MockUserUseCase)
 		handler := NewUserHandler(mockUseCase)
 
-		mockUseCase.On("FindByID", "nonexistent-id").Return(nil, errors.New("user not found"))
+		mockUseCase.On("FindByID", nonexistentUserID).Return(nil, model.NewNotFoundError("ユーザーが見つかりません", nil))
 
 		e := echo.New()
-		req := httptest.NewRequest(http.MethodGet, "/api/users/nonexistent-id", nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/users/"+nonexistentUserID, nil)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 		c.SetParamNames("id")
-		c.SetParamValues("nonexistent-id")
+		c.SetParamValues(nonexistentUserID)
 
 		err := handler.Get(c)
 
-		assert.NoError(t, err)
-		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Error(t, err)
+		HTTPErrorHandler(err, c)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
 		mockUseCase.AssertExpectations(t)
 	})
 }
 
+func TestUserHandler_InvalidID(t *testing.T) {
+	t.Run("失敗: 不正なIDでの取得", func(t *testing.T) {
+		mockUseCase := new(MockUserUseCase)
+		handler := NewUserHandler(mockUseCase)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/users/invalid-id", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("invalid-id")
+
+		err := handler.Get(c)
+
+		assert.Error(t, err)
+		HTTPErrorHandler(err, c)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockUseCase.AssertNotCalled(t, "FindByID", mock.Anything)
+	})
+
+	t.Run("失敗: 不正なIDでの削除", func(t *testing.T) {
+		mockUseCase := new(MockUserUseCase)
+		handler := NewUserHandler(mockUseCase)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/api/users/invalid-id", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("invalid-id")
+
+		err := handler.Delete(c)
+
+		assert.Error(t, err)
+		HTTPErrorHandler(err, c)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockUseCase.AssertNotCalled(t, "Delete", mock.Anything)
+	})
+}
+
+var defaultUserQuery = repository.UserQuery{
+	Limit:     repository.DefaultUserLimit,
+	SortField: repository.UserSortByCreatedAt,
+}
+
 func TestUserHandler_GetAll(t *testing.T) {
 	t.Run("成功: 全ユーザーを取得できる", func(t *testing.T) {
 		mockUseCase := new(MockUserUseCase)
@@ -206,23 +314,23 @@ func TestUserHandler_GetAll(t *testing.T) {
 
 		now := time.Now()
 		users := []*model.User{
-			{
+			model.ReconstructUser(model.UserSnapshot{
 				ID:        "1",
 				Username:  "user1",
 				Email:     "user1@example.com",
 				CreatedAt: now,
 				UpdatedAt: now,
-			},
-			{
+			}),
+			model.ReconstructUser(model.UserSnapshot{
 				ID:        "2",
 				Username:  "user2",
 				Email:     "user2@example.com",
 				CreatedAt: now,
 				UpdatedAt: now,
-			},
+			}),
 		}
 
-		mockUseCase.On("FindAll").Return(users, nil)
+		mockUseCase.On("FindAll", defaultUserQuery).Return(&repository.UserPage{Users: users}, nil)
 
 		e := echo.New()
 		req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
@@ -249,7 +357,7 @@ func TestUserHandler_GetAll(t *testing.T) {
 		mockUseCase := new(MockUserUseCase)
 		handler := NewUserHandler(mockUseCase)
 
-		mockUseCase.On("FindAll").Return(([]*model.User)(nil), errors.New("database error"))
+		mockUseCase.On("FindAll", defaultUserQuery).Return(nil, errors.New("database error"))
 
 		e := echo.New()
 		req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
@@ -258,27 +366,122 @@ func TestUserHandler_GetAll(t *testing.T) {
 
 		err := handler.GetAll(c)
 
-		assert.NoError(t, err)
+		assert.Error(t, err)
+		HTTPErrorHandler(err, c)
 		assert.Equal(t, http.StatusInternalServerError, rec.Code)
 		mockUseCase.AssertExpectations(t)
 	})
 }
 
+func TestUserHandler_GetAll_Query(t *testing.T) {
+	t.Run("成功: クエリパラメータを取得条件に変換できる", func(t *testing.T) {
+		mockUseCase := new(MockUserUseCase)
+		handler := NewUserHandler(mockUseCase)
+
+		cursor := repository.UserCursor{SortField: repository.UserSortByUsername, Value: "user1", ID: "1"}
+		createdAfter := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		expectedQuery := repository.UserQuery{
+			Limit:          10,
+			SortField:      repository.UserSortByUsername,
+			Descending:     true,
+			Cursor:         &cursor,
+			EmailDomain:    "example.com",
+			UsernamePrefix: "user",
+			Status:         model.UserStatusSuspended,
+			IncludeDeleted: true,
+			CreatedAfter:   createdAfter,
+		}
+		page := &repository.UserPage{Users: []*model.User{}, NextCursor: "next-cursor"}
+
+		mockUseCase.On("FindAll", expectedQuery).Return(page, nil)
+
+		e := echo.New()
+		target := "/users?limit=10&sort=username&order=desc&email_domain=example.com&username_prefix=user&status=suspended&include_deleted=true&created_after=2025-01-01T00:00:00Z&cursor=" + cursor.Encode()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler.GetAll(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "next-cursor", rec.Header().Get("X-Next-Cursor"))
+		assert.Contains(t, rec.Header().Get("Link"), "cursor=next-cursor")
+		assert.Contains(t, rec.Header().Get("Link"), "limit=10")
+		assert.Contains(t, rec.Header().Get("Link"), `rel="next"`)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("成功: 最終ページではLinkヘッダーを返さない", func(t *testing.T) {
+		mockUseCase := new(MockUserUseCase)
+		handler := NewUserHandler(mockUseCase)
+
+		mockUseCase.On("FindAll", defaultUserQuery).Return(&repository.UserPage{Users: []*model.User{}}, nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler.GetAll(c)
+
+		assert.NoError(t, err)
+		assert.Empty(t, rec.Header().Get("Link"))
+		mockUseCase.AssertExpectations(t)
+	})
+
+	cursor := repository.UserCursor{SortField: repository.UserSortByUsername, Value: "user1", ID: "1"}
+	type TestCase struct {
+		name  string
+		query string
+	}
+	testCases := []TestCase{
+		{"失敗: limitが数値でない", "limit=abc"},
+		{"失敗: limitが上限を超える", "limit=101"},
+		{"失敗: 未対応の並び替え項目", "sort=password"},
+		{"失敗: 不正なorder", "order=random"},
+		{"失敗: 不正な日時", "created_before=yesterday"},
+		{"失敗: 不正な状態", "status=banned"},
+		{"失敗: 不正なinclude_deleted", "include_deleted=maybe"},
+		{"失敗: 不正なカーソル", "cursor=invalid"},
+		{"失敗: 並び替え項目とカーソルの不一致", "sort=email&cursor=" + cursor.Encode()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUseCase := new(MockUserUseCase)
+			handler := NewUserHandler(mockUseCase)
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/users?"+tc.query, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err := handler.GetAll(c)
+
+			assert.Error(t, err)
+			HTTPErrorHandler(err, c)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			mockUseCase.AssertNotCalled(t, "FindAll", mock.Anything)
+		})
+	}
+}
+
 func TestUserHandler_Put(t *testing.T) {
 	t.Run("成功: ユーザーを更新できる", func(t *testing.T) {
 		mockUseCase := new(MockUserUseCase)
 		handler := NewUserHandler(mockUseCase)
 
 		now := time.Now()
-		user := &model.User{
-			ID:        "test-id",
+		user := model.ReconstructUser(model.UserSnapshot{
+			ID:        testUserID,
 			Username:  "updateduser",
 			Email:     "updated@example.com",
 			CreatedAt: now,
 			UpdatedAt: now,
-		}
+		})
 
-		mockUseCase.On("Update", "test-id", "updateduser", "updated@example.com", "newpassword").Return(user, nil)
+		mockUseCase.On("Update", testUserID, 0, "updateduser", "updated@example.com", "newpassword").Return(user, nil)
 
 		requestBody := map[string]string{
 			"username": "updateduser",
@@ -288,12 +491,12 @@ func TestUserHandler_Put(t *testing.T) {
 		jsonBody, _ := json.Marshal(requestBody)
 
 		e := echo.New()
-		req := httptest.NewRequest(http.MethodPut, "/api/users/test-id", bytes.NewBuffer(jsonBody))
+		req := httptest.NewRequest(http.MethodPut, "/api/users/"+testUserID, bytes.NewBuffer(jsonBody))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 		c.SetParamNames("id")
-		c.SetParamValues("test-id")
+		c.SetParamValues(testUserID)
 
 		err := handler.Put(c)
 
@@ -303,7 +506,7 @@ func TestUserHandler_Put(t *testing.T) {
 		var response resUser
 		err = json.Unmarshal(rec.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.Equal(t, "test-id", response.ID)
+		assert.Equal(t, testUserID, response.ID)
 		assert.Equal(t, "updateduser", response.Name)
 		assert.Equal(t, "updated@example.com", response.Email)
 		mockUseCase.AssertExpectations(t)
@@ -314,16 +517,17 @@ func TestUserHandler_Put(t *testing.T) {
 		handler := NewUserHandler(mockUseCase)
 
 		e := echo.New()
-		req := httptest.NewRequest(http.MethodPut, "/api/users/test-id", strings.NewReader("invalid json"))
+		req := httptest.NewRequest(http.MethodPut, "/api/users/"+testUserID, strings.NewReader("invalid json"))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 		c.SetParamNames("id")
-		c.SetParamValues("test-id")
+		c.SetParamValues(testUserID)
 
 		err := handler.Put(c)
 
-		assert.NoError(t, err)
+		assert.Error(t, err)
+		HTTPErrorHandler(err, c)
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 	})
 
@@ -331,7 +535,7 @@ func TestUserHandler_Put(t *testing.T) {
 		mockUseCase := new(MockUserUseCase)
 		handler := NewUserHandler(mockUseCase)
 
-		mockUseCase.On("Update", "test-id", "updateduser", "updated@example.com", "newpassword").Return(nil, errors.New("user not found"))
+		mockUseCase.On("Update", testUserID, 0, "updateduser", "updated@example.com", "newpassword").Return(nil, model.NewNotFoundError("ユーザーが見つかりません", nil))
 
 		requestBody := map[string]string{
 			"username": "updateduser",
@@ -341,19 +545,144 @@ func TestUserHandler_Put(t *testing.T) {
 		jsonBody, _ := json.Marshal(requestBody)
 
 		e := echo.New()
-		req := httptest.NewRequest(http.MethodPut, "/api/users/test-id", bytes.NewBuffer(jsonBody))
+		req := httptest.NewRequest(http.MethodPut, "/api/users/"+testUserID, bytes.NewBuffer(jsonBody))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 		c.SetParamNames("id")
-		c.SetParamValues("test-id")
+		c.SetParamValues(testUserID)
 
 		err := handler.Put(c)
 
+		assert.Error(t, err)
+		HTTPErrorHandler(err, c)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		mockUseCase.AssertExpectations(t)
+	})
+}
+
+func TestUserHandler_Patch(t *testing.T) {
+	email := "updated@example.com"
+	now := time.Now()
+	user := model.ReconstructUser(model.UserSnapshot{
+		ID:        testUserID,
+		Username:  "testuser",
+		Email:     email,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+
+	t.Run("成功: JSON Merge Patchで指定した項目のみ更新できる", func(t *testing.T) {
+		mockUseCase := new(MockUserUseCase)
+		handler := NewUserHandler(mockUseCase)
+
+		mockUseCase.On("Patch", testUserID, 0, model.UserChanges{Email: &email}).Return(user, nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPatch, "/api/users/"+testUserID, strings.NewReader(`{"email":"updated@example.com"}`))
+		req.Header.Set(echo.HeaderContentType, MIMEApplicationMergePatchJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(testUserID)
+
+		err := handler.Patch(c)
+
 		assert.NoError(t, err)
-		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response resUser
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, email, response.Email)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("成功: JSON Patchで指定した項目のみ更新できる", func(t *testing.T) {
+		mockUseCase := new(MockUserUseCase)
+		handler := NewUserHandler(mockUseCase)
+
+		mockUseCase.On("Patch", testUserID, 0, model.UserChanges{Email: &email}).Return(user, nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPatch, "/api/users/"+testUserID, strings.NewReader(`[{"op":"replace","path":"/email","value":"updated@example.com"}]`))
+		req.Header.Set(echo.HeaderContentType, MIMEApplicationJSONPatchJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(testUserID)
+
+		err := handler.Patch(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("成功: JSON Patchのtestは変更前の値の確認として渡す", func(t *testing.T) {
+		mockUseCase := new(MockUserUseCase)
+		handler := NewUserHandler(mockUseCase)
+
+		expected := "old@example.com"
+		mockUseCase.On("Patch", testUserID, 0, model.UserChanges{Email: &email, ExpectedEmail: &expected}).Return(user, nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPatch, "/api/users/"+testUserID, strings.NewReader(`[{"op":"test","path":"/email","value":"old@example.com"},{"op":"replace","path":"/email","value":"updated@example.com"},{"op":"test","path":"/email","value":"updated@example.com"}]`))
+		req.Header.Set(echo.HeaderContentType, MIMEApplicationJSONPatchJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(testUserID)
+
+		err := handler.Patch(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
 		mockUseCase.AssertExpectations(t)
 	})
+
+	type TestCase struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+	}
+	testCases := []TestCase{
+		{"失敗: 未対応のContent-Type", echo.MIMETextPlain, `{"email":"updated@example.com"}`, http.StatusUnsupportedMediaType},
+		{"失敗: 不正なJSON", MIMEApplicationMergePatchJSON, `{"email":`, http.StatusBadRequest},
+		{"失敗: 項目の削除", MIMEApplicationMergePatchJSON, `{"email":null}`, http.StatusUnprocessableEntity},
+		{"失敗: 存在しない項目", MIMEApplicationMergePatchJSON, `{"id":"other"}`, http.StatusUnprocessableEntity},
+		{"失敗: 文字列以外の値", MIMEApplicationMergePatchJSON, `{"email":1}`, http.StatusBadRequest},
+		{"失敗: 未対応の操作", MIMEApplicationJSONPatchJSON, `[{"op":"remove","path":"/email"}]`, http.StatusUnprocessableEntity},
+		{"失敗: valueなし", MIMEApplicationJSONPatchJSON, `[{"op":"replace","path":"/email"}]`, http.StatusBadRequest},
+		{"失敗: 変更後の値とtestの値が一致しない", MIMEApplicationJSONPatchJSON, `[{"op":"replace","path":"/email","value":"updated@example.com"},{"op":"test","path":"/email","value":"other@example.com"}]`, http.StatusConflict},
+		{"失敗: パスワードはtestできない", MIMEApplicationJSONPatchJSON, `[{"op":"test","path":"/password","value":"password123"}]`, http.StatusUnprocessableEntity},
+		{"失敗: エスケープを戻すと存在しない項目", MIMEApplicationJSONPatchJSON, `[{"op":"replace","path":"/e~1mail","value":"updated@example.com"}]`, http.StatusUnprocessableEntity},
+		{"失敗: 不正なエスケープ", MIMEApplicationJSONPatchJSON, `[{"op":"replace","path":"/email~2","value":"updated@example.com"}]`, http.StatusBadRequest},
+		{"失敗: 入れ子の参照", MIMEApplicationJSONPatchJSON, `[{"op":"replace","path":"/email/0","value":"updated@example.com"}]`, http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUseCase := new(MockUserUseCase)
+			handler := NewUserHandler(mockUseCase)
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPatch, "/api/users/"+testUserID, strings.NewReader(tc.body))
+			req.Header.Set(echo.HeaderContentType, tc.contentType)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(testUserID)
+
+			err := handler.Patch(c)
+
+			assert.Error(t, err)
+			HTTPErrorHandler(err, c)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			mockUseCase.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
 }
 
 func TestUserHandler_Delete(t *testing.T) {
@@ -361,14 +690,14 @@ func TestUserHandler_Delete(t *testing.T) {
 		mockUseCase := new(MockUserUseCase)
 		handler := NewUserHandler(mockUseCase)
 
-		mockUseCase.On("Delete", "test-id").Return(nil)
+		mockUseCase.On("Delete", testUserID, 0).Return(nil)
 
 		e := echo.New()
-		req := httptest.NewRequest(http.MethodDelete, "/api/users/test-id", nil)
+		req := httptest.NewRequest(http.MethodDelete, "/api/users/"+testUserID, nil)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 		c.SetParamNames("id")
-		c.SetParamValues("test-id")
+		c.SetParamValues(testUserID)
 
 		err := handler.Delete(c)
 
@@ -386,19 +715,208 @@ func TestUserHandler_Delete(t *testing.T) {
 		mockUseCase := new(MockUserUseCase)
 		handler := NewUserHandler(mockUseCase)
 
-		mockUseCase.On("Delete", "test-id").Return(errors.New("user not found"))
+		mockUseCase.On("Delete", testUserID, 0).Return(model.NewNotFoundError("ユーザーが見つかりません", nil))
 
 		e := echo.New()
-		req := httptest.NewRequest(http.MethodDelete, "/api/users/test-id", nil)
+		req := httptest.NewRequest(http.MethodDelete, "/api/users/"+testUserID, nil)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 		c.SetParamNames("id")
-		c.SetParamValues("test-id")
+		c.SetParamValues(testUserID)
 
 		err := handler.Delete(c)
 
+		assert.Error(t, err)
+		HTTPErrorHandler(err, c)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		mockUseCase.AssertExpectations(t)
+	})
+}
+func TestUserHandler_Suspend(t *testing.T) {
+	t.Run("成功: 理由を付けて利用停止できる", func(t *testing.T) {
+		mockUseCase := new(MockUserUseCase)
+		handler := NewUserHandler(mockUseCase)
+
+		user := model.ReconstructUser(model.UserSnapshot{ID: testUserID, Username: "testuser", Status: model.UserStatusSuspended, StatusReason: "スパム投稿", Version: 3})
+		mockUseCase.On("Suspend", testUserID, 2, "スパム投稿").Return(user, nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/user/"+testUserID+"/suspend", strings.NewReader(`{"reason":"スパム投稿"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(HeaderIfMatch, `"2"`)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(testUserID)
+
+		err := handler.Suspend(c)
+
 		assert.NoError(t, err)
-		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `"3"`, rec.Header().Get(HeaderETag))
+		var response resUser
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, "suspended", response.Status)
+		assert.Equal(t, "スパム投稿", response.StatusReason)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("失敗: 現在の状態から変更できない", func(t *testing.T) {
+		mockUseCase := new(MockUserUseCase)
+		handler := NewUserHandler(mockUseCase)
+
+		mockUseCase.On("Reinstate", testUserID, 0, "解除").Return(nil, model.NewConflictError("利用停止中のユーザーではありません", nil))
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/user/"+testUserID+"/reinstate", strings.NewReader(`{"reason":"解除"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(testUserID)
+
+		err := handler.Reinstate(c)
+
+		assert.ErrorIs(t, err, model.ErrConflict)
+	})
+}
+
+func TestUserHandler_Restore(t *testing.T) {
+	t.Run("成功: 論理削除されたユーザーを復元できる", func(t *testing.T) {
+		mockUseCase := new(MockUserUseCase)
+		handler := NewUserHandler(mockUseCase)
+
+		user := model.ReconstructUser(model.UserSnapshot{ID: testUserID, Username: "testuser", Version: 4})
+		mockUseCase.On("Restore", testUserID, 3).Return(user, nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/user/"+testUserID+"/restore", nil)
+		req.Header.Set(HeaderIfMatch, `"3"`)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(testUserID)
+
+		err := handler.Restore(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `"4"`, rec.Header().Get(HeaderETag))
+		assert.NotContains(t, rec.Body.String(), "deleted_at")
 		mockUseCase.AssertExpectations(t)
 	})
-}
\ No newline at end of file
+}
+
+func TestUserHandler_AssignRole(t *testing.T) {
+	t.Run("成功: 役割を変更できる", func(t *testing.T) {
+		mockUseCase := new(MockUserUseCase)
+		handler := NewUserHandler(mockUseCase)
+
+		user := model.ReconstructUser(model.UserSnapshot{ID: testUserID, Username: "testuser", Role: model.RoleSupport, Version: 2})
+		mockUseCase.On("AssignRole", testUserID, 0, "support").Return(user, nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/user/"+testUserID+"/role", strings.NewReader(`{"role":"support"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(testUserID)
+
+		err := handler.AssignRole(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var response resUser
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, "support", response.Role)
+		mockUseCase.AssertExpectations(t)
+	})
+}
+
+func TestUserHandler_IfMatch(t *testing.T) {
+	t.Run("成功: If-Matchのバージョンをユースケースに渡す", func(t *testing.T) {
+		mockUseCase := new(MockUserUseCase)
+		handler := NewUserHandler(mockUseCase)
+
+		mockUseCase.On("Delete", testUserID, 2).Return(nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/api/users/"+testUserID, nil)
+		req.Header.Set(HeaderIfMatch, `"2"`)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(testUserID)
+
+		err := handler.Delete(c)
+
+		assert.NoError(t, err)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("成功: 更新後のバージョンをETagで返す", func(t *testing.T) {
+		mockUseCase := new(MockUserUseCase)
+		handler := NewUserHandler(mockUseCase)
+
+		email := "updated@example.com"
+		user := model.ReconstructUser(model.UserSnapshot{ID: testUserID, Username: "testuser", Email: email, Version: 3})
+		mockUseCase.On("Patch", testUserID, 2, model.UserChanges{Email: &email}).Return(user, nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPatch, "/api/users/"+testUserID, strings.NewReader(`{"email":"updated@example.com"}`))
+		req.Header.Set(echo.HeaderContentType, MIMEApplicationMergePatchJSON)
+		req.Header.Set(HeaderIfMatch, `"2"`)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(testUserID)
+
+		err := handler.Patch(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `"3"`, rec.Header().Get(HeaderETag))
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("失敗: バージョンが一致しない", func(t *testing.T) {
+		mockUseCase := new(MockUserUseCase)
+		handler := NewUserHandler(mockUseCase)
+
+		mockUseCase.On("Delete", testUserID, 1).Return(model.NewPreconditionFailedError("ユーザーは他の操作で更新されています"))
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/api/users/"+testUserID, nil)
+		req.Header.Set(HeaderIfMatch, `"1"`)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(testUserID)
+
+		err := handler.Delete(c)
+
+		assert.Error(t, err)
+		HTTPErrorHandler(err, c)
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	})
+
+	t.Run("失敗: 弱いETagは一致しない", func(t *testing.T) {
+		mockUseCase := new(MockUserUseCase)
+		handler := NewUserHandler(mockUseCase)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/api/users/"+testUserID, nil)
+		req.Header.Set(HeaderIfMatch, `W/"1"`)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(testUserID)
+
+		err := handler.Delete(c)
+
+		assert.ErrorIs(t, err, model.ErrPreconditionFailed)
+		HTTPErrorHandler(err, c)
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		mockUseCase.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
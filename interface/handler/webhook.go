@@ -0,0 +1,317 @@
+package handler
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"api-sample-with-echo-ddd/usecase"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+type WebhookHandler interface {
+	Post(c echo.Context) error
+	Get(c echo.Context) error
+	GetAll(c echo.Context) error
+	Put(c echo.Context) error
+	Delete(c echo.Context) error
+	// GetDeliveries パスパラメータidの購読先への配信履歴を新しい順に返す
+	GetDeliveries(c echo.Context) error
+	GetDelivery(c echo.Context) error
+	Replay(c echo.Context) error
+}
+
+type webhookHandler struct {
+	webhookUsecase usecase.WebhookUseCase
+}
+
+func NewWebhookHandler(webhookUsecase usecase.WebhookUseCase) WebhookHandler {
+	return &webhookHandler{webhookUsecase: webhookUsecase}
+}
+
+type reqWebhook struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	// Active 省略した場合は配信の状態を変えない
+	Active *bool `json:"active"`
+}
+
+type resWebhook struct {
+	ID                  string   `json:"id"`
+	URL                 string   `json:"url"`
+	Events              []string `json:"events"`
+	Description         string   `json:"description"`
+	Active              bool     `json:"active"`
+	ConsecutiveFailures int      `json:"consecutive_failures"`
+	DisabledAt          string   `json:"disabled_at,omitempty"`
+	// Secret 作成時のみ返す
+	Secret    string `json:"secret,omitempty"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+func newResWebhook(subscription *model.WebhookSubscription) resWebhook {
+	res := resWebhook{
+		ID:                  subscription.ID,
+		URL:                 subscription.URL,
+		Events:              subscription.EventTypes,
+		Description:         subscription.Description,
+		Active:              subscription.Active,
+		ConsecutiveFailures: subscription.ConsecutiveFailures,
+		CreatedAt:           subscription.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:           subscription.UpdatedAt.Format(time.RFC3339),
+	}
+	if subscription.DisabledAt != nil {
+		res.DisabledAt = subscription.DisabledAt.Format(time.RFC3339)
+	}
+	return res
+}
+
+type resWebhookDelivery struct {
+	ID             string `json:"id"`
+	SubscriptionID string `json:"subscription_id"`
+	EventID        string `json:"event_id"`
+	Event          string `json:"event"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	NextAttemptAt  string `json:"next_attempt_at,omitempty"`
+	LastStatusCode int    `json:"last_status_code,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+	DeliveredAt    string `json:"delivered_at,omitempty"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+}
+
+func newResWebhookDelivery(delivery *model.WebhookDelivery) resWebhookDelivery {
+	res := resWebhookDelivery{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		Event:          delivery.EventType,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt.Format(time.RFC3339Nano),
+		UpdatedAt:      delivery.UpdatedAt.Format(time.RFC3339Nano),
+	}
+	if delivery.Status == model.WebhookDeliveryPending {
+		res.NextAttemptAt = delivery.NextAttemptAt.Format(time.RFC3339Nano)
+	}
+	if delivery.DeliveredAt != nil {
+		res.DeliveredAt = delivery.DeliveredAt.Format(time.RFC3339Nano)
+	}
+	return res
+}
+
+type resWebhookDeliveryAttempt struct {
+	ID          string `json:"id"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	Error       string `json:"error,omitempty"`
+	DurationMs  int64  `json:"duration_ms"`
+	AttemptedAt string `json:"attempted_at"`
+}
+
+// resWebhookDeliveryDetail 配信の詳細。送信する本文と、配信を試みた記録を含める
+type resWebhookDeliveryDetail struct {
+	resWebhookDelivery
+	Payload        json.RawMessage             `json:"payload"`
+	AttemptHistory []resWebhookDeliveryAttempt `json:"attempt_history"`
+}
+
+func (h *webhookHandler) Post(c echo.Context) error {
+	var reqWebhook reqWebhook
+	if err := c.Bind(&reqWebhook); err != nil {
+		return err
+	}
+
+	subscription, err := h.webhookUsecase.Create(c.Request().Context(), reqWebhook.URL, reqWebhook.Events, reqWebhook.Description)
+	if err != nil {
+		return err
+	}
+
+	res := newResWebhook(subscription)
+	res.Secret = subscription.Secret
+	return c.JSON(http.StatusCreated, res)
+}
+
+func (h *webhookHandler) Get(c echo.Context) error {
+	id, err := model.ParseWebhookID(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	subscription, err := h.webhookUsecase.FindByID(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newResWebhook(subscription))
+}
+
+func (h *webhookHandler) GetAll(c echo.Context) error {
+	subscriptions, err := h.webhookUsecase.FindAll(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	resWebhooks := make([]resWebhook, len(subscriptions))
+	for i, subscription := range subscriptions {
+		resWebhooks[i] = newResWebhook(subscription)
+	}
+	return c.JSON(http.StatusOK, resWebhooks)
+}
+
+func (h *webhookHandler) Put(c echo.Context) error {
+	id, err := model.ParseWebhookID(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	var reqWebhook reqWebhook
+	if err := c.Bind(&reqWebhook); err != nil {
+		return err
+	}
+
+	subscription, err := h.webhookUsecase.Update(c.Request().Context(), id, reqWebhook.URL, reqWebhook.Events, reqWebhook.Description, reqWebhook.Active)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newResWebhook(subscription))
+}
+
+func (h *webhookHandler) Delete(c echo.Context) error {
+	id, err := model.ParseWebhookID(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	if err := h.webhookUsecase.Delete(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Webhook deleted successfully"})
+}
+
+func (h *webhookHandler) GetDeliveries(c echo.Context) error {
+	id, err := model.ParseWebhookID(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	query, err := bindWebhookDeliveryQuery(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	query.SubscriptionID = id
+
+	page, err := h.webhookUsecase.FindDeliveries(c.Request().Context(), query)
+	if err != nil {
+		return err
+	}
+
+	if page.NextCursor != "" {
+		next := *c.Request().URL
+		params := next.Query()
+		params.Set("cursor", page.NextCursor)
+		next.RawQuery = params.Encode()
+		c.Response().Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+		c.Response().Header().Set("X-Next-Cursor", page.NextCursor)
+	}
+
+	resDeliveries := make([]resWebhookDelivery, len(page.Deliveries))
+	for i, delivery := range page.Deliveries {
+		resDeliveries[i] = newResWebhookDelivery(delivery)
+	}
+	return c.JSON(http.StatusOK, resDeliveries)
+}
+
+func (h *webhookHandler) GetDelivery(c echo.Context) error {
+	id, deliveryID, err := parseWebhookDeliveryParams(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	delivery, attempts, err := h.webhookUsecase.FindDelivery(c.Request().Context(), id, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	res := resWebhookDeliveryDetail{
+		resWebhookDelivery: newResWebhookDelivery(delivery),
+		Payload:            delivery.Payload,
+		AttemptHistory:     make([]resWebhookDeliveryAttempt, len(attempts)),
+	}
+	for i, attempt := range attempts {
+		res.AttemptHistory[i] = resWebhookDeliveryAttempt{
+			ID:          attempt.ID,
+			StatusCode:  attempt.StatusCode,
+			Error:       attempt.Error,
+			DurationMs:  attempt.Duration.Milliseconds(),
+			AttemptedAt: attempt.AttemptedAt.Format(time.RFC3339Nano),
+		}
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// Replay 送り直す配信を受け付けたことを返す。送信は配信待ちの配信と同様に行う
+func (h *webhookHandler) Replay(c echo.Context) error {
+	id, deliveryID, err := parseWebhookDeliveryParams(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	delivery, err := h.webhookUsecase.Replay(c.Request().Context(), id, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, newResWebhookDelivery(delivery))
+}
+
+func parseWebhookDeliveryParams(c echo.Context) (string, string, error) {
+	id, err := model.ParseWebhookID(c.Param("id"))
+	if err != nil {
+		return "", "", err
+	}
+	deliveryID, err := model.ParseWebhookID(c.Param("delivery_id"))
+	if err != nil {
+		return "", "", err
+	}
+	return id, deliveryID, nil
+}
+
+// bindWebhookDeliveryQuery クエリパラメータから配信履歴の取得条件を組み立てる
+func bindWebhookDeliveryQuery(c echo.Context) (repository.WebhookDeliveryQuery, error) {
+	query := repository.WebhookDeliveryQuery{Limit: repository.DefaultWebhookDeliveryLimit}
+
+	if status := c.QueryParam("status"); status != "" {
+		parsed, err := model.ParseWebhookDeliveryStatus(status)
+		if err != nil {
+			return query, err
+		}
+		query.Status = parsed
+	}
+	query.EventType = c.QueryParam("event")
+	if limit := c.QueryParam("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > repository.MaxWebhookDeliveryLimit {
+			return query, fmt.Errorf("limitは1以上%d以下で指定してください", repository.MaxWebhookDeliveryLimit)
+		}
+		query.Limit = n
+	}
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		decoded, err := repository.DecodeWebhookDeliveryCursor(cursor)
+		if err != nil {
+			return query, err
+		}
+		query.Cursor = &decoded
+	}
+
+	return query, nil
+}
@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWebhookUseCase is a mock implementation of WebhookUseCase
+type MockWebhookUseCase struct {
+	mock.Mock
+}
+
+func (m *MockWebhookUseCase) Create(ctx context.Context, url string, eventTypes []string, description string) (*model.WebhookSubscription, error) {
+	args := m.Called(url, eventTypes, description)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) FindByID(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) FindAll(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) Update(ctx context.Context, id string, url string, eventTypes []string, description string, active *bool) (*model.WebhookSubscription, error) {
+	args := m.Called(id, url, eventTypes, description, active)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) Delete(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookUseCase) FindDeliveries(ctx context.Context, query repository.WebhookDeliveryQuery) (*repository.WebhookDeliveryPage, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.WebhookDeliveryPage), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) FindDelivery(ctx context.Context, subscriptionID string, deliveryID string) (*model.WebhookDelivery, []*model.WebhookDeliveryAttempt, error) {
+	args := m.Called(subscriptionID, deliveryID)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*model.WebhookDelivery), args.Get(1).([]*model.WebhookDeliveryAttempt), args.Error(2)
+}
+
+func (m *MockWebhookUseCase) Replay(ctx context.Context, subscriptionID string, deliveryID string) (*model.WebhookDelivery, error) {
+	args := m.Called(subscriptionID, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebhookDelivery), args.Error(1)
+}
+
+const (
+	webhookID         = "0190a8b0-0000-7000-8000-000000000010"
+	webhookDeliveryID = "0190a8b0-0000-7000-8000-000000000020"
+)
+
+func testWebhookSubscription() *model.WebhookSubscription {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &model.WebhookSubscription{
+		ID:         webhookID,
+		URL:        "https://example.com/hooks",
+		EventTypes: []string{model.EventTypeUserRegistered},
+		Secret:     "whsec_secret",
+		Active:     true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+func TestWebhookHandler_Post(t *testing.T) {
+	t.Run("成功: 作成時のみ秘密鍵を返す", func(t *testing.T) {
+		mockUseCase := new(MockWebhookUseCase)
+		handler := NewWebhookHandler(mockUseCase)
+		mockUseCase.On("Create", "https://example.com/hooks", []string{model.EventTypeUserRegistered}, "partner").Return(testWebhookSubscription(), nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{"url":"https://example.com/hooks","events":["user.registered"],"description":"partner"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := handler.Post(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"secret":"whsec_secret"`)
+		assert.Contains(t, rec.Body.String(), `"events":["user.registered"]`)
+		mockUseCase.AssertExpectations(t)
+	})
+}
+
+func TestWebhookHandler_Get(t *testing.T) {
+	t.Run("成功: 秘密鍵は返さない", func(t *testing.T) {
+		mockUseCase := new(MockWebhookUseCase)
+		handler := NewWebhookHandler(mockUseCase)
+		mockUseCase.On("FindByID", webhookID).Return(testWebhookSubscription(), nil)
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/webhooks/"+webhookID, nil), rec)
+		c.SetParamNames("id")
+		c.SetParamValues(webhookID)
+
+		err := handler.Get(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "secret")
+	})
+
+	t.Run("失敗: 不正なIDは400", func(t *testing.T) {
+		mockUseCase := new(MockWebhookUseCase)
+		handler := NewWebhookHandler(mockUseCase)
+
+		e := echo.New()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/webhooks/invalid", nil), httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("invalid")
+
+		err := handler.Get(c)
+
+		he, ok := err.(*echo.HTTPError)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusBadRequest, he.Code)
+		mockUseCase.AssertNotCalled(t, "FindByID", mock.Anything)
+	})
+}
+
+func TestWebhookHandler_Put(t *testing.T) {
+	t.Run("成功: activeを指定して配信を再開する", func(t *testing.T) {
+		mockUseCase := new(MockWebhookUseCase)
+		handler := NewWebhookHandler(mockUseCase)
+		mockUseCase.On("Update", webhookID, "https://example.com/hooks", []string{model.EventTypeUserRegistered}, "", mock.MatchedBy(func(active *bool) bool {
+			return active != nil && *active
+		})).Return(testWebhookSubscription(), nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/webhooks/"+webhookID, strings.NewReader(`{"url":"https://example.com/hooks","events":["user.registered"],"active":true}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(webhookID)
+
+		err := handler.Put(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		mockUseCase.AssertExpectations(t)
+	})
+}
+
+func TestWebhookHandler_GetDeliveries(t *testing.T) {
+	t.Run("成功: 条件を指定して配信履歴を取得できる", func(t *testing.T) {
+		mockUseCase := new(MockWebhookUseCase)
+		handler := NewWebhookHandler(mockUseCase)
+		page := &repository.WebhookDeliveryPage{
+			Deliveries: []*model.WebhookDelivery{{
+				ID:             webhookDeliveryID,
+				SubscriptionID: webhookID,
+				EventID:        "event-1",
+				EventType:      model.EventTypeUserRegistered,
+				Status:         model.WebhookDeliveryFailed,
+				Attempts:       8,
+				LastStatusCode: 500,
+				LastError:      "unexpected status 500",
+			}},
+			NextCursor: "next",
+		}
+		mockUseCase.On("FindDeliveries", repository.WebhookDeliveryQuery{SubscriptionID: webhookID, Status: model.WebhookDeliveryFailed, Limit: 10}).Return(page, nil)
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/webhooks/"+webhookID+"/deliveries?status=failed&limit=10", nil), rec)
+		c.SetParamNames("id")
+		c.SetParamValues(webhookID)
+
+		err := handler.GetDeliveries(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "next", rec.Header().Get("X-Next-Cursor"))
+		assert.Contains(t, rec.Body.String(), `"status":"failed"`)
+		assert.Contains(t, rec.Body.String(), `"last_status_code":500`)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("失敗: 不正な状態は400", func(t *testing.T) {
+		mockUseCase := new(MockWebhookUseCase)
+		handler := NewWebhookHandler(mockUseCase)
+
+		e := echo.New()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/webhooks/"+webhookID+"/deliveries?status=unknown", nil), httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues(webhookID)
+
+		err := handler.GetDeliveries(c)
+
+		he, ok := err.(*echo.HTTPError)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusBadRequest, he.Code)
+		mockUseCase.AssertNotCalled(t, "FindDeliveries", mock.Anything)
+	})
+}
+
+func TestWebhookHandler_GetDelivery(t *testing.T) {
+	t.Run("成功: 本文と試みた記録を返す", func(t *testing.T) {
+		mockUseCase := new(MockWebhookUseCase)
+		handler := NewWebhookHandler(mockUseCase)
+		delivery := &model.WebhookDelivery{ID: webhookDeliveryID, SubscriptionID: webhookID, Status: model.WebhookDeliverySucceeded, Payload: []byte(`{"id":"event-1"}`)}
+		attempts := []*model.WebhookDeliveryAttempt{{ID: "attempt-1", StatusCode: 200, Duration: 120 * time.Millisecond}}
+		mockUseCase.On("FindDelivery", webhookID, webhookDeliveryID).Return(delivery, attempts, nil)
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/webhooks/"+webhookID+"/deliveries/"+webhookDeliveryID, nil), rec)
+		c.SetParamNames("id", "delivery_id")
+		c.SetParamValues(webhookID, webhookDeliveryID)
+
+		err := handler.GetDelivery(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"payload":{"id":"event-1"}`)
+		assert.Contains(t, rec.Body.String(), `"attempt_history":[{"id":"attempt-1","status_code":200,"duration_ms":120`)
+	})
+}
+
+func TestWebhookHandler_Replay(t *testing.T) {
+	t.Run("成功: 送り直す配信を受け付ける", func(t *testing.T) {
+		mockUseCase := new(MockWebhookUseCase)
+		handler := NewWebhookHandler(mockUseCase)
+		delivery := &model.WebhookDelivery{ID: webhookDeliveryID, SubscriptionID: webhookID, Status: model.WebhookDeliveryPending}
+		mockUseCase.On("Replay", webhookID, webhookDeliveryID).Return(delivery, nil)
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodPost, "/webhooks/"+webhookID+"/deliveries/"+webhookDeliveryID+"/replay", nil), rec)
+		c.SetParamNames("id", "delivery_id")
+		c.SetParamValues(webhookID, webhookDeliveryID)
+
+		err := handler.Replay(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"status":"pending"`)
+	})
+
+	t.Run("失敗: 配信待ちの配信は409", func(t *testing.T) {
+		mockUseCase := new(MockWebhookUseCase)
+		handler := NewWebhookHandler(mockUseCase)
+		mockUseCase.On("Replay", webhookID, webhookDeliveryID).Return(nil, model.NewConflictError("配信待ちの配信は再送できません", nil))
+
+		e := echo.New()
+		c := e.NewContext(httptest.NewRequest(http.MethodPost, "/webhooks/"+webhookID+"/deliveries/"+webhookDeliveryID+"/replay", nil), httptest.NewRecorder())
+		c.SetParamNames("id", "delivery_id")
+		c.SetParamValues(webhookID, webhookDeliveryID)
+
+		err := handler.Replay(c)
+
+		assert.ErrorIs(t, err, model.ErrConflict)
+	})
+}
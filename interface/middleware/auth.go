@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/usecase"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+const claimsKey = "claims"
+
+// Authenticate Authorizationヘッダーのアクセストークンを検証し、利用者の情報をコンテキストに格納する。
+// 利用停止中や無効化されたアカウントのアクセストークンは拒否する。ロールはトークンに含まれる発行時点のものではなく、
+// 現在のユーザーのものを使い、降格された利用者が有効期限まで以前の権限で操作できないようにする
+func Authenticate(verifier usecase.TokenVerifier, statuses usecase.AccountStatusChecker) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			auth := c.Request().Header.Get(echo.HeaderAuthorization)
+			token := strings.TrimPrefix(auth, "Bearer ")
+			if auth == "" || token == auth {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				return model.NewUnauthorizedError("認証が必要です")
+			}
+
+			claims, err := verifier.Verify(token)
+			if err != nil {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+				return err
+			}
+			user, err := statuses.CheckStatus(c.Request().Context(), claims.UserID)
+			if err != nil {
+				return err
+			}
+
+			actor := &usecase.Claims{UserID: user.ID(), Role: user.Role(), ExpiresAt: claims.ExpiresAt}
+			c.Set(claimsKey, actor)
+			c.SetRequest(c.Request().WithContext(usecase.WithActor(c.Request().Context(), actor)))
+			return next(c)
+		}
+	}
+}
+
+// ClaimsFrom Authenticateが格納した利用者の情報を返す。未認証の場合はnil
+func ClaimsFrom(c echo.Context) *usecase.Claims {
+	claims, _ := c.Get(claimsKey).(*usecase.Claims)
+	return claims
+}
+
+// Authorize 利用者がパスパラメータparamのユーザーに対してpermissionの操作を行えなければ拒否する。
+// 特定のユーザーを対象としないルートではparamに空文字を渡す。Authenticateの後に使う
+func Authorize(policy usecase.AccessPolicy, permission model.Permission, param string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var targetID string
+			if param != "" {
+				targetID = c.Param(param)
+			}
+			if err := policy.Authorize(c.Request().Context(), permission, targetID); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/usecase"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubVerifier struct {
+	claims map[string]*usecase.Claims
+}
+
+func (v stubVerifier) Verify(token string) (*usecase.Claims, error) {
+	claims, ok := v.claims[token]
+	if !ok {
+		return nil, model.NewUnauthorizedError("アクセストークンが不正です")
+	}
+	return claims, nil
+}
+
+// stubStatusChecker blockedに含まれるユーザーを利用停止中として扱い、それ以外はrolesのロールを持つユーザーを返す
+type stubStatusChecker struct {
+	blocked map[string]bool
+	roles   map[string]model.Role
+}
+
+func (s stubStatusChecker) CheckStatus(ctx context.Context, userID string) (*model.User, error) {
+	if s.blocked[userID] {
+		return nil, model.NewForbiddenError("アカウントは利用停止中です")
+	}
+	return model.ReconstructUser(model.UserSnapshot{ID: userID, Role: s.roles[userID]}), nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	verifier := stubVerifier{claims: map[string]*usecase.Claims{
+		"member-token":    {UserID: "member-id", Role: model.RoleMember},
+		"admin-token":     {UserID: "admin-id", Role: model.RoleAdmin},
+		"suspended-token": {UserID: "suspended-id", Role: model.RoleMember},
+	}}
+	statuses := stubStatusChecker{
+		blocked: map[string]bool{"suspended-id": true},
+		roles:   map[string]model.Role{"member-id": model.RoleMember, "admin-id": model.RoleAdmin},
+	}
+	policy := usecase.NewAccessPolicy(&stubDenialRecorder{})
+	handler := Authenticate(verifier, statuses)(Authorize(policy, model.PermissionReadUsers, "id")(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}))
+
+	type TestCase struct {
+		name          string
+		authorization string
+		id            string
+		expectedError error
+	}
+	testCases := []TestCase{
+		{"成功: 本人", "Bearer member-token", "member-id", nil},
+		{"成功: 管理者は他のユーザーにアクセスできる", "Bearer admin-token", "member-id", nil},
+		{"失敗: ヘッダーなし", "", "member-id", model.ErrUnauthorized},
+		{"失敗: Bearer以外", "Basic member-token", "member-id", model.ErrUnauthorized},
+		{"失敗: 不正なトークン", "Bearer invalid-token", "member-id", model.ErrUnauthorized},
+		{"失敗: 他のユーザー", "Bearer member-token", "admin-id", model.ErrForbidden},
+		{"失敗: 利用停止中のユーザー", "Bearer suspended-token", "suspended-id", model.ErrForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/user/"+tc.id, nil)
+			if tc.authorization != "" {
+				req.Header.Set(echo.HeaderAuthorization, tc.authorization)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tc.id)
+
+			err := handler(c)
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, rec.Code)
+				return
+			}
+			assert.ErrorIs(t, err, tc.expectedError)
+			if tc.expectedError == model.ErrUnauthorized {
+				assert.NotEmpty(t, rec.Header().Get(echo.HeaderWWWAuthenticate))
+			}
+		})
+	}
+}
+
+func TestAuthenticate_Actor(t *testing.T) {
+	t.Run("成功: 利用者の情報をコンテキストに格納する", func(t *testing.T) {
+		// Arrange
+		expiresAt := time.Now().Add(time.Minute)
+		verifier := stubVerifier{claims: map[string]*usecase.Claims{"member-token": {UserID: "member-id", Role: model.RoleMember, ExpiresAt: expiresAt}}}
+		statuses := stubStatusChecker{roles: map[string]model.Role{"member-id": model.RoleMember}}
+		var actor *usecase.Claims
+		handler := Authenticate(verifier, statuses)(func(c echo.Context) error {
+			actor = usecase.ActorFrom(c.Request().Context())
+			return c.NoContent(http.StatusOK)
+		})
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer member-token")
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		// Act
+		err := handler(c)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, &usecase.Claims{UserID: "member-id", Role: model.RoleMember, ExpiresAt: expiresAt}, actor)
+		assert.Equal(t, actor, ClaimsFrom(c))
+	})
+
+	t.Run("成功: トークンの発行後に降格された場合は現在のロールで認可する", func(t *testing.T) {
+		// Arrange
+		verifier := stubVerifier{claims: map[string]*usecase.Claims{"admin-token": {UserID: "demoted-id", Role: model.RoleAdmin}}}
+		statuses := stubStatusChecker{roles: map[string]model.Role{"demoted-id": model.RoleMember}}
+		policy := usecase.NewAccessPolicy(&stubDenialRecorder{})
+		handler := Authenticate(verifier, statuses)(Authorize(policy, model.PermissionReadUsers, "id")(func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		}))
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/user/member-id", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer admin-token")
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("member-id")
+
+		// Act
+		err := handler(c)
+
+		// Assert
+		assert.ErrorIs(t, err, model.ErrForbidden)
+	})
+}
+
+// stubDenialRecorder 記録された拒否を保持する
+type stubDenialRecorder struct {
+	denials []usecase.AccessDenial
+}
+
+func (r *stubDenialRecorder) RecordDenial(ctx context.Context, denial usecase.AccessDenial) error {
+	r.denials = append(r.denials, denial)
+	return nil
+}
+
+func TestAuthorize(t *testing.T) {
+	type TestCase struct {
+		name          string
+		role          model.Role
+		permission    model.Permission
+		param         string
+		expectedError error
+	}
+	testCases := []TestCase{
+		{"成功: 管理者は役割を変更できる", model.RoleAdmin, model.PermissionAssignRoles, "id", nil},
+		{"成功: サポートは一覧を取得できる", model.RoleSupport, model.PermissionReadUsers, "", nil},
+		{"失敗: メンバーは一覧を取得できない", model.RoleMember, model.PermissionReadUsers, "", model.ErrForbidden},
+		{"失敗: サポートは役割を変更できない", model.RoleSupport, model.PermissionAssignRoles, "id", model.ErrForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := &stubDenialRecorder{}
+			verifier := stubVerifier{claims: map[string]*usecase.Claims{"token": {UserID: "actor-id", Role: tc.role}}}
+			statuses := stubStatusChecker{roles: map[string]model.Role{"actor-id": tc.role}}
+			handler := Authenticate(verifier, statuses)(Authorize(usecase.NewAccessPolicy(recorder), tc.permission, tc.param)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}))
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPut, "/user/target-id/role", nil)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer token")
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("target-id")
+
+			err := handler(c)
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Empty(t, recorder.denials)
+				return
+			}
+			assert.ErrorIs(t, err, tc.expectedError)
+			if assert.Len(t, recorder.denials, 1) {
+				assert.Equal(t, tc.permission, recorder.denials[0].Permission)
+				if tc.param != "" {
+					assert.Equal(t, "target-id", recorder.denials[0].TargetID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"api-sample-with-echo-ddd/usecase"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// ClientIP リクエスト元のIPアドレスをリクエストのコンテキストに格納する。
+// 通常は接続元のアドレスを使い、接続元がtrustedProxiesに含まれる場合のみX-Forwarded-ForとX-Real-IPを参照する
+func ClientIP(trustedProxies []*net.IPNet) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ip, _, err := net.SplitHostPort(req.RemoteAddr)
+			if err != nil {
+				ip = req.RemoteAddr
+			}
+			if isTrusted(trustedProxies, ip) {
+				ip = forwardedIP(req.Header, trustedProxies, ip)
+			}
+
+			c.SetRequest(req.WithContext(usecase.WithClientIP(req.Context(), ip)))
+			return next(c)
+		}
+	}
+}
+
+// forwardedIP X-Forwarded-Forを右から辿り、信頼できるプロキシ以外で最初に現れたアドレスを返す。
+// 左側の値はクライアントが自由に書けるため、信頼できるプロキシが付け足した範囲のみを使う。
+// X-Forwarded-Forが無い場合はX-Real-IPを使い、いずれも解釈できなければpeerを返す
+func forwardedIP(header http.Header, trustedProxies []*net.IPNet, peer string) string {
+	forwarded := strings.Join(header.Values(echo.HeaderXForwardedFor), ",")
+	if forwarded == "" {
+		if ip := strings.TrimSpace(header.Get(echo.HeaderXRealIP)); net.ParseIP(ip) != nil {
+			return ip
+		}
+		return peer
+	}
+
+	addresses := strings.Split(forwarded, ",")
+	for i := len(addresses) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(addresses[i])
+		if net.ParseIP(ip) == nil {
+			return peer
+		}
+		if !isTrusted(trustedProxies, ip) {
+			return ip
+		}
+		peer = ip
+	}
+	return peer
+}
+
+func isTrusted(trustedProxies []*net.IPNet, ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"api-sample-with-echo-ddd/usecase"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	type TestCase struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		expected   string
+	}
+	testCases := []TestCase{
+		{"成功: 信頼できるプロキシからはX-Forwarded-Forのアドレスを使う", "10.0.0.1:1234", "203.0.113.1", "", "203.0.113.1"},
+		{"成功: 信頼できるプロキシが付け足した範囲のみを使う", "10.0.0.1:1234", "198.51.100.1, 203.0.113.1, 10.0.0.2", "", "203.0.113.1"},
+		{"成功: 信頼できるプロキシからはX-Real-IPも使う", "10.0.0.1:1234", "", "203.0.113.1", "203.0.113.1"},
+		{"成功: 信頼できないプロキシからのヘッダーは無視する", "192.0.2.1:1234", "203.0.113.1", "203.0.113.2", "192.0.2.1"},
+		{"成功: ヘッダーが無い場合は接続元のアドレスを使う", "10.0.0.1:1234", "", "", "10.0.0.1"},
+		{"成功: IPアドレスでない場合は接続元のアドレスを使う", "10.0.0.1:1234", "<script>", "", "10.0.0.1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			var ip string
+			handler := ClientIP([]*net.IPNet{proxies})(func(c echo.Context) error {
+				ip = usecase.ClientIPFrom(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			})
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.forwarded != "" {
+				req.Header.Set(echo.HeaderXForwardedFor, tc.forwarded)
+			}
+			if tc.realIP != "" {
+				req.Header.Set(echo.HeaderXRealIP, tc.realIP)
+			}
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			// Act
+			err := handler(c)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, ip)
+		})
+	}
+}
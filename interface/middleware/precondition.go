@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+)
+
+// RequireIfMatch If-Matchヘッダーの無い更新リクエストを拒否する
+func RequireIfMatch() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Request().Header.Get("If-Match") == "" {
+				return echo.NewHTTPError(http.StatusPreconditionRequired, "If-Matchヘッダーが必要です")
+			}
+			return next(c)
+		}
+	}
+}
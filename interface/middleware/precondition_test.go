@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireIfMatch(t *testing.T) {
+	handler := RequireIfMatch()(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	t.Run("成功: If-Matchヘッダーがある", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/", nil)
+		req.Header.Set("If-Match", `"1"`)
+		rec := httptest.NewRecorder()
+
+		err := handler(e.NewContext(req, rec))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("失敗: If-Matchヘッダーが無い", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/", nil)
+
+		err := handler(e.NewContext(req, httptest.NewRecorder()))
+
+		var he *echo.HTTPError
+		assert.ErrorAs(t, err, &he)
+		assert.Equal(t, http.StatusPreconditionRequired, he.Code)
+	})
+}
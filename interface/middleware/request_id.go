@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"api-sample-with-echo-ddd/usecase"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/labstack/echo"
+)
+
+const maxRequestIDLength = 128
+
+// RequestID X-Request-IDヘッダーのリクエストIDを引き継ぎ、無い場合は採番してリクエストのコンテキストに格納する
+func RequestID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			requestID := req.Header.Get(echo.HeaderXRequestID)
+			if !isValidRequestID(requestID) {
+				requestID = newRequestID()
+			}
+
+			c.SetRequest(req.WithContext(usecase.WithRequestID(req.Context(), requestID)))
+			c.Response().Header().Set(echo.HeaderXRequestID, requestID)
+			return next(c)
+		}
+	}
+}
+
+// isValidRequestID ログやヘッダーに埋め込めない値は引き継がない
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < 0x21 || requestID[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"api-sample-with-echo-ddd/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	type TestCase struct {
+		name      string
+		requestID string
+		inherited bool
+	}
+	testCases := []TestCase{
+		{"成功: ヘッダーのリクエストIDを引き継ぐ", "abc-123", true},
+		{"成功: ヘッダーが無い場合は採番する", "", false},
+		{"成功: 空白を含む場合は採番する", "abc 123", false},
+		{"成功: 長すぎる場合は採番する", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			var requestID string
+			handler := RequestID()(func(c echo.Context) error {
+				requestID = usecase.RequestIDFrom(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			})
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.requestID != "" {
+				req.Header.Set(echo.HeaderXRequestID, tc.requestID)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			// Act
+			err := handler(c)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.NotEmpty(t, requestID)
+			assert.Equal(t, requestID, rec.Header().Get(echo.HeaderXRequestID))
+			if tc.inherited {
+				assert.Equal(t, tc.requestID, requestID)
+			} else {
+				assert.NotEqual(t, tc.requestID, requestID)
+			}
+		})
+	}
+}
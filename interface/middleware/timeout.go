@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// RouteTimeouts ルートごとのタイムアウト設定。キーは"METHOD /path"の形式
+type RouteTimeouts struct {
+	Default time.Duration
+	Routes  map[string]time.Duration
+}
+
+// For ルートのタイムアウトを返す。個別の設定が無い場合はDefault
+func (t RouteTimeouts) For(method string, path string) time.Duration {
+	if d, ok := t.Routes[strings.ToUpper(method)+" "+path]; ok {
+		return d
+	}
+	return t.Default
+}
+
+// Timeout リクエストのコンテキストに期限を設定する。0以下の場合は期限を設けない
+func Timeout(d time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if d <= 0 {
+			return next
+		}
+		return func(c echo.Context) error {
+			ctx, cancel := context.WithTimeout(c.Request().Context(), d)
+			defer cancel()
+
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
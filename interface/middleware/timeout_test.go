@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout(t *testing.T) {
+	t.Run("成功: リクエストのコンテキストに期限が設定される", func(t *testing.T) {
+		// Arrange
+		var deadline time.Time
+		var ok bool
+		handler := Timeout(time.Second)(func(c echo.Context) error {
+			deadline, ok = c.Request().Context().Deadline()
+			return c.NoContent(http.StatusOK)
+		})
+		e := echo.New()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+
+		// Act
+		err := handler(c)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Second), deadline, time.Second)
+	})
+
+	t.Run("成功: 0の場合は期限を設定しない", func(t *testing.T) {
+		// Arrange
+		var ok bool
+		handler := Timeout(0)(func(c echo.Context) error {
+			_, ok = c.Request().Context().Deadline()
+			return c.NoContent(http.StatusOK)
+		})
+		e := echo.New()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+
+		// Act
+		err := handler(c)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestRouteTimeouts_For(t *testing.T) {
+	// Arrange
+	timeouts := RouteTimeouts{
+		Default: 10 * time.Second,
+		Routes:  map[string]time.Duration{"POST /auth/login": 3 * time.Second},
+	}
+
+	// Act & Assert
+	assert.Equal(t, 3*time.Second, timeouts.For(http.MethodPost, "/auth/login"))
+	assert.Equal(t, 10*time.Second, timeouts.For(http.MethodGet, "/users"))
+}
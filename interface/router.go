@@ -1,16 +1,69 @@
 package router
 
 import (
+	"api-sample-with-echo-ddd/domain/model"
 	"api-sample-with-echo-ddd/interface/handler"
+	"api-sample-with-echo-ddd/interface/middleware"
+	"api-sample-with-echo-ddd/usecase"
+	"net"
 
 	"github.com/labstack/echo"
 )
 
 // InitRouting routesの初期化
-func InitRouting(e *echo.Echo, userHandler handler.UserHandler) {
-	e.POST("/user", userHandler.Post)
-	e.GET("/user/:id", userHandler.Get)
-	e.GET("/users", userHandler.GetAll)
-	e.PUT("/user/:id", userHandler.Put)
-	e.DELETE("/user/:id", userHandler.Delete)
+func InitRouting(e *echo.Echo, userHandler handler.UserHandler, authHandler handler.AuthHandler, emailVerificationHandler handler.EmailVerificationHandler, passwordResetHandler handler.PasswordResetHandler, auditLogHandler handler.AuditLogHandler, webhookHandler handler.WebhookHandler, tokenVerifier usecase.TokenVerifier, accountStatusChecker usecase.AccountStatusChecker, accessPolicy usecase.AccessPolicy, trustedProxies []*net.IPNet, timeouts middleware.RouteTimeouts, requireIfMatch bool) {
+	authenticate := middleware.Authenticate(tokenVerifier, accountStatusChecker)
+	// authorize 認証した上で、パスパラメータidのユーザーに対する権限を確認する
+	authorize := func(permission model.Permission) []echo.MiddlewareFunc {
+		return []echo.MiddlewareFunc{authenticate, middleware.Authorize(accessPolicy, permission, "id")}
+	}
+	// 更新系はIf-Matchヘッダーで楽観的排他制御を行う。requireIfMatchの場合はヘッダーを必須にする
+	preconditions := func(permission model.Permission) []echo.MiddlewareFunc {
+		m := authorize(permission)
+		if requireIfMatch {
+			m = append(m, middleware.RequireIfMatch())
+		}
+		return m
+	}
+
+	e.Use(middleware.RequestID())
+	e.Use(middleware.ClientIP(trustedProxies))
+
+	add := func(method string, path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) {
+		e.Add(method, path, h, append([]echo.MiddlewareFunc{middleware.Timeout(timeouts.For(method, path))}, m...)...)
+	}
+
+	add(echo.POST, "/auth/login", authHandler.Login)
+	add(echo.POST, "/auth/refresh", authHandler.Refresh)
+	add(echo.POST, "/auth/logout", authHandler.Logout)
+	add(echo.POST, "/auth/logout/all", authHandler.LogoutAll, authenticate)
+	add(echo.POST, "/auth/password/forgot", passwordResetHandler.Forgot)
+	add(echo.POST, "/auth/password/reset", passwordResetHandler.Reset)
+
+	add(echo.POST, "/user", userHandler.Post)
+	add(echo.POST, "/user/verify", emailVerificationHandler.Verify)
+	add(echo.POST, "/user/verify/resend", emailVerificationHandler.Resend)
+	add(echo.GET, "/user/:id", userHandler.Get, authorize(model.PermissionReadUsers)...)
+	add(echo.GET, "/users", userHandler.GetAll, authenticate, middleware.Authorize(accessPolicy, model.PermissionReadUsers, ""))
+	add(echo.PUT, "/user/:id", userHandler.Put, preconditions(model.PermissionUpdateUsers)...)
+	add(echo.PATCH, "/user/:id", userHandler.Patch, preconditions(model.PermissionUpdateUsers)...)
+	add(echo.DELETE, "/user/:id", userHandler.Delete, preconditions(model.PermissionDeleteUsers)...)
+	add(echo.POST, "/user/:id/suspend", userHandler.Suspend, preconditions(model.PermissionSuspendUsers)...)
+	add(echo.POST, "/user/:id/reinstate", userHandler.Reinstate, preconditions(model.PermissionSuspendUsers)...)
+	add(echo.POST, "/user/:id/deactivate", userHandler.Deactivate, preconditions(model.PermissionDeactivateUsers)...)
+	add(echo.POST, "/user/:id/restore", userHandler.Restore, preconditions(model.PermissionRestoreUsers)...)
+	add(echo.PUT, "/user/:id/role", userHandler.AssignRole, preconditions(model.PermissionAssignRoles)...)
+
+	add(echo.GET, "/users/:id/audit", auditLogHandler.GetByUser, authorize(model.PermissionReadAuditLogs)...)
+	add(echo.GET, "/audit", auditLogHandler.GetAll, authenticate, middleware.Authorize(accessPolicy, model.PermissionReadAuditLogs, ""))
+
+	manageWebhooks := []echo.MiddlewareFunc{authenticate, middleware.Authorize(accessPolicy, model.PermissionManageWebhooks, "")}
+	add(echo.POST, "/webhooks", webhookHandler.Post, manageWebhooks...)
+	add(echo.GET, "/webhooks", webhookHandler.GetAll, manageWebhooks...)
+	add(echo.GET, "/webhooks/:id", webhookHandler.Get, manageWebhooks...)
+	add(echo.PUT, "/webhooks/:id", webhookHandler.Put, manageWebhooks...)
+	add(echo.DELETE, "/webhooks/:id", webhookHandler.Delete, manageWebhooks...)
+	add(echo.GET, "/webhooks/:id/deliveries", webhookHandler.GetDeliveries, manageWebhooks...)
+	add(echo.GET, "/webhooks/:id/deliveries/:delivery_id", webhookHandler.GetDelivery, manageWebhooks...)
+	add(echo.POST, "/webhooks/:id/deliveries/:delivery_id/replay", webhookHandler.Replay, manageWebhooks...)
 }
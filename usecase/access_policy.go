@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// AccessDenial 権限が無いため拒否した操作
+type AccessDenial struct {
+	ActorID    string
+	Role       model.Role
+	Permission model.Permission
+	TargetID   string
+	RequestID  string
+	DeniedAt   time.Time
+}
+
+// AccessDenialRecorder 拒否した操作を記録する
+type AccessDenialRecorder interface {
+	RecordDenial(ctx context.Context, denial AccessDenial) error
+}
+
+// AccessPolicy 利用者がユーザーに対する操作を行えるか判定する
+type AccessPolicy interface {
+	// Authorize ctxの利用者がtargetIDのユーザーに対してpermissionの操作を行えなければエラーを返す。
+	// 特定のユーザーを対象としない操作ではtargetIDに空文字を渡す
+	Authorize(ctx context.Context, permission model.Permission, targetID string) error
+	// AuthorizeTarget ctxの利用者より上位の役割を持つtargetに対するpermissionの操作を拒否する。
+	// 役割ごとの権限はAuthorizeで確認済みであることを前提とする
+	AuthorizeTarget(ctx context.Context, permission model.Permission, target *model.User) error
+}
+
+type accessPolicy struct {
+	recorder AccessDenialRecorder
+}
+
+func NewAccessPolicy(recorder AccessDenialRecorder) AccessPolicy {
+	return &accessPolicy{recorder: recorder}
+}
+
+// Authorize 役割に権限があるか、本人が自分のユーザーに対して行える操作であれば許可する。拒否した場合は記録する
+func (p *accessPolicy) Authorize(ctx context.Context, permission model.Permission, targetID string) error {
+	actor := ActorFrom(ctx)
+	if actor == nil {
+		return model.NewUnauthorizedError("認証が必要です")
+	}
+	if actor.Role.Can(permission) {
+		return nil
+	}
+	if targetID != "" && permission.AllowsSelf() && strings.EqualFold(actor.UserID, targetID) {
+		return nil
+	}
+
+	p.recordDenial(ctx, actor, permission, targetID)
+	if targetID != "" && permission.AllowsSelf() {
+		return model.NewForbiddenError("他のユーザーの情報にはアクセスできません")
+	}
+	return model.NewForbiddenError("この操作を行う権限がありません")
+}
+
+// AuthorizeTarget 利用者と同じか下位の役割のユーザーであれば許可する。拒否した場合は記録する
+func (p *accessPolicy) AuthorizeTarget(ctx context.Context, permission model.Permission, target *model.User) error {
+	actor := ActorFrom(ctx)
+	if actor == nil {
+		return model.NewUnauthorizedError("認証が必要です")
+	}
+	if !target.Role().Outranks(actor.Role) {
+		return nil
+	}
+	p.recordDenial(ctx, actor, permission, target.ID())
+	return model.NewForbiddenError("上位の役割のユーザーは操作できません")
+}
+
+// recordDenial 記録できなくても拒否の結果は変わらないため、ログに残して続ける
+func (p *accessPolicy) recordDenial(ctx context.Context, actor *Claims, permission model.Permission, targetID string) {
+	if err := p.recorder.RecordDenial(ctx, AccessDenial{
+		ActorID:    actor.UserID,
+		Role:       actor.Role,
+		Permission: permission,
+		TargetID:   targetID,
+		RequestID:  RequestIDFrom(ctx),
+		DeniedAt:   time.Now(),
+	}); err != nil {
+		log.Printf("failed to record access denial: %v", err)
+	}
+}
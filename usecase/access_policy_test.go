@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubDenialRecorder 記録された拒否を保持する
+type stubDenialRecorder struct {
+	denials []AccessDenial
+	err     error
+}
+
+func (r *stubDenialRecorder) RecordDenial(ctx context.Context, denial AccessDenial) error {
+	r.denials = append(r.denials, denial)
+	return r.err
+}
+
+var testAccessPolicy = NewAccessPolicy(&stubDenialRecorder{})
+
+func TestAccessPolicy_Authorize(t *testing.T) {
+	type TestCase struct {
+		name          string
+		role          model.Role
+		permission    model.Permission
+		targetID      string
+		expectedError error
+	}
+	testCases := []TestCase{
+		{"成功: 本人は自分のユーザーを参照できる", model.RoleMember, model.PermissionReadUsers, "MEMBER-ID", nil},
+		{"成功: 本人は自分のユーザーを削除できる", model.RoleMember, model.PermissionDeleteUsers, "member-id", nil},
+		{"成功: サポートは他のユーザーを参照できる", model.RoleSupport, model.PermissionReadUsers, "other-id", nil},
+		{"成功: サポートは利用停止できる", model.RoleSupport, model.PermissionSuspendUsers, "other-id", nil},
+		{"成功: 管理者は他のユーザーを削除できる", model.RoleAdmin, model.PermissionDeleteUsers, "other-id", nil},
+		{"失敗: メンバーは他のユーザーを参照できない", model.RoleMember, model.PermissionReadUsers, "other-id", model.ErrForbidden},
+		{"失敗: メンバーは一覧を取得できない", model.RoleMember, model.PermissionReadUsers, "", model.ErrForbidden},
+		{"失敗: 本人でも自分を利用停止できない", model.RoleMember, model.PermissionSuspendUsers, "member-id", model.ErrForbidden},
+		{"失敗: サポートは他のユーザーを更新できない", model.RoleSupport, model.PermissionUpdateUsers, "other-id", model.ErrForbidden},
+		{"失敗: サポートは役割を変更できない", model.RoleSupport, model.PermissionAssignRoles, "other-id", model.ErrForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := &stubDenialRecorder{}
+			policy := NewAccessPolicy(recorder)
+			ctx := WithRequestID(WithActor(context.Background(), &Claims{UserID: "member-id", Role: tc.role}), "request-id")
+
+			err := policy.Authorize(ctx, tc.permission, tc.targetID)
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+				assert.Empty(t, recorder.denials)
+				return
+			}
+			assert.ErrorIs(t, err, tc.expectedError)
+			if assert.Len(t, recorder.denials, 1) {
+				denial := recorder.denials[0]
+				assert.Equal(t, "member-id", denial.ActorID)
+				assert.Equal(t, tc.role, denial.Role)
+				assert.Equal(t, tc.permission, denial.Permission)
+				assert.Equal(t, tc.targetID, denial.TargetID)
+				assert.Equal(t, "request-id", denial.RequestID)
+			}
+		})
+	}
+
+	t.Run("失敗: 未認証", func(t *testing.T) {
+		recorder := &stubDenialRecorder{}
+		policy := NewAccessPolicy(recorder)
+
+		err := policy.Authorize(context.Background(), model.PermissionReadUsers, "")
+
+		assert.ErrorIs(t, err, model.ErrUnauthorized)
+		assert.Empty(t, recorder.denials)
+	})
+
+	t.Run("失敗: 記録に失敗しても拒否する", func(t *testing.T) {
+		policy := NewAccessPolicy(&stubDenialRecorder{err: errors.New("disk full")})
+		ctx := WithActor(context.Background(), &Claims{UserID: "member-id", Role: model.RoleMember})
+
+		err := policy.Authorize(ctx, model.PermissionDeleteUsers, "other-id")
+
+		assert.ErrorIs(t, err, model.ErrForbidden)
+	})
+}
@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+)
+
+// AuditLogUseCase ユーザーに対する操作の監査ログを閲覧する。閲覧の権限はルーティングで確認する
+type AuditLogUseCase interface {
+	FindAll(ctx context.Context, query repository.AuditLogQuery) (*repository.AuditLogPage, error)
+}
+
+type auditLogUsecase struct {
+	auditLogRepo repository.AuditLogRepository
+}
+
+func NewAuditLogUsecase(auditLogRepo repository.AuditLogRepository) AuditLogUseCase {
+	return &auditLogUsecase{auditLogRepo: auditLogRepo}
+}
+
+func (u *auditLogUsecase) FindAll(ctx context.Context, query repository.AuditLogQuery) (*repository.AuditLogPage, error) {
+	page, err := u.auditLogRepo.FindAll(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// recordAudit beforeからafterへの変更を監査ログに記録する。変更と同じトランザクション内で呼び出し、
+// 記録できなければ変更ごとロールバックさせるためエラーをそのまま返す
+func recordAudit(ctx context.Context, ids model.IDGenerator, auditLogRepo repository.AuditLogRepository, action model.AuditAction, before *model.User, after *model.User) error {
+	audit := model.AuditContext{RequestID: RequestIDFrom(ctx), IP: ClientIPFrom(ctx)}
+	if actor := ActorFrom(ctx); actor != nil {
+		audit.ActorID = actor.UserID
+	}
+	log := model.NewAuditLog(ids, action, before, after, audit)
+	return auditLogRepo.Create(ctx, &log)
+}
@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// stubAuditLogRepository 記録した監査ログを保持する。errを設定すると記録に失敗する
+type stubAuditLogRepository struct {
+	logs []*model.AuditLog
+	err  error
+	page *repository.AuditLogPage
+}
+
+func (s *stubAuditLogRepository) Create(ctx context.Context, log *model.AuditLog) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.logs = append(s.logs, log)
+	return nil
+}
+
+func (s *stubAuditLogRepository) FindAll(ctx context.Context, query repository.AuditLogQuery) (*repository.AuditLogPage, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.page, nil
+}
+
+func TestRecordAudit(t *testing.T) {
+	// Arrange
+	auditLogRepo := &stubAuditLogRepository{}
+	ctx := WithClientIP(WithRequestID(WithActor(context.Background(), &Claims{UserID: "admin-id", Role: model.RoleAdmin}), "req-1"), "192.0.2.1")
+	before := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Username: "olduser", PasswordHash: "old-hash", Role: model.RoleMember})
+	after := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Username: "olduser", PasswordHash: "new-hash", Role: model.RoleSupport})
+
+	// Act
+	err := recordAudit(ctx, testIDs, auditLogRepo, model.AuditActionUserUpdated, before, after)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, auditLogRepo.logs, 1)
+	log := auditLogRepo.logs[0]
+	assert.Equal(t, "admin-id", log.ActorID)
+	assert.Equal(t, model.AuditActionUserUpdated, log.Action)
+	assert.Equal(t, "user-id", log.TargetID)
+	assert.Equal(t, "req-1", log.RequestID)
+	assert.Equal(t, "192.0.2.1", log.IP)
+	assert.Equal(t, []string{"password", "role"}, changedFields(log))
+}
+
+func changedFields(log *model.AuditLog) []string {
+	fields := make([]string, len(log.Changes))
+	for i, change := range log.Changes {
+		fields[i] = change.Field
+	}
+	return fields
+}
+
+func TestUserUsecase_Audit(t *testing.T) {
+	t.Run("成功: 作成を未認証の操作として記録する", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(MockUserRepository)
+		auditLogRepo := &stubAuditLogRepository{}
+		txManager := &stubTransactionManager{}
+		usecase := NewUserUsecase(mockRepo, auditLogRepo, txManager, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+		expectUnique(mockRepo)
+		mockRepo.On("Create", mock.AnythingOfType("*model.User")).Return(&model.User{}, nil)
+
+		// Act
+		user, err := usecase.Create(context.Background(), "testuser", "test@example.com", "password123")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 1, txManager.calls)
+		assert.Len(t, auditLogRepo.logs, 1)
+		log := auditLogRepo.logs[0]
+		assert.Equal(t, model.AuditActionUserCreated, log.Action)
+		assert.Equal(t, user.ID(), log.TargetID)
+		assert.Empty(t, log.ActorID)
+		assert.Contains(t, changedFields(log), "password")
+		for _, change := range log.Changes {
+			assert.Nil(t, change.Before)
+			if change.Field == "password" {
+				assert.Equal(t, "[REDACTED]", *change.After)
+			}
+		}
+	})
+
+	t.Run("成功: 削除を削除日時の変更として記録する", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(MockUserRepository)
+		auditLogRepo := &stubAuditLogRepository{}
+		usecase := NewUserUsecase(mockRepo, auditLogRepo, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+		user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Version: 1})
+		mockRepo.On("FindByIDForUpdate", "user-id").Return(user, nil)
+		mockRepo.On("Delete", user).Return(nil)
+
+		// Act
+		err := usecase.Delete(WithActor(context.Background(), &Claims{UserID: "user-id"}), "user-id", 1)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, auditLogRepo.logs, 1)
+		assert.Equal(t, model.AuditActionUserDeleted, auditLogRepo.logs[0].Action)
+		assert.Equal(t, "user-id", auditLogRepo.logs[0].ActorID)
+		assert.Equal(t, []string{"deleted_at"}, changedFields(auditLogRepo.logs[0]))
+	})
+
+	t.Run("成功: 管理者による変更を操作ごとに記録する", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(MockUserRepository)
+		auditLogRepo := &stubAuditLogRepository{}
+		usecase := NewUserUsecase(mockRepo, auditLogRepo, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+		user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Role: model.RoleMember, Status: model.UserStatusActive, Version: 1})
+		mockRepo.On("FindByIDForUpdate", "user-id").Return(user, nil)
+		mockRepo.On("Update", user).Return(user, nil)
+
+		// Act
+		_, err := usecase.Suspend(adminContext(), "user-id", 0, "規約違反")
+		_, errRole := usecase.AssignRole(adminContext(), "user-id", 0, "support")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, errRole)
+		assert.Len(t, auditLogRepo.logs, 2)
+		assert.Equal(t, model.AuditActionUserSuspended, auditLogRepo.logs[0].Action)
+		assert.Equal(t, []string{"status", "status_reason"}, changedFields(auditLogRepo.logs[0]))
+		assert.Equal(t, model.AuditActionUserRoleAssigned, auditLogRepo.logs[1].Action)
+		assert.Equal(t, []string{"role"}, changedFields(auditLogRepo.logs[1]))
+	})
+
+	t.Run("失敗: 記録できなければ変更も失敗させる", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(MockUserRepository)
+		auditLogRepo := &stubAuditLogRepository{err: errors.New("database error")}
+		usecase := NewUserUsecase(mockRepo, auditLogRepo, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+		user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Status: model.UserStatusActive, Version: 1})
+		mockRepo.On("FindByIDForUpdate", "user-id").Return(user, nil)
+		mockRepo.On("Update", user).Return(user, nil)
+
+		// Act
+		result, err := usecase.Deactivate(adminContext(), "user-id", 0, "退会")
+
+		// Assert
+		assert.EqualError(t, err, "database error")
+		assert.Nil(t, result)
+	})
+}
+
+func TestAuditLogUsecase_FindAll(t *testing.T) {
+	// Arrange
+	page := &repository.AuditLogPage{Logs: []*model.AuditLog{{ID: "log-id"}}}
+	usecase := NewAuditLogUsecase(&stubAuditLogRepository{page: page})
+
+	// Act
+	result, err := usecase.FindAll(context.Background(), repository.AuditLogQuery{TargetID: "user-id"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, page, result)
+}
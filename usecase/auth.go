@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// AccessToken 発行したアクセストークン
+type AccessToken struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// Claims アクセストークンに含まれる利用者の情報。Roleは発行時点のもので、
+// 認可にはAuthenticateが現在のロールに置き換えたものを使う
+type Claims struct {
+	UserID    string
+	Role      model.Role
+	ExpiresAt time.Time
+}
+
+// TokenVerifier アクセストークンを検証する
+type TokenVerifier interface {
+	Verify(token string) (*Claims, error)
+}
+
+// TokenIssuer アクセストークンの発行と検証を行う
+type TokenIssuer interface {
+	TokenVerifier
+	Issue(user *model.User) (*AccessToken, error)
+}
+
+// AccountStatusChecker アクセストークンの利用者がアカウントを利用できる状態か確認する。
+// アクセストークンは有効期限まで失効できないため、利用停止やロールの変更はリクエストごとに確認する
+type AccountStatusChecker interface {
+	// CheckStatus 利用できる場合は現在のユーザーを返す
+	CheckStatus(ctx context.Context, userID string) (*model.User, error)
+}
+
+type accountStatusChecker struct {
+	userRepo repository.UserRepository
+}
+
+func NewAccountStatusChecker(userRepo repository.UserRepository) AccountStatusChecker {
+	return &accountStatusChecker{userRepo: userRepo}
+}
+
+func (c *accountStatusChecker) CheckStatus(ctx context.Context, userID string) (*model.User, error) {
+	user, err := c.userRepo.FindByID(ctx, userID)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, model.NewUnauthorizedError("アクセストークンが不正です")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := user.CheckSignIn(); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// TokenPair ログイン・リフレッシュで発行するトークンの組
+type TokenPair struct {
+	AccessToken      *AccessToken
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+type AuthUseCase interface {
+	Login(ctx context.Context, email string, password string) (*TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*TokenPair, error)
+	Logout(ctx context.Context, refreshToken string) error
+	LogoutAll(ctx context.Context, userID string) error
+}
+
+type authUsecase struct {
+	userRepo         repository.UserRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	tokenIssuer      TokenIssuer
+	factory          *model.UserFactory
+	ids              model.IDGenerator
+	refreshTokenTTL  time.Duration
+}
+
+func NewAuthUsecase(userRepo repository.UserRepository, refreshTokenRepo repository.RefreshTokenRepository, tokenIssuer TokenIssuer, factory *model.UserFactory, ids model.IDGenerator, refreshTokenTTL time.Duration) AuthUseCase {
+	return &authUsecase{
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		tokenIssuer:      tokenIssuer,
+		factory:          factory,
+		ids:              ids,
+		refreshTokenTTL:  refreshTokenTTL,
+	}
+}
+
+var errInvalidRefreshToken = model.NewUnauthorizedError("リフレッシュトークンが不正です")
+
+func (u *authUsecase) Login(ctx context.Context, email string, password string) (*TokenPair, error) {
+	user, err := u.userRepo.FindByEmail(ctx, email)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, u.factory.RejectAuthentication(password)
+	}
+	if err != nil {
+		return nil, err
+	}
+	currentHash := user.PasswordHash()
+	rehashed, err := user.Authenticate(u.factory, password)
+	if err != nil {
+		return nil, err
+	}
+	// パスワードが一致した場合のみ状態を伝え、アカウントの状態を第三者に知られないようにする
+	if err := user.CheckSignIn(); err != nil {
+		return nil, err
+	}
+	if rehashed {
+		// 保存できなくても次回のログインで再度ハッシュ化し直すため、ログインは続ける
+		if _, err := u.userRepo.ReplacePasswordHash(ctx, user.ID(), currentHash, user.PasswordHash()); err != nil {
+			log.Printf("failed to upgrade password hash: %v", err)
+		}
+	}
+
+	return u.issue(ctx, user, "")
+}
+
+// Refresh リフレッシュトークンをローテーションする。
+// 使用済みのトークンが再度提示された場合は漏洩とみなし、同じ系列のトークンを全て失効させる
+func (u *authUsecase) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	token, err := u.refreshTokenRepo.FindByHash(ctx, model.HashRefreshToken(refreshToken))
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, errInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if token.IsRevoked() || token.IsExpired(now) {
+		return nil, errInvalidRefreshToken
+	}
+	if token.IsUsed() {
+		return nil, u.revokeReusedFamily(ctx, token, now)
+	}
+	marked, err := u.refreshTokenRepo.MarkUsed(ctx, token.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		// 同時に同じトークンが使われた場合も再利用として扱う
+		return nil, u.revokeReusedFamily(ctx, token, now)
+	}
+
+	user, err := u.userRepo.FindByID(ctx, token.UserID)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, errInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := user.CheckSignIn(); err != nil {
+		return nil, err
+	}
+
+	return u.issue(ctx, user, token.FamilyID)
+}
+
+func (u *authUsecase) revokeReusedFamily(ctx context.Context, token *model.RefreshToken, now time.Time) error {
+	if err := u.refreshTokenRepo.RevokeFamily(ctx, token.FamilyID, now); err != nil {
+		return err
+	}
+	return errInvalidRefreshToken
+}
+
+// Logout リフレッシュトークンの系列を失効させる。不明なトークンの場合は何もしない
+func (u *authUsecase) Logout(ctx context.Context, refreshToken string) error {
+	token, err := u.refreshTokenRepo.FindByHash(ctx, model.HashRefreshToken(refreshToken))
+	if errors.Is(err, model.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return u.refreshTokenRepo.RevokeFamily(ctx, token.FamilyID, time.Now())
+}
+
+// LogoutAll ユーザーの全ての端末のリフレッシュトークンを失効させる
+func (u *authUsecase) LogoutAll(ctx context.Context, userID string) error {
+	return u.refreshTokenRepo.RevokeByUserID(ctx, userID, time.Now())
+}
+
+func (u *authUsecase) issue(ctx context.Context, user *model.User, familyID string) (*TokenPair, error) {
+	accessToken, err := u.tokenIssuer.Issue(user)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, raw, err := model.NewRefreshToken(u.ids, user.ID(), familyID, u.refreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.refreshTokenRepo.Create(ctx, &refreshToken); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     raw,
+		RefreshExpiresAt: refreshToken.ExpiresAt,
+	}, nil
+}
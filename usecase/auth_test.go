@@ -0,0 +1,401 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTokenIssuer is a mock implementation of TokenIssuer
+type MockTokenIssuer struct {
+	mock.Mock
+}
+
+func (m *MockTokenIssuer) Issue(user *model.User) (*AccessToken, error) {
+	args := m.Called(user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*AccessToken), args.Error(1)
+}
+
+func (m *MockTokenIssuer) Verify(token string) (*Claims, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Claims), args.Error(1)
+}
+
+// MockRefreshTokenRepository is a mock implementation of RefreshTokenRepository
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) FindByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	args := m.Called(hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) MarkUsed(ctx context.Context, id string, usedAt time.Time) (bool, error) {
+	args := m.Called(id, usedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, revokedAt time.Time) error {
+	args := m.Called(familyID, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeByUserID(ctx context.Context, userID string, revokedAt time.Time) error {
+	args := m.Called(userID, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+const refreshTokenTTL = 24 * time.Hour
+
+func TestAuthUsecase_Login(t *testing.T) {
+	user, err := testUserFactory.NewUser("testuser", "test@example.com", "password123")
+	assert.NoError(t, err)
+
+	t.Run("成功: アクセストークンを発行できる", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockIssuer := new(MockTokenIssuer)
+		usecase := NewAuthUsecase(mockRepo, mockTokenRepo, mockIssuer, testUserFactory, testIDs, refreshTokenTTL)
+
+		expectedToken := &AccessToken{Token: "token", ExpiresAt: time.Now().Add(time.Minute)}
+		mockRepo.On("FindByEmail", "test@example.com").Return(&user, nil)
+		mockIssuer.On("Issue", &user).Return(expectedToken, nil)
+		var saved *model.RefreshToken
+		mockTokenRepo.On("Create", mock.AnythingOfType("*model.RefreshToken")).Run(func(args mock.Arguments) {
+			saved = args.Get(0).(*model.RefreshToken)
+		}).Return(nil)
+
+		result, err := usecase.Login(context.Background(), "test@example.com", "password123")
+
+		assert.NoError(t, err)
+		assert.Equal(t, expectedToken, result.AccessToken)
+		assert.NotEmpty(t, result.RefreshToken)
+		assert.Equal(t, model.HashRefreshToken(result.RefreshToken), saved.TokenHash)
+		assert.Equal(t, saved.ID, saved.FamilyID)
+		assert.Equal(t, user.ID(), saved.UserID)
+		mockRepo.AssertExpectations(t)
+		mockTokenRepo.AssertExpectations(t)
+		mockIssuer.AssertExpectations(t)
+	})
+
+	t.Run("成功: 古い設定のハッシュはハッシュ化し直して保存する", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockIssuer := new(MockTokenIssuer)
+		usecase := NewAuthUsecase(mockRepo, mockTokenRepo, mockIssuer, testUserFactory, testIDs, refreshTokenTTL)
+
+		policy := model.DefaultPasswordPolicy()
+		policy.Cost = 4
+		oldUser, err := newTestUserFactory(policy).NewUser("olduser", "old@example.com", "password123")
+		assert.NoError(t, err)
+		oldHash := oldUser.PasswordHash()
+
+		mockRepo.On("FindByEmail", "old@example.com").Return(&oldUser, nil)
+		mockRepo.On("ReplacePasswordHash", oldUser.ID(), oldHash, mock.AnythingOfType("string")).Return(true, nil)
+		mockIssuer.On("Issue", &oldUser).Return(&AccessToken{Token: "token"}, nil)
+		mockTokenRepo.On("Create", mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+
+		_, err = usecase.Login(context.Background(), "old@example.com", "password123")
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, oldHash, oldUser.PasswordHash())
+		mockRepo.AssertCalled(t, "ReplacePasswordHash", oldUser.ID(), oldHash, oldUser.PasswordHash())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("失敗: パスワードが一致しない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockIssuer := new(MockTokenIssuer)
+		usecase := NewAuthUsecase(mockRepo, mockTokenRepo, mockIssuer, testUserFactory, testIDs, refreshTokenTTL)
+
+		mockRepo.On("FindByEmail", "test@example.com").Return(&user, nil)
+
+		result, err := usecase.Login(context.Background(), "test@example.com", "wrongpassword1")
+
+		assert.ErrorIs(t, err, model.ErrUnauthorized)
+		assert.Nil(t, result)
+		mockIssuer.AssertNotCalled(t, "Issue", mock.Anything)
+	})
+
+	t.Run("失敗: 利用停止中のユーザー", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockIssuer := new(MockTokenIssuer)
+		usecase := NewAuthUsecase(mockRepo, mockTokenRepo, mockIssuer, testUserFactory, testIDs, refreshTokenTTL)
+
+		suspended := model.ReconstructUser(model.UserSnapshot{ID: user.ID(), Email: user.Email(), PasswordHash: user.PasswordHash(), Status: model.UserStatusSuspended})
+		mockRepo.On("FindByEmail", "test@example.com").Return(suspended, nil)
+
+		_, wrongPasswordErr := usecase.Login(context.Background(), "test@example.com", "wrongpassword1")
+		result, err := usecase.Login(context.Background(), "test@example.com", "password123")
+
+		assert.ErrorIs(t, wrongPasswordErr, model.ErrUnauthorized)
+		assert.ErrorIs(t, err, model.ErrForbidden)
+		assert.Nil(t, result)
+		mockIssuer.AssertNotCalled(t, "Issue", mock.Anything)
+	})
+
+	t.Run("失敗: ユーザーが存在しない場合も同じエラーを返す", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockIssuer := new(MockTokenIssuer)
+		usecase := NewAuthUsecase(mockRepo, mockTokenRepo, mockIssuer, testUserFactory, testIDs, refreshTokenTTL)
+
+		mockRepo.On("FindByEmail", "unknown@example.com").Return(nil, model.NewNotFoundError("ユーザーが見つかりません", nil))
+
+		result, err := usecase.Login(context.Background(), "unknown@example.com", "password123")
+
+		assert.ErrorIs(t, err, model.ErrUnauthorized)
+		assert.Equal(t, "メールアドレスまたはパスワードが正しくありません", err.Error())
+		assert.Nil(t, result)
+	})
+
+	t.Run("失敗: リポジトリエラー", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockIssuer := new(MockTokenIssuer)
+		usecase := NewAuthUsecase(mockRepo, mockTokenRepo, mockIssuer, testUserFactory, testIDs, refreshTokenTTL)
+
+		mockRepo.On("FindByEmail", "test@example.com").Return(nil, errors.New("database error"))
+
+		result, err := usecase.Login(context.Background(), "test@example.com", "password123")
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		assert.Contains(t, err.Error(), "database error")
+	})
+}
+
+func TestAuthUsecase_Refresh(t *testing.T) {
+	user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Role: model.RoleMember})
+
+	newStoredToken := func(raw string) *model.RefreshToken {
+		return &model.RefreshToken{
+			ID:        "token-id",
+			UserID:    user.ID(),
+			FamilyID:  "family-id",
+			TokenHash: model.HashRefreshToken(raw),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	t.Run("成功: トークンをローテーションできる", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockIssuer := new(MockTokenIssuer)
+		usecase := NewAuthUsecase(mockRepo, mockTokenRepo, mockIssuer, testUserFactory, testIDs, refreshTokenTTL)
+
+		stored := newStoredToken("raw-token")
+		mockTokenRepo.On("FindByHash", stored.TokenHash).Return(stored, nil)
+		mockTokenRepo.On("MarkUsed", "token-id", mock.AnythingOfType("time.Time")).Return(true, nil)
+		mockRepo.On("FindByID", user.ID()).Return(user, nil)
+		mockIssuer.On("Issue", user).Return(&AccessToken{Token: "token"}, nil)
+		var saved *model.RefreshToken
+		mockTokenRepo.On("Create", mock.AnythingOfType("*model.RefreshToken")).Run(func(args mock.Arguments) {
+			saved = args.Get(0).(*model.RefreshToken)
+		}).Return(nil)
+
+		result, err := usecase.Refresh(context.Background(), "raw-token")
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, "raw-token", result.RefreshToken)
+		assert.Equal(t, "family-id", saved.FamilyID)
+		assert.NotEqual(t, "token-id", saved.ID)
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("失敗: 無効化されたユーザー", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockIssuer := new(MockTokenIssuer)
+		usecase := NewAuthUsecase(mockRepo, mockTokenRepo, mockIssuer, testUserFactory, testIDs, refreshTokenTTL)
+
+		stored := newStoredToken("raw-token")
+		deactivated := model.ReconstructUser(model.UserSnapshot{ID: user.ID(), Status: model.UserStatusDeactivated})
+		mockTokenRepo.On("FindByHash", stored.TokenHash).Return(stored, nil)
+		mockTokenRepo.On("MarkUsed", "token-id", mock.AnythingOfType("time.Time")).Return(true, nil)
+		mockRepo.On("FindByID", user.ID()).Return(deactivated, nil)
+
+		result, err := usecase.Refresh(context.Background(), "raw-token")
+
+		assert.ErrorIs(t, err, model.ErrForbidden)
+		assert.Nil(t, result)
+		mockIssuer.AssertNotCalled(t, "Issue", mock.Anything)
+	})
+
+	t.Run("失敗: 使用済みトークンの再利用は系列ごと失効させる", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockIssuer := new(MockTokenIssuer)
+		usecase := NewAuthUsecase(mockRepo, mockTokenRepo, mockIssuer, testUserFactory, testIDs, refreshTokenTTL)
+
+		stored := newStoredToken("raw-token")
+		usedAt := time.Now().Add(-time.Minute)
+		stored.UsedAt = &usedAt
+		mockTokenRepo.On("FindByHash", stored.TokenHash).Return(stored, nil)
+		mockTokenRepo.On("RevokeFamily", "family-id", mock.AnythingOfType("time.Time")).Return(nil)
+
+		result, err := usecase.Refresh(context.Background(), "raw-token")
+
+		assert.ErrorIs(t, err, model.ErrUnauthorized)
+		assert.Nil(t, result)
+		mockTokenRepo.AssertExpectations(t)
+		mockIssuer.AssertNotCalled(t, "Issue", mock.Anything)
+	})
+
+	t.Run("失敗: 同時に使用された場合も再利用として扱う", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockIssuer := new(MockTokenIssuer)
+		usecase := NewAuthUsecase(mockRepo, mockTokenRepo, mockIssuer, testUserFactory, testIDs, refreshTokenTTL)
+
+		stored := newStoredToken("raw-token")
+		mockTokenRepo.On("FindByHash", stored.TokenHash).Return(stored, nil)
+		mockTokenRepo.On("MarkUsed", "token-id", mock.AnythingOfType("time.Time")).Return(false, nil)
+		mockTokenRepo.On("RevokeFamily", "family-id", mock.AnythingOfType("time.Time")).Return(nil)
+
+		_, err := usecase.Refresh(context.Background(), "raw-token")
+
+		assert.ErrorIs(t, err, model.ErrUnauthorized)
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("失敗: 失効済み・期限切れ・不明なトークン", func(t *testing.T) {
+		revokedAt := time.Now()
+		revoked := newStoredToken("revoked")
+		revoked.RevokedAt = &revokedAt
+		expired := newStoredToken("expired")
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockIssuer := new(MockTokenIssuer)
+		usecase := NewAuthUsecase(mockRepo, mockTokenRepo, mockIssuer, testUserFactory, testIDs, refreshTokenTTL)
+
+		mockTokenRepo.On("FindByHash", revoked.TokenHash).Return(revoked, nil)
+		mockTokenRepo.On("FindByHash", expired.TokenHash).Return(expired, nil)
+		mockTokenRepo.On("FindByHash", model.HashRefreshToken("unknown")).Return(nil, model.NewNotFoundError("リフレッシュトークンが見つかりません", nil))
+
+		for _, raw := range []string{"revoked", "expired", "unknown"} {
+			_, err := usecase.Refresh(context.Background(), raw)
+			assert.ErrorIs(t, err, model.ErrUnauthorized, raw)
+		}
+		mockTokenRepo.AssertNotCalled(t, "MarkUsed", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthUsecase_Logout(t *testing.T) {
+	t.Run("成功: 系列を失効させる", func(t *testing.T) {
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		usecase := NewAuthUsecase(new(MockUserRepository), mockTokenRepo, new(MockTokenIssuer), testUserFactory, testIDs, refreshTokenTTL)
+
+		stored := &model.RefreshToken{ID: "token-id", FamilyID: "family-id", TokenHash: model.HashRefreshToken("raw-token")}
+		mockTokenRepo.On("FindByHash", stored.TokenHash).Return(stored, nil)
+		mockTokenRepo.On("RevokeFamily", "family-id", mock.AnythingOfType("time.Time")).Return(nil)
+
+		err := usecase.Logout(context.Background(), "raw-token")
+
+		assert.NoError(t, err)
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("成功: 不明なトークンは何もしない", func(t *testing.T) {
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		usecase := NewAuthUsecase(new(MockUserRepository), mockTokenRepo, new(MockTokenIssuer), testUserFactory, testIDs, refreshTokenTTL)
+
+		mockTokenRepo.On("FindByHash", model.HashRefreshToken("unknown")).Return(nil, model.NewNotFoundError("リフレッシュトークンが見つかりません", nil))
+
+		err := usecase.Logout(context.Background(), "unknown")
+
+		assert.NoError(t, err)
+		mockTokenRepo.AssertNotCalled(t, "RevokeFamily", mock.Anything, mock.Anything)
+	})
+
+	t.Run("成功: 全ての端末からログアウトする", func(t *testing.T) {
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		usecase := NewAuthUsecase(new(MockUserRepository), mockTokenRepo, new(MockTokenIssuer), testUserFactory, testIDs, refreshTokenTTL)
+
+		mockTokenRepo.On("RevokeByUserID", "user-id", mock.AnythingOfType("time.Time")).Return(nil)
+
+		err := usecase.LogoutAll(context.Background(), "user-id")
+
+		assert.NoError(t, err)
+		mockTokenRepo.AssertExpectations(t)
+	})
+}
+
+func TestRefreshTokenSweeper(t *testing.T) {
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	sweeper := NewRefreshTokenSweeper(mockTokenRepo, time.Hour)
+
+	mockTokenRepo.On("DeleteExpired", mock.AnythingOfType("time.Time")).Return(int64(3), nil)
+
+	deleted, err := sweeper.Sweep(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+	mockTokenRepo.AssertExpectations(t)
+}
+
+func TestAccountStatusChecker(t *testing.T) {
+	type TestCase struct {
+		name          string
+		user          *model.User
+		findErr       error
+		expectedError error
+	}
+	testCases := []TestCase{
+		{"成功: 有効なユーザー", model.ReconstructUser(model.UserSnapshot{ID: "user-id", Status: model.UserStatusActive}), nil, nil},
+		{"成功: 確認待ちのユーザー", model.ReconstructUser(model.UserSnapshot{ID: "user-id", Status: model.UserStatusPending}), nil, nil},
+		{"失敗: 利用停止中のユーザー", model.ReconstructUser(model.UserSnapshot{ID: "user-id", Status: model.UserStatusSuspended}), nil, model.ErrForbidden},
+		{"失敗: 存在しないユーザー", nil, model.NewNotFoundError("ユーザーが見つかりません", nil), model.ErrUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			checker := NewAccountStatusChecker(mockRepo)
+			if tc.user != nil {
+				mockRepo.On("FindByID", "user-id").Return(tc.user, tc.findErr)
+			} else {
+				mockRepo.On("FindByID", "user-id").Return(nil, tc.findErr)
+			}
+
+			user, err := checker.CheckStatus(context.Background(), "user-id")
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+				assert.Same(t, tc.user, user)
+				return
+			}
+			assert.ErrorIs(t, err, tc.expectedError)
+			assert.Nil(t, user)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package usecase
+
+import "context"
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	actorKey
+	clientIPKey
+)
+
+// WithRequestID リクエストIDをコンテキストに格納する
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFrom コンテキストに格納されたリクエストIDを返す。未設定の場合は空文字
+func RequestIDFrom(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithActor 操作を行う利用者の情報をコンテキストに格納する
+func WithActor(ctx context.Context, actor *Claims) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// ActorFrom コンテキストに格納された利用者の情報を返す。未認証の場合はnil
+func ActorFrom(ctx context.Context) *Claims {
+	actor, _ := ctx.Value(actorKey).(*Claims)
+	return actor
+}
+
+// WithClientIP リクエスト元のIPアドレスをコンテキストに格納する
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey, ip)
+}
+
+// ClientIPFrom コンテキストに格納されたリクエスト元のIPアドレスを返す。未設定の場合は空文字
+func ClientIPFrom(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey).(string)
+	return ip
+}
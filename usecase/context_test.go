@@ -0,0 +1,28 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext(t *testing.T) {
+	t.Run("成功: リクエストIDと利用者を格納して取り出せる", func(t *testing.T) {
+		// Arrange
+		actor := &Claims{UserID: "user-id"}
+
+		// Act
+		ctx := WithActor(WithRequestID(context.Background(), "request-id"), actor)
+
+		// Assert
+		assert.Equal(t, "request-id", RequestIDFrom(ctx))
+		assert.Same(t, actor, ActorFrom(ctx))
+	})
+
+	t.Run("成功: 未設定の場合はゼロ値を返す", func(t *testing.T) {
+		// Act & Assert
+		assert.Empty(t, RequestIDFrom(context.Background()))
+		assert.Nil(t, ActorFrom(context.Background()))
+	})
+}
@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// EmailVerificationSender ユーザーにメールアドレスの確認メールを送る
+type EmailVerificationSender interface {
+	// Send 確認用のトークンを発行してメールを送る。以前に発行したトークンは使えなくなる
+	Send(ctx context.Context, user *model.User) error
+}
+
+type EmailVerificationUseCase interface {
+	EmailVerificationSender
+	// Verify トークンを検証し、発行時のメールアドレスを確認済みにする
+	Verify(ctx context.Context, token string) (*model.User, error)
+	// Resend 未確認のユーザーに確認メールを送り直す。登録の有無を推測されないよう、
+	// 送信はキューに任せ、該当するユーザーがいない場合や確認済みの場合、同じメールアドレスへの回数が上限を超えた場合もエラーにしない
+	Resend(ctx context.Context, email string) error
+}
+
+// EmailVerificationConfig URLには確認用の画面を指定し、クエリパラメータtokenにトークンを付けてメールに記載する
+type EmailVerificationConfig struct {
+	TTL time.Duration
+	URL string
+}
+
+type emailVerificationUsecase struct {
+	userRepo     repository.UserRepository
+	auditLogRepo repository.AuditLogRepository
+	txManager    repository.TransactionManager
+	tokens       oneTimeTokens
+	mailer       Mailer
+	queue        *MailQueue
+	limiter      RateLimiter
+	factory      *model.UserFactory
+	ids          model.IDGenerator
+	config       EmailVerificationConfig
+}
+
+func NewEmailVerificationUsecase(userRepo repository.UserRepository, tokenRepo repository.OneTimeTokenRepository, auditLogRepo repository.AuditLogRepository, txManager repository.TransactionManager, signer TokenSigner, mailer Mailer, queue *MailQueue, limiter RateLimiter, factory *model.UserFactory, ids model.IDGenerator, config EmailVerificationConfig) EmailVerificationUseCase {
+	return &emailVerificationUsecase{
+		userRepo:     userRepo,
+		auditLogRepo: auditLogRepo,
+		txManager:    txManager,
+		tokens: oneTimeTokens{
+			purpose:   model.TokenPurposeEmailVerification,
+			tokenRepo: tokenRepo,
+			userRepo:  userRepo,
+			signer:    signer,
+			ids:       ids,
+			ttl:       config.TTL,
+			invalid:   errInvalidVerificationToken,
+		},
+		mailer:  mailer,
+		queue:   queue,
+		limiter: limiter,
+		factory: factory,
+		ids:     ids,
+		config:  config,
+	}
+}
+
+var errInvalidVerificationToken = model.NewValidationError(model.CodeInvalidToken, "確認トークンが不正か有効期限が切れています")
+
+func (u *emailVerificationUsecase) Send(ctx context.Context, user *model.User) error {
+	var token *model.OneTimeToken
+	var signed string
+	err := u.txManager.Do(ctx, func(ctx context.Context) error {
+		var err error
+		token, signed, err = u.tokens.issue(ctx, user)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	link := u.config.URL + "?token=" + url.QueryEscape(signed)
+	return u.mailer.Send(ctx, Mail{
+		To:      user.Email(),
+		Subject: "メールアドレスの確認",
+		Body: fmt.Sprintf("%s 様\n\nメールアドレスを確認するには、次のURLを開いてください。\n%s\n\nこのURLは%sまで有効です。\n心当たりがない場合は、このメールを破棄してください。\n",
+			user.Username(), link, token.ExpiresAt.Format("2006-01-02 15:04")),
+	})
+}
+
+func (u *emailVerificationUsecase) Verify(ctx context.Context, signed string) (*model.User, error) {
+	var user *model.User
+	err := u.txManager.Do(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		token, found, err := u.tokens.consume(ctx, signed, now)
+		if err != nil {
+			return err
+		}
+		user = found
+		before := *user
+		if err := user.VerifyEmail(token.Email, now); err != nil {
+			return err
+		}
+		if _, err := u.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.ids, u.auditLogRepo, model.AuditActionUserEmailVerified, &before, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (u *emailVerificationUsecase) Resend(ctx context.Context, email string) error {
+	if !allowMail(u.limiter, u.factory, model.TokenPurposeEmailVerification, email) {
+		return nil
+	}
+	u.queue.Enqueue("resend verification email", func(ctx context.Context) error {
+		return u.resend(ctx, email)
+	})
+	return nil
+}
+
+func (u *emailVerificationUsecase) resend(ctx context.Context, email string) error {
+	user, err := u.userRepo.FindByEmail(ctx, email)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.IsEmailVerified() {
+		return nil
+	}
+	return u.Send(ctx, user)
+}
@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockOneTimeTokenRepository is a mock implementation of OneTimeTokenRepository
+type MockOneTimeTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockOneTimeTokenRepository) Create(ctx context.Context, token *model.OneTimeToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockOneTimeTokenRepository) FindByHash(ctx context.Context, purpose model.TokenPurpose, hash string) (*model.OneTimeToken, error) {
+	args := m.Called(purpose, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.OneTimeToken), args.Error(1)
+}
+
+func (m *MockOneTimeTokenRepository) MarkUsed(ctx context.Context, id string, usedAt time.Time) (bool, error) {
+	args := m.Called(id, usedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOneTimeTokenRepository) DeleteByUserID(ctx context.Context, purpose model.TokenPurpose, userID string) error {
+	args := m.Called(purpose, userID)
+	return args.Error(0)
+}
+
+// stubTokenSigner 用途を前置しただけの署名
+type stubTokenSigner struct{}
+
+func (stubTokenSigner) Sign(purpose string, token string) string {
+	return purpose + ":" + token
+}
+
+func (stubTokenSigner) Verify(purpose string, signed string) (string, error) {
+	token, ok := strings.CutPrefix(signed, purpose+":")
+	if !ok {
+		return "", errors.New("invalid signature")
+	}
+	return token, nil
+}
+
+type stubMailer struct {
+	sent []Mail
+}
+
+func (m *stubMailer) Send(ctx context.Context, mail Mail) error {
+	m.sent = append(m.sent, mail)
+	return nil
+}
+
+// stubRateLimiter キーごとにlimit回まで受け付ける
+type stubRateLimiter struct {
+	limit  int
+	counts map[string]int
+}
+
+func newStubRateLimiter(limit int) *stubRateLimiter {
+	return &stubRateLimiter{limit: limit, counts: map[string]int{}}
+}
+
+func (l *stubRateLimiter) Allow(key string, now time.Time) bool {
+	l.counts[key]++
+	return l.counts[key] <= l.limit
+}
+
+var emailVerificationConfig = EmailVerificationConfig{TTL: time.Hour, URL: "https://example.com/verify-email"}
+
+func TestEmailVerificationUsecase_Send(t *testing.T) {
+	t.Run("成功: 以前のトークンを削除して確認メールを送る", func(t *testing.T) {
+		mockTokenRepo := new(MockOneTimeTokenRepository)
+		mailer := &stubMailer{}
+		usecase := NewEmailVerificationUsecase(new(MockUserRepository), mockTokenRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, stubTokenSigner{}, mailer, NewMailQueue(10), newStubRateLimiter(10), testUserFactory, testIDs, emailVerificationConfig)
+		user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Username: "testuser", Email: "test@example.com"})
+
+		var saved *model.OneTimeToken
+		mockTokenRepo.On("DeleteByUserID", model.TokenPurposeEmailVerification, "user-id").Return(nil)
+		mockTokenRepo.On("Create", mock.AnythingOfType("*model.OneTimeToken")).Run(func(args mock.Arguments) {
+			saved = args.Get(0).(*model.OneTimeToken)
+		}).Return(nil)
+
+		err := usecase.Send(context.Background(), user)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "test@example.com", saved.Email)
+		assert.Len(t, mailer.sent, 1)
+		assert.Equal(t, "test@example.com", mailer.sent[0].To)
+		assert.Contains(t, mailer.sent[0].Body, "https://example.com/verify-email?token=email-verification%3A")
+		mockTokenRepo.AssertExpectations(t)
+	})
+}
+
+func TestEmailVerificationUsecase_Verify(t *testing.T) {
+	newToken := func(t *testing.T, email string) (*model.OneTimeToken, string) {
+		user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Email: email})
+		token, raw, err := model.NewOneTimeToken(testIDs, model.TokenPurposeEmailVerification, user, time.Hour)
+		assert.NoError(t, err)
+		return &token, stubTokenSigner{}.Sign(string(model.TokenPurposeEmailVerification), raw)
+	}
+
+	t.Run("成功: メールアドレスを確認済みにする", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockOneTimeTokenRepository)
+		usecase := NewEmailVerificationUsecase(mockRepo, mockTokenRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, stubTokenSigner{}, &stubMailer{}, NewMailQueue(10), newStubRateLimiter(10), testUserFactory, testIDs, emailVerificationConfig)
+		token, signed := newToken(t, "test@example.com")
+		user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Email: "test@example.com", Version: 1})
+
+		mockTokenRepo.On("FindByHash", model.TokenPurposeEmailVerification, token.TokenHash).Return(token, nil)
+		mockTokenRepo.On("MarkUsed", token.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
+		mockRepo.On("FindByIDForUpdate", "user-id").Return(user, nil)
+		mockRepo.On("Update", user).Return(user, nil)
+
+		result, err := usecase.Verify(context.Background(), signed)
+
+		assert.NoError(t, err)
+		assert.True(t, result.IsEmailVerified())
+		mockRepo.AssertExpectations(t)
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("失敗: 確認できないトークン", func(t *testing.T) {
+		used, usedSigned := newToken(t, "test@example.com")
+		usedAt := time.Now()
+		used.UsedAt = &usedAt
+		expired, expiredSigned := newToken(t, "test@example.com")
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+		raced, racedSigned := newToken(t, "test@example.com")
+
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockOneTimeTokenRepository)
+		usecase := NewEmailVerificationUsecase(mockRepo, mockTokenRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, stubTokenSigner{}, &stubMailer{}, NewMailQueue(10), newStubRateLimiter(10), testUserFactory, testIDs, emailVerificationConfig)
+		mockTokenRepo.On("FindByHash", model.TokenPurposeEmailVerification, used.TokenHash).Return(used, nil)
+		mockTokenRepo.On("FindByHash", model.TokenPurposeEmailVerification, expired.TokenHash).Return(expired, nil)
+		mockTokenRepo.On("FindByHash", model.TokenPurposeEmailVerification, raced.TokenHash).Return(raced, nil)
+		mockTokenRepo.On("MarkUsed", raced.ID, mock.AnythingOfType("time.Time")).Return(false, nil)
+		mockTokenRepo.On("FindByHash", model.TokenPurposeEmailVerification, mock.Anything).Return(nil, model.NewNotFoundError("トークンが見つかりません", nil))
+
+		for _, signed := range []string{"unsigned", "email-verification:unknown", usedSigned, expiredSigned, racedSigned} {
+			result, err := usecase.Verify(context.Background(), signed)
+
+			assert.ErrorIs(t, err, model.ErrValidation, signed)
+			assert.Nil(t, result)
+		}
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("失敗: 発行後にメールアドレスが変わっている", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockOneTimeTokenRepository)
+		usecase := NewEmailVerificationUsecase(mockRepo, mockTokenRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, stubTokenSigner{}, &stubMailer{}, NewMailQueue(10), newStubRateLimiter(10), testUserFactory, testIDs, emailVerificationConfig)
+		token, signed := newToken(t, "old@example.com")
+		user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Email: "new@example.com"})
+
+		mockTokenRepo.On("FindByHash", model.TokenPurposeEmailVerification, token.TokenHash).Return(token, nil)
+		mockTokenRepo.On("MarkUsed", token.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
+		mockRepo.On("FindByIDForUpdate", "user-id").Return(user, nil)
+
+		result, err := usecase.Verify(context.Background(), signed)
+
+		assert.ErrorIs(t, err, model.ErrValidation)
+		assert.Nil(t, result)
+		assert.False(t, user.IsEmailVerified())
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+}
+
+func TestEmailVerificationUsecase_Resend(t *testing.T) {
+	newUsecase := func(mockRepo *MockUserRepository, mockTokenRepo *MockOneTimeTokenRepository, mailer Mailer, queue *MailQueue, limiter RateLimiter) EmailVerificationUseCase {
+		return NewEmailVerificationUsecase(mockRepo, mockTokenRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, stubTokenSigner{}, mailer, queue, limiter, testUserFactory, testIDs, emailVerificationConfig)
+	}
+
+	t.Run("成功: ユーザーの検索と送信はキューで実行する", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockOneTimeTokenRepository)
+		mailer := &stubMailer{}
+		queue := NewMailQueue(10)
+		usecase := newUsecase(mockRepo, mockTokenRepo, mailer, queue, newStubRateLimiter(10))
+		user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Username: "testuser", Email: "test@example.com"})
+
+		mockRepo.On("FindByEmail", "test@example.com").Return(user, nil)
+		mockTokenRepo.On("DeleteByUserID", model.TokenPurposeEmailVerification, "user-id").Return(nil)
+		mockTokenRepo.On("Create", mock.AnythingOfType("*model.OneTimeToken")).Return(nil)
+
+		err := usecase.Resend(context.Background(), "test@example.com")
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "FindByEmail", mock.Anything)
+		assert.Empty(t, mailer.sent)
+
+		queue.Flush(context.Background())
+
+		assert.Len(t, mailer.sent, 1)
+		assert.Equal(t, "test@example.com", mailer.sent[0].To)
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("成功: 該当するユーザーがいない場合や確認済みの場合は何もしない", func(t *testing.T) {
+		verifiedAt := time.Now()
+		verified := model.ReconstructUser(model.UserSnapshot{ID: "verified-id", Email: "verified@example.com", EmailVerifiedAt: &verifiedAt})
+		mockRepo := new(MockUserRepository)
+		mailer := &stubMailer{}
+		queue := NewMailQueue(10)
+		usecase := newUsecase(mockRepo, new(MockOneTimeTokenRepository), mailer, queue, newStubRateLimiter(10))
+
+		mockRepo.On("FindByEmail", "unknown@example.com").Return(nil, model.NewNotFoundError("ユーザーが見つかりません", nil))
+		mockRepo.On("FindByEmail", "verified@example.com").Return(verified, nil)
+
+		assert.NoError(t, usecase.Resend(context.Background(), "unknown@example.com"))
+		assert.NoError(t, usecase.Resend(context.Background(), "verified@example.com"))
+		queue.Flush(context.Background())
+
+		assert.Empty(t, mailer.sent)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("成功: 同じメールアドレスへの回数が上限を超えた場合はエラーにせず送らない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockOneTimeTokenRepository)
+		mailer := &stubMailer{}
+		queue := NewMailQueue(10)
+		limiter := newStubRateLimiter(1)
+		usecase := newUsecase(mockRepo, mockTokenRepo, mailer, queue, limiter)
+		user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Username: "testuser", Email: "test@example.com"})
+
+		mockRepo.On("FindByEmail", "Test@Example.com").Return(user, nil)
+		mockTokenRepo.On("DeleteByUserID", model.TokenPurposeEmailVerification, "user-id").Return(nil)
+		mockTokenRepo.On("Create", mock.AnythingOfType("*model.OneTimeToken")).Return(nil)
+
+		assert.NoError(t, usecase.Resend(context.Background(), "Test@Example.com"))
+		err := usecase.Resend(context.Background(), "test@example.com")
+		queue.Flush(context.Background())
+
+		assert.NoError(t, err)
+		assert.Len(t, mailer.sent, 1)
+		assert.Equal(t, map[string]int{"email-verification:test@example.com": 2}, limiter.counts)
+		mockRepo.AssertNumberOfCalls(t, "FindByEmail", 1)
+	})
+}
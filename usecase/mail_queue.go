@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"log"
+	"time"
+)
+
+// MailQueue メールの送信処理を受け付け、リクエストとは別のゴルーチンで順に実行する。
+// 宛先のユーザーを探す処理ごと任せることで、登録の有無によって応答時間が変わらないようにする
+type MailQueue struct {
+	jobs chan mailJob
+}
+
+type mailJob struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// NewMailQueue sizeは実行待ちにできる処理の数
+func NewMailQueue(size int) *MailQueue {
+	return &MailQueue{jobs: make(chan mailJob, size)}
+}
+
+// Enqueue 処理を実行待ちにする。呼び出し元を待たせないよう、キューが一杯の場合は記録して破棄する
+func (q *MailQueue) Enqueue(name string, run func(ctx context.Context) error) {
+	select {
+	case q.jobs <- mailJob{name: name, run: run}:
+	default:
+		log.Printf("failed to enqueue %s: mail queue is full", name)
+	}
+}
+
+// Flush 実行待ちの処理を全て実行する
+func (q *MailQueue) Flush(ctx context.Context) {
+	for {
+		select {
+		case job := <-q.jobs:
+			q.execute(ctx, job)
+		default:
+			return
+		}
+	}
+}
+
+// Run ctxがキャンセルされるまで実行待ちの処理を順に実行する
+func (q *MailQueue) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-q.jobs:
+			q.execute(ctx, job)
+		}
+	}
+}
+
+func (q *MailQueue) execute(ctx context.Context, job mailJob) {
+	if err := job.run(ctx); err != nil {
+		log.Printf("failed to %s: %v", job.name, err)
+	}
+}
+
+// allowMail 宛先ごとに送信を受け付ける回数を制限する。ユーザーの有無に関わらず数える。
+// 制限したことを応答で返すと直前にそのアドレスへ送ったことが分かるため、記録するのみとし、呼び出し元は送らずに成功として扱う
+func allowMail(limiter RateLimiter, factory *model.UserFactory, purpose model.TokenPurpose, email string) bool {
+	if limiter.Allow(string(purpose)+":"+factory.CanonicalizeEmail(email), time.Now()) {
+		return true
+	}
+	log.Printf("dropped %s mail: rate limit exceeded", purpose)
+	return false
+}
@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMailQueue(t *testing.T) {
+	t.Run("成功: 受け付けた順に実行し、失敗しても続ける", func(t *testing.T) {
+		queue := NewMailQueue(10)
+		var executed []string
+
+		queue.Enqueue("send first", func(ctx context.Context) error {
+			executed = append(executed, "first")
+			return errors.New("smtp unavailable")
+		})
+		queue.Enqueue("send second", func(ctx context.Context) error {
+			executed = append(executed, "second")
+			return nil
+		})
+		queue.Flush(context.Background())
+
+		assert.Equal(t, []string{"first", "second"}, executed)
+	})
+
+	t.Run("成功: キューが一杯の場合は呼び出し元を待たせずに破棄する", func(t *testing.T) {
+		queue := NewMailQueue(1)
+		var executed int
+		job := func(ctx context.Context) error {
+			executed++
+			return nil
+		}
+
+		queue.Enqueue("send", job)
+		queue.Enqueue("send", job)
+		queue.Flush(context.Background())
+
+		assert.Equal(t, 1, executed)
+	})
+}
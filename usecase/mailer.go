@@ -0,0 +1,15 @@
+package usecase
+
+import "context"
+
+// Mail 送信するメール。本文はテキスト形式
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer メールを送信する
+type Mailer interface {
+	Send(ctx context.Context, mail Mail) error
+}
@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"errors"
+	"time"
+)
+
+// oneTimeTokens 用途ごとに1回限りのトークンを発行・使用する。メールアドレスの確認とパスワードの再設定で共通の処理
+type oneTimeTokens struct {
+	purpose   model.TokenPurpose
+	tokenRepo repository.OneTimeTokenRepository
+	userRepo  repository.UserRepository
+	signer    TokenSigner
+	ids       model.IDGenerator
+	ttl       time.Duration
+	// invalid トークンが使えない場合に返すエラー
+	invalid error
+}
+
+// issue 以前に発行した同じ用途のトークンを削除して新しいトークンを保存し、リンクに載せる署名付きのトークンを返す。
+// 削除と保存を揃えるためトランザクション内で呼び出す
+func (t *oneTimeTokens) issue(ctx context.Context, user *model.User) (*model.OneTimeToken, string, error) {
+	token, raw, err := model.NewOneTimeToken(t.ids, t.purpose, user, t.ttl)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := t.tokenRepo.DeleteByUserID(ctx, t.purpose, user.ID()); err != nil {
+		return nil, "", err
+	}
+	if err := t.tokenRepo.Create(ctx, &token); err != nil {
+		return nil, "", err
+	}
+	return &token, t.signer.Sign(string(t.purpose), raw), nil
+}
+
+// consume 署名付きのトークンを検証して使用済みにし、トークンと更新用にロックした発行先のユーザーを返す。
+// トークンを使った変更と同じトランザクション内で呼び出し、変更が失敗した場合は使用済みにしたこともロールバックさせる。
+// 不正・期限切れ・使用済みのトークンや、ユーザーが存在しない場合はinvalidを返す
+func (t *oneTimeTokens) consume(ctx context.Context, signed string, now time.Time) (*model.OneTimeToken, *model.User, error) {
+	raw, err := t.signer.Verify(string(t.purpose), signed)
+	if err != nil {
+		return nil, nil, t.invalid
+	}
+	token, err := t.tokenRepo.FindByHash(ctx, t.purpose, model.HashOneTimeToken(raw))
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, nil, t.invalid
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if token.IsUsed() || token.IsExpired(now) {
+		return nil, nil, t.invalid
+	}
+	marked, err := t.tokenRepo.MarkUsed(ctx, token.ID, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !marked {
+		return nil, nil, t.invalid
+	}
+
+	user, err := t.userRepo.FindByIDForUpdate(ctx, token.UserID)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, nil, t.invalid
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return token, user, nil
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// EventPublisher アウトボックスのメッセージの配信先。同じメッセージが複数回渡されることがあるため、
+// 受け取る側はメッセージのIDで重複を取り除く
+type EventPublisher interface {
+	Publish(ctx context.Context, message *model.OutboxMessage) error
+}
+
+// OutboxRelayConfig RetryBaseDelayは1回目の失敗後に待つ時間で、失敗するたびに倍にしてRetryMaxDelayで頭打ちにする
+type OutboxRelayConfig struct {
+	BatchSize      int
+	Interval       time.Duration
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// Retention 配信したメッセージを削除するまでの保持期間
+	Retention time.Duration
+}
+
+// OutboxRelay アウトボックスに保存されたイベントを定期的に配信先に渡す。
+// 全ての配信先が受け付けるまで間隔を空けて繰り返すため、少なくとも1回は配信される
+type OutboxRelay struct {
+	outboxRepo repository.OutboxRepository
+	publishers []EventPublisher
+	config     OutboxRelayConfig
+}
+
+func NewOutboxRelay(outboxRepo repository.OutboxRepository, config OutboxRelayConfig, publishers ...EventPublisher) *OutboxRelay {
+	return &OutboxRelay{outboxRepo: outboxRepo, publishers: publishers, config: config}
+}
+
+// Relay 配信時期を迎えたメッセージを保存した順に配信し、配信できた件数を返す。
+// 配信に失敗したメッセージは次に試す日時を遅らせ、残りのメッセージの配信を続ける
+func (r *OutboxRelay) Relay(ctx context.Context) (int, error) {
+	messages, err := r.outboxRepo.FindPending(ctx, time.Now(), r.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, message := range messages {
+		if err := r.publish(ctx, message); err != nil {
+			attempts := message.Attempts + 1
+			if err := r.outboxRepo.MarkFailed(ctx, message.ID, attempts, time.Now().Add(r.backoff(attempts)), err.Error()); err != nil {
+				return published, err
+			}
+			log.Printf("failed to publish event %s (%s, attempt %d): %v", message.ID, message.EventType, attempts, err)
+			continue
+		}
+		if err := r.outboxRepo.MarkPublished(ctx, message.ID, time.Now()); err != nil {
+			return published, err
+		}
+		published++
+	}
+	return published, nil
+}
+
+// publish 全ての配信先に渡す。1つでも失敗した場合は、受け付けた配信先も含めて後で再度配信する
+func (r *OutboxRelay) publish(ctx context.Context, message *model.OutboxMessage) error {
+	for _, publisher := range r.publishers {
+		if err := publisher.Publish(ctx, message); err != nil {
+			return fmt.Errorf("%T: %w", publisher, err)
+		}
+	}
+	return nil
+}
+
+// backoff attempts回失敗した後に次に試すまで待つ時間
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	return exponentialBackoff(r.config.RetryBaseDelay, r.config.RetryMaxDelay, attempts)
+}
+
+// exponentialBackoff 1回目の失敗後はbaseDelay待ち、失敗するたびに倍にしてmaxDelayで頭打ちにする
+func exponentialBackoff(baseDelay time.Duration, maxDelay time.Duration, attempts int) time.Duration {
+	delay := baseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	return delay
+}
+
+// Sweep 保持期間を過ぎた配信済みのメッセージを削除し、削除した件数を返す
+func (r *OutboxRelay) Sweep(ctx context.Context) (int64, error) {
+	return r.outboxRepo.DeletePublished(ctx, time.Now().Add(-r.config.Retention))
+}
+
+// Run ctxがキャンセルされるまでIntervalごとにRelayとSweepを実行する
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.Relay(ctx); err != nil {
+				log.Printf("failed to relay outbox messages: %v", err)
+			}
+			if _, err := r.Sweep(ctx); err != nil {
+				log.Printf("failed to sweep outbox messages: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockOutboxRepository is a mock implementation of OutboxRepository
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepository) FindPending(ctx context.Context, now time.Time, limit int) ([]*model.OutboxMessage, error) {
+	args := m.Called(now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.OutboxMessage), args.Error(1)
+}
+
+func (m *MockOutboxRepository) MarkPublished(ctx context.Context, id string, publishedAt time.Time) error {
+	args := m.Called(id, publishedAt)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) MarkFailed(ctx context.Context, id string, attempts int, nextAttemptAt time.Time, lastError string) error {
+	args := m.Called(id, attempts, nextAttemptAt, lastError)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) DeletePublished(ctx context.Context, publishedBefore time.Time) (int64, error) {
+	args := m.Called(publishedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// stubEventPublisher 渡されたメッセージのIDを記録する。failに含まれるIDのメッセージは配信に失敗する
+type stubEventPublisher struct {
+	published []string
+	fail      map[string]bool
+}
+
+func (s *stubEventPublisher) Publish(ctx context.Context, message *model.OutboxMessage) error {
+	if s.fail[message.ID] {
+		return errors.New("connection refused")
+	}
+	s.published = append(s.published, message.ID)
+	return nil
+}
+
+var outboxRelayConfig = OutboxRelayConfig{
+	BatchSize:      10,
+	Interval:       time.Second,
+	RetryBaseDelay: time.Second,
+	RetryMaxDelay:  10 * time.Second,
+	Retention:      24 * time.Hour,
+}
+
+func TestOutboxRelay_Relay(t *testing.T) {
+	t.Run("成功: 全ての配信先に渡して配信済みにする", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(MockOutboxRepository)
+		first, second := &stubEventPublisher{}, &stubEventPublisher{}
+		relay := NewOutboxRelay(mockRepo, outboxRelayConfig, first, second)
+		messages := []*model.OutboxMessage{{ID: "message-1"}, {ID: "message-2"}}
+		mockRepo.On("FindPending", mock.AnythingOfType("time.Time"), 10).Return(messages, nil)
+		mockRepo.On("MarkPublished", mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+
+		// Act
+		published, err := relay.Relay(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 2, published)
+		assert.Equal(t, []string{"message-1", "message-2"}, first.published)
+		assert.Equal(t, []string{"message-1", "message-2"}, second.published)
+		mockRepo.AssertNumberOfCalls(t, "MarkPublished", 2)
+	})
+
+	t.Run("成功: 失敗したメッセージは間隔を空けて再度配信し、残りの配信を続ける", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(MockOutboxRepository)
+		publisher := &stubEventPublisher{fail: map[string]bool{"message-1": true}}
+		relay := NewOutboxRelay(mockRepo, outboxRelayConfig, publisher)
+		messages := []*model.OutboxMessage{{ID: "message-1", Attempts: 2}, {ID: "message-2"}}
+		mockRepo.On("FindPending", mock.AnythingOfType("time.Time"), 10).Return(messages, nil)
+		var nextAttemptAt time.Time
+		mockRepo.On("MarkFailed", "message-1", 3, mock.AnythingOfType("time.Time"), mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+			nextAttemptAt = args.Get(2).(time.Time)
+		}).Return(nil)
+		mockRepo.On("MarkPublished", "message-2", mock.AnythingOfType("time.Time")).Return(nil)
+
+		// Act
+		published, err := relay.Relay(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 1, published)
+		assert.WithinDuration(t, time.Now().Add(4*time.Second), nextAttemptAt, time.Second)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("失敗: メッセージを取得できない", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(MockOutboxRepository)
+		relay := NewOutboxRelay(mockRepo, outboxRelayConfig, &stubEventPublisher{})
+		mockRepo.On("FindPending", mock.AnythingOfType("time.Time"), 10).Return(nil, errors.New("database error"))
+
+		// Act
+		published, err := relay.Relay(context.Background())
+
+		// Assert
+		assert.EqualError(t, err, "database error")
+		assert.Zero(t, published)
+	})
+}
+
+func TestOutboxRelay_Backoff(t *testing.T) {
+	relay := NewOutboxRelay(new(MockOutboxRepository), outboxRelayConfig)
+
+	assert.Equal(t, time.Second, relay.backoff(1))
+	assert.Equal(t, 2*time.Second, relay.backoff(2))
+	assert.Equal(t, 8*time.Second, relay.backoff(4))
+	assert.Equal(t, 10*time.Second, relay.backoff(5))
+	assert.Equal(t, 10*time.Second, relay.backoff(100))
+}
+
+func TestOutboxRelay_Sweep(t *testing.T) {
+	mockRepo := new(MockOutboxRepository)
+	relay := NewOutboxRelay(mockRepo, outboxRelayConfig)
+
+	var publishedBefore time.Time
+	mockRepo.On("DeletePublished", mock.AnythingOfType("time.Time")).Run(func(args mock.Arguments) {
+		publishedBefore = args.Get(0).(time.Time)
+	}).Return(int64(3), nil)
+
+	deleted, err := relay.Sweep(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+	assert.WithinDuration(t, time.Now().Add(-24*time.Hour), publishedBefore, time.Minute)
+}
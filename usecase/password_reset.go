@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+)
+
+type PasswordResetUseCase interface {
+	// Forgot 再設定用のトークンを発行してメールを送る。登録の有無を推測されないよう、
+	// 送信はキューに任せ、該当するユーザーがいない場合や同じメールアドレスへの回数が上限を超えた場合もエラーにしない
+	Forgot(ctx context.Context, email string) error
+	// Reset トークンを検証してパスワードを変更し、全てのリフレッシュトークンを失効させる
+	Reset(ctx context.Context, token string, password string) error
+}
+
+// PasswordResetConfig URLには再設定用の画面を指定し、クエリパラメータtokenにトークンを付けてメールに記載する
+type PasswordResetConfig struct {
+	TTL time.Duration
+	URL string
+}
+
+type passwordResetUsecase struct {
+	userRepo         repository.UserRepository
+	tokenRepo        repository.OneTimeTokenRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	auditLogRepo     repository.AuditLogRepository
+	txManager        repository.TransactionManager
+	tokens           oneTimeTokens
+	mailer           Mailer
+	queue            *MailQueue
+	limiter          RateLimiter
+	factory          *model.UserFactory
+	ids              model.IDGenerator
+	config           PasswordResetConfig
+}
+
+func NewPasswordResetUsecase(userRepo repository.UserRepository, tokenRepo repository.OneTimeTokenRepository, refreshTokenRepo repository.RefreshTokenRepository, auditLogRepo repository.AuditLogRepository, txManager repository.TransactionManager, signer TokenSigner, mailer Mailer, queue *MailQueue, limiter RateLimiter, factory *model.UserFactory, ids model.IDGenerator, config PasswordResetConfig) PasswordResetUseCase {
+	return &passwordResetUsecase{
+		userRepo:         userRepo,
+		tokenRepo:        tokenRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		auditLogRepo:     auditLogRepo,
+		txManager:        txManager,
+		tokens: oneTimeTokens{
+			purpose:   model.TokenPurposePasswordReset,
+			tokenRepo: tokenRepo,
+			userRepo:  userRepo,
+			signer:    signer,
+			ids:       ids,
+			ttl:       config.TTL,
+			invalid:   errInvalidResetToken,
+		},
+		mailer:  mailer,
+		queue:   queue,
+		limiter: limiter,
+		factory: factory,
+		ids:     ids,
+		config:  config,
+	}
+}
+
+var (
+	errInvalidResetToken = model.NewValidationError(model.CodeInvalidToken, "再設定トークンが不正か有効期限が切れています")
+	errResetEmailChanged = model.NewValidationError(model.CodeInvalidToken, "メールアドレスが変更されているため再設定できません")
+)
+
+func (u *passwordResetUsecase) Forgot(ctx context.Context, email string) error {
+	if !allowMail(u.limiter, u.factory, model.TokenPurposePasswordReset, email) {
+		return nil
+	}
+	u.queue.Enqueue("send password reset email", func(ctx context.Context) error {
+		return u.forgot(ctx, email)
+	})
+	return nil
+}
+
+func (u *passwordResetUsecase) forgot(ctx context.Context, email string) error {
+	user, err := u.userRepo.FindByEmail(ctx, email)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return u.sendResetMail(ctx, user)
+}
+
+func (u *passwordResetUsecase) sendResetMail(ctx context.Context, user *model.User) error {
+	var token *model.OneTimeToken
+	var signed string
+	err := u.txManager.Do(ctx, func(ctx context.Context) error {
+		var err error
+		token, signed, err = u.tokens.issue(ctx, user)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	link := u.config.URL + "?token=" + url.QueryEscape(signed)
+	return u.mailer.Send(ctx, Mail{
+		To:      user.Email(),
+		Subject: "パスワードの再設定",
+		Body: fmt.Sprintf("%s 様\n\nパスワードを再設定するには、次のURLを開いてください。\n%s\n\nこのURLは%sまで有効です。\n心当たりがない場合は、このメールを破棄してください。パスワードは変更されません。\n",
+			user.Username(), link, token.ExpiresAt.Format("2006-01-02 15:04")),
+	})
+}
+
+func (u *passwordResetUsecase) Reset(ctx context.Context, signed string, password string) error {
+	var user *model.User
+	err := u.txManager.Do(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		token, found, err := u.tokens.consume(ctx, signed, now)
+		if err != nil {
+			return err
+		}
+		// 古いメールアドレスに届いたリンクで再設定されないよう、発行後にメールアドレスが変わっていれば拒否する
+		if token.Email != found.Email() {
+			return errResetEmailChanged
+		}
+		user = found
+		before := *user
+		// パスワードが条件を満たさない場合はロールバックされ、同じトークンで再度試せる
+		if err := user.ChangePassword(u.factory, password); err != nil {
+			return err
+		}
+		if _, err := u.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, u.ids, u.auditLogRepo, model.AuditActionUserPasswordReset, &before, user); err != nil {
+			return err
+		}
+		if err := u.tokenRepo.DeleteByUserID(ctx, model.TokenPurposePasswordReset, user.ID()); err != nil {
+			return err
+		}
+		return u.refreshTokenRepo.RevokeByUserID(ctx, user.ID(), now)
+	})
+	if err != nil {
+		return err
+	}
+
+	// 本人以外による変更に気付けるよう通知する。変更は完了しているため、送れなくてもエラーにしない
+	err = u.mailer.Send(ctx, Mail{
+		To:      user.Email(),
+		Subject: "パスワードが変更されました",
+		Body:    fmt.Sprintf("%s 様\n\nパスワードが再設定され、全ての端末からログアウトしました。\n心当たりがない場合は、至急お問い合わせください。\n", user.Username()),
+	})
+	if err != nil {
+		log.Printf("failed to send password change notification: %v", err)
+	}
+	return nil
+}
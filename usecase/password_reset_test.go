@@ -0,0 +1,193 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// failingMailer 常に送信に失敗する
+type failingMailer struct{}
+
+func (failingMailer) Send(ctx context.Context, mail Mail) error {
+	return errors.New("smtp error")
+}
+
+var passwordResetConfig = PasswordResetConfig{TTL: time.Hour, URL: "https://example.com/reset-password"}
+
+func TestPasswordResetUsecase_Forgot(t *testing.T) {
+	t.Run("成功: ユーザーの検索と再設定メールの送信はキューで実行する", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockOneTimeTokenRepository)
+		mailer := &stubMailer{}
+		queue := NewMailQueue(10)
+		usecase := NewPasswordResetUsecase(mockRepo, mockTokenRepo, new(MockRefreshTokenRepository), &stubAuditLogRepository{}, &stubTransactionManager{}, stubTokenSigner{}, mailer, queue, newStubRateLimiter(10), testUserFactory, testIDs, passwordResetConfig)
+		user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Username: "testuser", Email: "test@example.com"})
+
+		mockRepo.On("FindByEmail", "test@example.com").Return(user, nil)
+		mockTokenRepo.On("DeleteByUserID", model.TokenPurposePasswordReset, "user-id").Return(nil)
+		mockTokenRepo.On("Create", mock.AnythingOfType("*model.OneTimeToken")).Return(nil)
+
+		err := usecase.Forgot(context.Background(), "test@example.com")
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "FindByEmail", mock.Anything)
+		assert.Empty(t, mailer.sent)
+
+		queue.Flush(context.Background())
+
+		assert.Len(t, mailer.sent, 1)
+		assert.Contains(t, mailer.sent[0].Body, "https://example.com/reset-password?token=password-reset%3A")
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("成功: ユーザーがいない場合も送信に失敗した場合もエラーにしない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockOneTimeTokenRepository)
+		queue := NewMailQueue(10)
+		usecase := NewPasswordResetUsecase(mockRepo, mockTokenRepo, new(MockRefreshTokenRepository), &stubAuditLogRepository{}, &stubTransactionManager{}, stubTokenSigner{}, failingMailer{}, queue, newStubRateLimiter(10), testUserFactory, testIDs, passwordResetConfig)
+		user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Email: "test@example.com"})
+
+		mockRepo.On("FindByEmail", "unknown@example.com").Return(nil, model.NewNotFoundError("ユーザーが見つかりません", nil))
+		mockRepo.On("FindByEmail", "test@example.com").Return(user, nil)
+		mockTokenRepo.On("DeleteByUserID", model.TokenPurposePasswordReset, "user-id").Return(nil)
+		mockTokenRepo.On("Create", mock.AnythingOfType("*model.OneTimeToken")).Return(nil)
+
+		assert.NoError(t, usecase.Forgot(context.Background(), "unknown@example.com"))
+		assert.NoError(t, usecase.Forgot(context.Background(), "test@example.com"))
+		queue.Flush(context.Background())
+
+		mockRepo.AssertExpectations(t)
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("成功: 同じメールアドレスへの回数が上限を超えた場合はエラーにせず送らない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockOneTimeTokenRepository)
+		mailer := &stubMailer{}
+		queue := NewMailQueue(10)
+		limiter := newStubRateLimiter(1)
+		usecase := NewPasswordResetUsecase(mockRepo, mockTokenRepo, new(MockRefreshTokenRepository), &stubAuditLogRepository{}, &stubTransactionManager{}, stubTokenSigner{}, mailer, queue, limiter, testUserFactory, testIDs, passwordResetConfig)
+		user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Username: "testuser", Email: "test@example.com"})
+
+		mockRepo.On("FindByEmail", "test@example.com").Return(user, nil)
+		mockTokenRepo.On("DeleteByUserID", model.TokenPurposePasswordReset, "user-id").Return(nil)
+		mockTokenRepo.On("Create", mock.AnythingOfType("*model.OneTimeToken")).Return(nil)
+
+		assert.NoError(t, usecase.Forgot(context.Background(), "test@example.com"))
+		err := usecase.Forgot(context.Background(), "test@example.com")
+		queue.Flush(context.Background())
+
+		assert.NoError(t, err)
+		assert.Len(t, mailer.sent, 1)
+		assert.Equal(t, map[string]int{"password-reset:test@example.com": 2}, limiter.counts)
+		mockRepo.AssertNumberOfCalls(t, "FindByEmail", 1)
+	})
+}
+
+func TestPasswordResetUsecase_Reset(t *testing.T) {
+	newToken := func(t *testing.T) (*model.OneTimeToken, string) {
+		user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Email: "test@example.com"})
+		token, raw, err := model.NewOneTimeToken(testIDs, model.TokenPurposePasswordReset, user, time.Hour)
+		assert.NoError(t, err)
+		return &token, stubTokenSigner{}.Sign(string(model.TokenPurposePasswordReset), raw)
+	}
+
+	t.Run("成功: パスワードを変更してセッションを失効させ、通知する", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockOneTimeTokenRepository)
+		mockRefreshTokenRepo := new(MockRefreshTokenRepository)
+		mailer := &stubMailer{}
+		usecase := NewPasswordResetUsecase(mockRepo, mockTokenRepo, mockRefreshTokenRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, stubTokenSigner{}, mailer, NewMailQueue(10), newStubRateLimiter(10), testUserFactory, testIDs, passwordResetConfig)
+		token, signed := newToken(t)
+		user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Username: "testuser", Email: "test@example.com", PasswordHash: "old-hash", Version: 1})
+
+		mockTokenRepo.On("FindByHash", model.TokenPurposePasswordReset, token.TokenHash).Return(token, nil)
+		mockTokenRepo.On("MarkUsed", token.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
+		mockRepo.On("FindByIDForUpdate", "user-id").Return(user, nil)
+		mockRepo.On("Update", user).Return(user, nil)
+		mockTokenRepo.On("DeleteByUserID", model.TokenPurposePasswordReset, "user-id").Return(nil)
+		mockRefreshTokenRepo.On("RevokeByUserID", "user-id", mock.AnythingOfType("time.Time")).Return(nil)
+
+		err := usecase.Reset(context.Background(), signed, "newpassword1")
+
+		assert.NoError(t, err)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.PasswordHash()), []byte("newpassword1")))
+		assert.Len(t, mailer.sent, 1)
+		assert.Equal(t, "パスワードが変更されました", mailer.sent[0].Subject)
+		mockRepo.AssertExpectations(t)
+		mockTokenRepo.AssertExpectations(t)
+		mockRefreshTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("失敗: 条件を満たさないパスワード", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockOneTimeTokenRepository)
+		mockRefreshTokenRepo := new(MockRefreshTokenRepository)
+		usecase := NewPasswordResetUsecase(mockRepo, mockTokenRepo, mockRefreshTokenRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, stubTokenSigner{}, &stubMailer{}, NewMailQueue(10), newStubRateLimiter(10), testUserFactory, testIDs, passwordResetConfig)
+		token, signed := newToken(t)
+		user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Email: "test@example.com", PasswordHash: "old-hash"})
+
+		mockTokenRepo.On("FindByHash", model.TokenPurposePasswordReset, token.TokenHash).Return(token, nil)
+		mockTokenRepo.On("MarkUsed", token.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
+		mockRepo.On("FindByIDForUpdate", "user-id").Return(user, nil)
+
+		err := usecase.Reset(context.Background(), signed, "short")
+
+		assert.ErrorIs(t, err, model.ErrValidation)
+		assert.Equal(t, "old-hash", user.PasswordHash())
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+		mockRefreshTokenRepo.AssertNotCalled(t, "RevokeByUserID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("失敗: 発行後にメールアドレスが変わっている", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockOneTimeTokenRepository)
+		mockRefreshTokenRepo := new(MockRefreshTokenRepository)
+		usecase := NewPasswordResetUsecase(mockRepo, mockTokenRepo, mockRefreshTokenRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, stubTokenSigner{}, &stubMailer{}, NewMailQueue(10), newStubRateLimiter(10), testUserFactory, testIDs, passwordResetConfig)
+		token, signed := newToken(t)
+		user := model.ReconstructUser(model.UserSnapshot{ID: "user-id", Email: "test@example.com", PasswordHash: "old-hash"})
+		assert.NoError(t, user.ChangeEmail(testUserFactory, "new@example.com"))
+
+		mockTokenRepo.On("FindByHash", model.TokenPurposePasswordReset, token.TokenHash).Return(token, nil)
+		mockTokenRepo.On("MarkUsed", token.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
+		mockRepo.On("FindByIDForUpdate", "user-id").Return(user, nil)
+
+		err := usecase.Reset(context.Background(), signed, "newpassword1")
+
+		var domainErr *model.Error
+		assert.ErrorAs(t, err, &domainErr)
+		assert.Equal(t, model.CodeInvalidToken, domainErr.Code)
+		assert.Equal(t, "old-hash", user.PasswordHash())
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+		mockRefreshTokenRepo.AssertNotCalled(t, "RevokeByUserID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("失敗: 再設定できないトークン", func(t *testing.T) {
+		used, usedSigned := newToken(t)
+		usedAt := time.Now()
+		used.UsedAt = &usedAt
+		expired, expiredSigned := newToken(t)
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+		_, otherPurpose := newToken(t)
+		otherPurpose = stubTokenSigner{}.Sign(string(model.TokenPurposeEmailVerification), otherPurpose[len(model.TokenPurposePasswordReset)+1:])
+
+		mockTokenRepo := new(MockOneTimeTokenRepository)
+		usecase := NewPasswordResetUsecase(new(MockUserRepository), mockTokenRepo, new(MockRefreshTokenRepository), &stubAuditLogRepository{}, &stubTransactionManager{}, stubTokenSigner{}, &stubMailer{}, NewMailQueue(10), newStubRateLimiter(10), testUserFactory, testIDs, passwordResetConfig)
+		mockTokenRepo.On("FindByHash", model.TokenPurposePasswordReset, used.TokenHash).Return(used, nil)
+		mockTokenRepo.On("FindByHash", model.TokenPurposePasswordReset, expired.TokenHash).Return(expired, nil)
+		mockTokenRepo.On("FindByHash", model.TokenPurposePasswordReset, mock.Anything).Return(nil, model.NewNotFoundError("トークンが見つかりません", nil))
+
+		for _, signed := range []string{"unsigned", "password-reset:unknown", usedSigned, expiredSigned, otherPurpose} {
+			err := usecase.Reset(context.Background(), signed, "newpassword1")
+
+			assert.ErrorIs(t, err, model.ErrValidation, signed)
+		}
+	})
+}
@@ -0,0 +1,9 @@
+package usecase
+
+import "time"
+
+// RateLimiter キーごとに一定時間内に受け付ける回数を制限する
+type RateLimiter interface {
+	// Allow 回数を数え、上限を超えている場合はfalseを返す
+	Allow(key string, now time.Time) bool
+}
@@ -0,0 +1,40 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"log"
+	"time"
+)
+
+// RefreshTokenSweeper 有効期限切れのリフレッシュトークンを定期的に削除する
+type RefreshTokenSweeper struct {
+	refreshTokenRepo repository.RefreshTokenRepository
+	interval         time.Duration
+}
+
+func NewRefreshTokenSweeper(refreshTokenRepo repository.RefreshTokenRepository, interval time.Duration) *RefreshTokenSweeper {
+	return &RefreshTokenSweeper{refreshTokenRepo: refreshTokenRepo, interval: interval}
+}
+
+// Sweep 有効期限切れのトークンを削除し、削除した件数を返す
+func (s *RefreshTokenSweeper) Sweep(ctx context.Context) (int64, error) {
+	return s.refreshTokenRepo.DeleteExpired(ctx, time.Now())
+}
+
+// Run ctxがキャンセルされるまでintervalごとにSweepを実行する
+func (s *RefreshTokenSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Sweep(ctx); err != nil {
+				log.Printf("failed to sweep refresh tokens: %v", err)
+			}
+		}
+	}
+}
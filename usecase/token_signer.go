@@ -0,0 +1,8 @@
+package usecase
+
+// TokenSigner 利用者に渡すトークンに用途ごとの署名を付け、改ざんや他の用途への流用を検出する
+type TokenSigner interface {
+	Sign(purpose string, token string) string
+	// Verify 署名を検証し、署名を除いたトークンを返す
+	Verify(purpose string, signed string) (string, error)
+}
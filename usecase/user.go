@@ -3,73 +3,275 @@ package usecase
 import (
 	"api-sample-with-echo-ddd/domain/model"
 	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"errors"
+	"log"
+	"time"
 )
 
 type UserUseCase interface {
-	Create(username string, email string, password string) (*model.User, error)
-	FindByID(id string) (*model.User, error)
-	FindAll() ([]*model.User, error)
-	Update(id string, username string, email string, password string) (*model.User, error)
-	Delete(id string) error
+	Create(ctx context.Context, username string, email string, password string) (*model.User, error)
+	FindByID(ctx context.Context, id string) (*model.User, error)
+	FindAll(ctx context.Context, query repository.UserQuery) (*repository.UserPage, error)
+	// Update versionは呼び出し元が参照したユーザーのバージョン。0の場合は検証しない
+	Update(ctx context.Context, id string, version int, username string, email string, password string) (*model.User, error)
+	Patch(ctx context.Context, id string, version int, changes model.UserChanges) (*model.User, error)
+	Delete(ctx context.Context, id string, version int) error
+	// Suspend Reinstate Deactivate 管理者がアカウントの状態を変更する。reasonは変更の理由
+	Suspend(ctx context.Context, id string, version int, reason string) (*model.User, error)
+	Reinstate(ctx context.Context, id string, version int, reason string) (*model.User, error)
+	Deactivate(ctx context.Context, id string, version int, reason string) (*model.User, error)
+	// Restore 論理削除されたユーザーを復元する
+	Restore(ctx context.Context, id string, version int) (*model.User, error)
+	// AssignRole 役割を変更する。アクセストークンに含まれる役割は再発行されるまで変わらない
+	AssignRole(ctx context.Context, id string, version int, role string) (*model.User, error)
 }
 
 type userUsecase struct {
-	userRepo repository.UserRepository
+	userRepo     repository.UserRepository
+	auditLogRepo repository.AuditLogRepository
+	txManager    repository.TransactionManager
+	verification EmailVerificationSender
+	policy       AccessPolicy
+	factory      *model.UserFactory
+	ids          model.IDGenerator
 }
 
-func NewUserUsecase(userRepo repository.UserRepository) UserUseCase {
-	return &userUsecase{userRepo: userRepo}
+func NewUserUsecase(userRepo repository.UserRepository, auditLogRepo repository.AuditLogRepository, txManager repository.TransactionManager, verification EmailVerificationSender, policy AccessPolicy, factory *model.UserFactory, ids model.IDGenerator) UserUseCase {
+	return &userUsecase{userRepo: userRepo, auditLogRepo: auditLogRepo, txManager: txManager, verification: verification, policy: policy, factory: factory, ids: ids}
 }
 
-func (u *userUsecase) Create(username string, email string, password string) (*model.User, error) {
-	user, err := model.NewUser(username, email, password)
+func (u *userUsecase) Create(ctx context.Context, username string, email string, password string) (*model.User, error) {
+	user, err := u.factory.NewUser(username, email, password)
 	if err != nil {
 		return nil, err
 	}
+	if err := u.checkUnique(ctx, &user, true, true); err != nil {
+		return nil, err
+	}
 
-	if _, err := u.userRepo.Create(&user); err != nil {
+	err = u.txManager.Do(ctx, func(ctx context.Context) error {
+		if _, err := u.userRepo.Create(ctx, &user); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.ids, u.auditLogRepo, model.AuditActionUserCreated, nil, &user)
+	})
+	if err != nil {
 		return nil, err
 	}
+	u.sendVerification(ctx, &user)
 	return &user, nil
 }
 
-func (u *userUsecase) FindByID(id string) (*model.User, error) {
-	user, err := u.userRepo.FindByID(id)
+func (u *userUsecase) FindByID(ctx context.Context, id string) (*model.User, error) {
+	user, err := u.userRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
-func (u *userUsecase) FindAll() ([]*model.User, error) {
-	users, err := u.userRepo.FindAll()
+// FindAll 論理削除されたユーザーを含める場合は、その権限があるかを確認する
+func (u *userUsecase) FindAll(ctx context.Context, query repository.UserQuery) (*repository.UserPage, error) {
+	if query.IncludeDeleted {
+		if err := u.policy.Authorize(ctx, model.PermissionReadDeletedUsers, ""); err != nil {
+			return nil, err
+		}
+	}
+	page, err := u.userRepo.FindAll(ctx, query)
 	if err != nil {
 		return nil, err
 	}
-	return users, nil
+	return page, nil
 }
 
-func (u *userUsecase) Update(id string, username string, email string, password string) (*model.User, error) {
-	user, err := u.userRepo.FindByID(id)
+func (u *userUsecase) Update(ctx context.Context, id string, version int, username string, email string, password string) (*model.User, error) {
+	return u.Patch(ctx, id, version, model.UserChanges{Username: &username, Email: &email, Password: &password})
+}
+
+func (u *userUsecase) Patch(ctx context.Context, id string, version int, changes model.UserChanges) (*model.User, error) {
+	var user *model.User
+	var previousEmail string
+	err := u.txManager.Do(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = u.userRepo.FindByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := user.CheckVersion(version); err != nil {
+			return err
+		}
+		before := *user
+		previousEmail = user.Email()
+		if err := user.Change(u.factory, changes); err != nil {
+			return err
+		}
+		if changes.IsEmpty() {
+			// 変更が無ければ保存せず、バージョンとETagを変えない
+			return nil
+		}
+		if err := u.checkUnique(ctx, user, changes.Username != nil, changes.Email != nil); err != nil {
+			return err
+		}
+		if _, err := u.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.ids, u.auditLogRepo, model.AuditActionUserUpdated, &before, user)
+	})
 	if err != nil {
 		return nil, err
 	}
-	user.Username = username
-	user.Email = email
-	user.Password = password
-	if _, err := u.userRepo.Update(user); err != nil {
+	if user.Email() != previousEmail {
+		u.sendVerification(ctx, user)
+	}
+	return user, nil
+}
+
+// sendVerification 確認メールを送る。送れなくてもユーザーは再送を依頼できるため、登録や変更は失敗させない
+func (u *userUsecase) sendVerification(ctx context.Context, user *model.User) {
+	if err := u.verification.Send(ctx, user); err != nil {
+		log.Printf("failed to send verification email: %v", err)
+	}
+}
+
+func (u *userUsecase) Delete(ctx context.Context, id string, version int) error {
+	return u.txManager.Do(ctx, func(ctx context.Context) error {
+		user, err := u.userRepo.FindByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := user.CheckVersion(version); err != nil {
+			return err
+		}
+		before := *user
+		if err := user.Delete(time.Now()); err != nil {
+			return err
+		}
+		if err := u.userRepo.Delete(ctx, user); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.ids, u.auditLogRepo, model.AuditActionUserDeleted, &before, user)
+	})
+}
+
+func (u *userUsecase) Suspend(ctx context.Context, id string, version int, reason string) (*model.User, error) {
+	return u.changeStatus(ctx, id, version, model.AuditActionUserSuspended, model.PermissionSuspendUsers, func(user *model.User) error {
+		return user.Suspend(reason, time.Now())
+	})
+}
+
+func (u *userUsecase) Reinstate(ctx context.Context, id string, version int, reason string) (*model.User, error) {
+	return u.changeStatus(ctx, id, version, model.AuditActionUserReinstated, model.PermissionSuspendUsers, func(user *model.User) error {
+		return user.Reinstate(reason, time.Now())
+	})
+}
+
+func (u *userUsecase) Deactivate(ctx context.Context, id string, version int, reason string) (*model.User, error) {
+	return u.changeStatus(ctx, id, version, model.AuditActionUserDeactivated, model.PermissionDeactivateUsers, func(user *model.User) error {
+		return user.Deactivate(reason, time.Now())
+	})
+}
+
+func (u *userUsecase) Restore(ctx context.Context, id string, version int) (*model.User, error) {
+	var user *model.User
+	err := u.txManager.Do(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = u.userRepo.FindDeletedByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := user.CheckVersion(version); err != nil {
+			return err
+		}
+		before := *user
+		if err := user.Restore(time.Now()); err != nil {
+			return err
+		}
+		if _, err := u.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.ids, u.auditLogRepo, model.AuditActionUserRestored, &before, user)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
-func (u *userUsecase) Delete(id string) error {
-	user, err := u.userRepo.FindByID(id)
+func (u *userUsecase) AssignRole(ctx context.Context, id string, version int, role string) (*model.User, error) {
+	return u.changeStatus(ctx, id, version, model.AuditActionUserRoleAssigned, model.PermissionAssignRoles, func(user *model.User) error {
+		return user.AssignRole(role, time.Now())
+	})
+}
+
+// changeStatus 管理者による変更に共通する処理。行ロックを取得して、利用者より上位の役割のユーザーでないこととバージョンを確認した上で
+// changeを適用して保存し、actionとして監査ログに記録する
+func (u *userUsecase) changeStatus(ctx context.Context, id string, version int, action model.AuditAction, permission model.Permission, change func(user *model.User) error) (*model.User, error) {
+	var user *model.User
+	err := u.txManager.Do(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = u.userRepo.FindByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := u.policy.AuthorizeTarget(ctx, permission, user); err != nil {
+			return err
+		}
+		if err := user.CheckVersion(version); err != nil {
+			return err
+		}
+		before := *user
+		if err := change(user); err != nil {
+			return err
+		}
+		if _, err := u.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.ids, u.auditLogRepo, action, &before, user)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if err := u.userRepo.Delete(user); err != nil {
-		return err
+	return user, nil
+}
+
+// checkUnique ユーザー名とメールアドレスが他のユーザーに使われていないか確認する。
+// 同時に登録された場合はここでは検出できないため、最終的にはDBの一意制約で防ぐ
+func (u *userUsecase) checkUnique(ctx context.Context, user *model.User, username bool, email bool) error {
+	var fields []model.FieldError
+	if username {
+		found, err := u.userRepo.FindByUsername(ctx, user.Username())
+		taken, err := isTakenByOther(user, found, err)
+		if err != nil {
+			return err
+		}
+		if taken {
+			fields = append(fields, model.FieldUsernameTaken)
+		}
+	}
+	if email {
+		found, err := u.userRepo.FindByEmail(ctx, user.Email())
+		taken, err := isTakenByOther(user, found, err)
+		if err != nil {
+			return err
+		}
+		if taken {
+			fields = append(fields, model.FieldEmailTaken)
+		}
+	}
+	if len(fields) > 0 {
+		return model.NewFieldsConflictError(fields, nil)
 	}
 	return nil
 }
+
+// isTakenByOther 検索結果foundがuser以外のユーザーであればtrueを返す。見つからなかった場合はfalse
+func isTakenByOther(user *model.User, found *model.User, err error) (bool, error) {
+	if errors.Is(err, model.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return found.ID() != user.ID(), nil
+}
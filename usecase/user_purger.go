@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"log"
+	"time"
+)
+
+// UserPurger 論理削除から保持期間を過ぎたユーザーを定期的に完全に削除する
+type UserPurger struct {
+	userRepo  repository.UserRepository
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewUserPurger(userRepo repository.UserRepository, retention time.Duration, interval time.Duration) *UserPurger {
+	return &UserPurger{userRepo: userRepo, retention: retention, interval: interval}
+}
+
+// Purge 保持期間を過ぎたユーザーを完全に削除し、削除した件数を返す
+func (p *UserPurger) Purge(ctx context.Context) (int64, error) {
+	return p.userRepo.Purge(ctx, time.Now().Add(-p.retention))
+}
+
+// Run ctxがキャンセルされるまでintervalごとにPurgeを実行する
+func (p *UserPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := p.Purge(ctx); err != nil {
+				log.Printf("failed to purge deleted users: %v", err)
+			}
+		}
+	}
+}
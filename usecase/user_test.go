@@ -2,12 +2,15 @@ package usecase
 
 import (
 	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
 	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // MockUserRepository is a mock implementation of UserRepository
@@ -15,12 +18,12 @@ type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) Create(user *model.User) (*model.User, error) {
+func (m *MockUserRepository) Create(ctx context.Context, user *model.User) (*model.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserRepository) FindByID(id string) (*model.User, error) {
+func (m *MockUserRepository) FindByID(ctx context.Context, id string) (*model.User, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -28,51 +31,156 @@ func (m *MockUserRepository) FindByID(id string) (*model.User, error) {
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserRepository) FindAll() ([]*model.User, error) {
-	args := m.Called()
-	return args.Get(0).([]*model.User), args.Error(1)
+func (m *MockUserRepository) FindByIDForUpdate(ctx context.Context, id string) (*model.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindDeletedByIDForUpdate(ctx context.Context, id string) (*model.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	args := m.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindByUsername(ctx context.Context, username string) (*model.User, error) {
+	args := m.Called(username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Update(user *model.User) (*model.User, error) {
+func (m *MockUserRepository) FindAll(ctx context.Context, query repository.UserQuery) (*repository.UserPage, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.UserPage), args.Error(1)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, user *model.User) (*model.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Delete(user *model.User) error {
+func (m *MockUserRepository) ReplacePasswordHash(ctx context.Context, id string, currentHash string, newHash string) (bool, error) {
+	args := m.Called(id, currentHash, newHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, user *model.User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// stubTransactionManager fnをそのまま実行し、呼び出し回数を記録する
+type stubTransactionManager struct {
+	calls int
+}
+
+func (m *stubTransactionManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.calls++
+	return fn(ctx)
+}
+
+type stubVerificationSender struct {
+	sent []string
+	err  error
+}
+
+func (s *stubVerificationSender) Send(ctx context.Context, user *model.User) error {
+	s.sent = append(s.sent, user.Email())
+	return s.err
+}
+
+// expectUnique ユーザー名・メールアドレスの重複確認で該当なしを返す
+func expectUnique(mockRepo *MockUserRepository) {
+	notFound := model.NewNotFoundError("ユーザーが見つかりません", nil)
+	mockRepo.On("FindByUsername", mock.Anything).Return(nil, notFound)
+	mockRepo.On("FindByEmail", mock.Anything).Return(nil, notFound)
+}
+
 func TestUserUsecase_Create(t *testing.T) {
 	t.Run("成功: ユーザーを作成できる", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		usecase := NewUserUsecase(mockRepo)
+		verification := &stubVerificationSender{}
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, verification, testAccessPolicy, testUserFactory, testIDs)
 
 		now := time.Now()
-		expectedUser := &model.User{
-			ID:        "test-id",
-			Username:  "testuser",
-			Email:     "test@example.com",
-			Password:  "hashedpassword",
-			CreatedAt: now,
-			UpdatedAt: now,
-		}
-
+		expectedUser := model.ReconstructUser(model.UserSnapshot{
+			ID:           "test-id",
+			Username:     "testuser",
+			Email:        "test@example.com",
+			PasswordHash: "hashedpassword",
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+
+		expectUnique(mockRepo)
 		mockRepo.On("Create", mock.AnythingOfType("*model.User")).Return(expectedUser, nil)
 
-		result, err := usecase.Create("testuser", "test@example.com", "password123")
+		result, err := usecase.Create(context.Background(), "testuser", "test@example.com", "password123")
 
 		assert.NoError(t, err)
-		assert.Equal(t, "testuser", result.Username)
-		assert.Equal(t, "test@example.com", result.Email)
+		assert.NotEmpty(t, result.ID())
+		assert.Equal(t, "testuser", result.Username())
+		assert.Equal(t, "test@example.com", result.Email())
+		assert.False(t, result.IsEmailVerified())
+		assert.Equal(t, []string{"test@example.com"}, verification.sent)
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("成功: 確認メールを送れなくても作成する", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{err: errors.New("smtp error")}, testAccessPolicy, testUserFactory, testIDs)
+
+		expectUnique(mockRepo)
+		mockRepo.On("Create", mock.AnythingOfType("*model.User")).Return(model.ReconstructUser(model.UserSnapshot{}), nil)
+
+		result, err := usecase.Create(context.Background(), "testuser", "test@example.com", "password123")
+
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+	})
+
+	t.Run("成功: 作成ごとに異なるIDが割り当てられる", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+
+		expectUnique(mockRepo)
+		mockRepo.On("Create", mock.AnythingOfType("*model.User")).Return(model.ReconstructUser(model.UserSnapshot{}), nil)
+
+		first, err := usecase.Create(context.Background(), "testuser1", "test1@example.com", "password123")
+		assert.NoError(t, err)
+		second, err := usecase.Create(context.Background(), "testuser2", "test2@example.com", "password123")
+		assert.NoError(t, err)
+
+		assert.NotEqual(t, first.ID(), second.ID())
+	})
+
 	t.Run("失敗: 無効なユーザー名", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		usecase := NewUserUsecase(mockRepo)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
 
-		result, err := usecase.Create("ab", "test@example.com", "password123")
+		result, err := usecase.Create(context.Background(), "ab", "test@example.com", "password123")
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -81,9 +189,9 @@ func TestUserUsecase_Create(t *testing.T) {
 
 	t.Run("失敗: 無効なメールアドレス", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		usecase := NewUserUsecase(mockRepo)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
 
-		result, err := usecase.Create("testuser", "invalid-email", "password123")
+		result, err := usecase.Create(context.Background(), "testuser", "invalid-email", "password123")
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -92,9 +200,9 @@ func TestUserUsecase_Create(t *testing.T) {
 
 	t.Run("失敗: 無効なパスワード", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		usecase := NewUserUsecase(mockRepo)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
 
-		result, err := usecase.Create("testuser", "test@example.com", "short")
+		result, err := usecase.Create(context.Background(), "testuser", "test@example.com", "short")
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -103,33 +211,52 @@ func TestUserUsecase_Create(t *testing.T) {
 
 	t.Run("失敗: リポジトリエラー", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		usecase := NewUserUsecase(mockRepo)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
 
+		expectUnique(mockRepo)
 		mockRepo.On("Create", mock.AnythingOfType("*model.User")).Return((*model.User)(nil), errors.New("database error"))
 
-		result, err := usecase.Create("testuser", "test@example.com", "password123")
+		result, err := usecase.Create(context.Background(), "testuser", "test@example.com", "password123")
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
 		assert.Contains(t, err.Error(), "database error")
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("失敗: メールアドレスが他のユーザーに使われている", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+
+		other := model.ReconstructUser(model.UserSnapshot{ID: "other-id", Username: "other", Email: "Test@Example.com"})
+		mockRepo.On("FindByUsername", "testuser").Return(nil, model.NewNotFoundError("ユーザーが見つかりません", nil))
+		mockRepo.On("FindByEmail", "test@example.com").Return(other, nil)
+
+		result, err := usecase.Create(context.Background(), "testuser", "test@example.com", "password123")
+
+		assert.ErrorIs(t, err, model.ErrConflict)
+		assert.Nil(t, result)
+		var domainErr *model.Error
+		assert.ErrorAs(t, err, &domainErr)
+		assert.Equal(t, []model.FieldError{model.FieldEmailTaken}, domainErr.Fields)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
 }
 
 func TestUserUsecase_FindByID(t *testing.T) {
 	t.Run("成功: ユーザーを取得できる", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		usecase := NewUserUsecase(mockRepo)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
 
-		expectedUser := &model.User{
+		expectedUser := model.ReconstructUser(model.UserSnapshot{
 			ID:       "test-id",
 			Username: "testuser",
 			Email:    "test@example.com",
-		}
+		})
 
 		mockRepo.On("FindByID", "test-id").Return(expectedUser, nil)
 
-		result, err := usecase.FindByID("test-id")
+		result, err := usecase.FindByID(context.Background(), "test-id")
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedUser, result)
@@ -138,11 +265,11 @@ func TestUserUsecase_FindByID(t *testing.T) {
 
 	t.Run("失敗: ユーザーが見つからない", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		usecase := NewUserUsecase(mockRepo)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
 
 		mockRepo.On("FindByID", "nonexistent-id").Return(nil, errors.New("user not found"))
 
-		result, err := usecase.FindByID("nonexistent-id")
+		result, err := usecase.FindByID(context.Background(), "nonexistent-id")
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -154,29 +281,33 @@ func TestUserUsecase_FindByID(t *testing.T) {
 func TestUserUsecase_FindAll(t *testing.T) {
 	t.Run("成功: 全ユーザーを取得できる", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		usecase := NewUserUsecase(mockRepo)
-
-		expectedUsers := []*model.User{
-			{ID: "1", Username: "user1", Email: "user1@example.com"},
-			{ID: "2", Username: "user2", Email: "user2@example.com"},
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+
+		query := repository.UserQuery{Limit: 2, SortField: repository.UserSortByUsername}
+		expectedPage := &repository.UserPage{
+			Users: []*model.User{
+				model.ReconstructUser(model.UserSnapshot{ID: "1", Username: "user1", Email: "user1@example.com"}),
+				model.ReconstructUser(model.UserSnapshot{ID: "2", Username: "user2", Email: "user2@example.com"}),
+			},
+			NextCursor: "next-cursor",
 		}
 
-		mockRepo.On("FindAll").Return(expectedUsers, nil)
+		mockRepo.On("FindAll", query).Return(expectedPage, nil)
 
-		result, err := usecase.FindAll()
+		result, err := usecase.FindAll(context.Background(), query)
 
 		assert.NoError(t, err)
-		assert.Equal(t, expectedUsers, result)
+		assert.Equal(t, expectedPage, result)
 		mockRepo.AssertExpectations(t)
 	})
 
 	t.Run("失敗: リポジトリエラー", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		usecase := NewUserUsecase(mockRepo)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
 
-		mockRepo.On("FindAll").Return(([]*model.User)(nil), errors.New("database error"))
+		mockRepo.On("FindAll", repository.UserQuery{}).Return(nil, errors.New("database error"))
 
-		result, err := usecase.FindAll()
+		result, err := usecase.FindAll(context.Background(), repository.UserQuery{})
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -188,75 +319,264 @@ func TestUserUsecase_FindAll(t *testing.T) {
 func TestUserUsecase_Update(t *testing.T) {
 	t.Run("成功: ユーザーを更新できる", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		usecase := NewUserUsecase(mockRepo)
+		txManager := &stubTransactionManager{}
+		verification := &stubVerificationSender{}
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, txManager, verification, testAccessPolicy, testUserFactory, testIDs)
+
+		verifiedAt := time.Now()
+		existingUser := model.ReconstructUser(model.UserSnapshot{
+			ID:              "test-id",
+			Username:        "olduser",
+			Email:           "old@example.com",
+			EmailVerifiedAt: &verifiedAt,
+			PasswordHash:    "oldpassword",
+		})
+
+		mockRepo.On("FindByIDForUpdate", "test-id").Return(existingUser, nil)
+		expectUnique(mockRepo)
+		mockRepo.On("Update", mock.AnythingOfType("*model.User")).Return(existingUser, nil)
+
+		result, err := usecase.Update(context.Background(), "test-id", 0, "newuser", "new@example.com", "newpassword1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "newuser", result.Username())
+		assert.Equal(t, "new@example.com", result.Email())
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(result.PasswordHash()), []byte("newpassword1")))
+		assert.False(t, result.UpdatedAt().IsZero())
+		assert.False(t, result.IsEmailVerified())
+		assert.Equal(t, []string{"new@example.com"}, verification.sent)
+		assert.Equal(t, 1, txManager.calls)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("失敗: 無効な値では更新しない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+
+		existingUser := model.ReconstructUser(model.UserSnapshot{
+			ID:           "test-id",
+			Username:     "olduser",
+			Email:        "old@example.com",
+			PasswordHash: "oldpassword",
+		})
+
+		mockRepo.On("FindByIDForUpdate", "test-id").Return(existingUser, nil)
+
+		result, err := usecase.Update(context.Background(), "test-id", 0, "newuser", "invalid-email", "newpassword")
+
+		assert.ErrorIs(t, err, model.ErrValidation)
+		assert.Nil(t, result)
+		assert.Contains(t, err.Error(), "メールアドレスが不正です")
+		assert.Contains(t, err.Error(), "パスワードは数字を含む必要があります")
+		assert.Equal(t, "olduser", existingUser.Username())
+		assert.Equal(t, "oldpassword", existingUser.PasswordHash())
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("失敗: ユーザーが見つからない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+
+		mockRepo.On("FindByIDForUpdate", "nonexistent-id").Return(nil, errors.New("user not found"))
+
+		result, err := usecase.Update(context.Background(), "nonexistent-id", 0, "newuser", "new@example.com", "newpassword")
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		assert.Contains(t, err.Error(), "user not found")
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestUserUsecase_Patch(t *testing.T) {
+	t.Run("成功: 指定した項目のみ更新できる", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+
+		existingUser := model.ReconstructUser(model.UserSnapshot{
+			ID:           "test-id",
+			Username:     "olduser",
+			Email:        "old@example.com",
+			PasswordHash: "oldpassword",
+		})
+
+		mockRepo.On("FindByIDForUpdate", "test-id").Return(existingUser, nil)
+		mockRepo.On("FindByEmail", "new@example.com").Return(nil, model.NewNotFoundError("ユーザーが見つかりません", nil))
+		mockRepo.On("Update", mock.AnythingOfType("*model.User")).Return(existingUser, nil)
+
+		email := "new@example.com"
+		result, err := usecase.Patch(context.Background(), "test-id", 0, model.UserChanges{Email: &email})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "olduser", result.Username())
+		assert.Equal(t, "new@example.com", result.Email())
+		assert.Equal(t, "oldpassword", result.PasswordHash())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("失敗: 無効な値では更新しない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
 
-		existingUser := &model.User{
+		existingUser := model.ReconstructUser(model.UserSnapshot{
 			ID:       "test-id",
 			Username: "olduser",
 			Email:    "old@example.com",
-			Password: "oldpassword",
-		}
+		})
 
-		updatedUser := &model.User{
+		mockRepo.On("FindByIDForUpdate", "test-id").Return(existingUser, nil)
+
+		username := "ab"
+		result, err := usecase.Patch(context.Background(), "test-id", 0, model.UserChanges{Username: &username})
+
+		assert.ErrorIs(t, err, model.ErrValidation)
+		assert.Nil(t, result)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("失敗: バージョンが一致しない場合は更新しない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+
+		existingUser := model.ReconstructUser(model.UserSnapshot{
 			ID:       "test-id",
-			Username: "newuser",
-			Email:    "new@example.com",
-			Password: "newpassword",
-		}
+			Username: "olduser",
+			Email:    "old@example.com",
+			Version:  3,
+		})
+
+		mockRepo.On("FindByIDForUpdate", "test-id").Return(existingUser, nil)
+
+		email := "new@example.com"
+		result, err := usecase.Patch(context.Background(), "test-id", 2, model.UserChanges{Email: &email})
+
+		assert.ErrorIs(t, err, model.ErrPreconditionFailed)
+		assert.Nil(t, result)
+		assert.Equal(t, "old@example.com", existingUser.Email())
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
 
-		mockRepo.On("FindByID", "test-id").Return(existingUser, nil)
-		mockRepo.On("Update", mock.AnythingOfType("*model.User")).Return(updatedUser, nil)
+	t.Run("成功: 自分のメールアドレスの大文字・小文字のみの変更は重複としない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+
+		existingUser := model.ReconstructUser(model.UserSnapshot{
+			ID:       "test-id",
+			Username: "olduser",
+			Email:    "old@example.com",
+		})
+
+		mockRepo.On("FindByIDForUpdate", "test-id").Return(existingUser, nil)
+		mockRepo.On("FindByEmail", "Old@example.com").Return(existingUser, nil)
+		mockRepo.On("Update", mock.AnythingOfType("*model.User")).Return(existingUser, nil)
 
-		result, err := usecase.Update("test-id", "newuser", "new@example.com", "newpassword")
+		email := "Old@example.com"
+		result, err := usecase.Patch(context.Background(), "test-id", 0, model.UserChanges{Email: &email})
 
 		assert.NoError(t, err)
-		assert.Equal(t, "newuser", result.Username)
-		assert.Equal(t, "new@example.com", result.Email)
+		assert.Equal(t, "Old@example.com", result.Email())
+		mockRepo.AssertNotCalled(t, "FindByUsername", mock.Anything)
 		mockRepo.AssertExpectations(t)
 	})
 
-	t.Run("失敗: ユーザーが見つからない", func(t *testing.T) {
+	t.Run("成功: 変更する項目が無ければ保存しない", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		usecase := NewUserUsecase(mockRepo)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
 
-		mockRepo.On("FindByID", "nonexistent-id").Return(nil, errors.New("user not found"))
+		updatedAt := time.Now().Add(-time.Hour)
+		existingUser := model.ReconstructUser(model.UserSnapshot{
+			ID:        "test-id",
+			Username:  "olduser",
+			Email:     "old@example.com",
+			Version:   3,
+			UpdatedAt: updatedAt,
+		})
 
-		result, err := usecase.Update("nonexistent-id", "newuser", "new@example.com", "newpassword")
+		mockRepo.On("FindByIDForUpdate", "test-id").Return(existingUser, nil)
 
-		assert.Error(t, err)
+		expected := "olduser"
+		result, err := usecase.Patch(context.Background(), "test-id", 3, model.UserChanges{ExpectedUsername: &expected})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, result.Version())
+		assert.Equal(t, updatedAt, result.UpdatedAt())
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("失敗: 変更前の値が期待した値と一致しない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+
+		existingUser := model.ReconstructUser(model.UserSnapshot{
+			ID:       "test-id",
+			Username: "olduser",
+			Email:    "old@example.com",
+		})
+
+		mockRepo.On("FindByIDForUpdate", "test-id").Return(existingUser, nil)
+
+		expected := "other@example.com"
+		email := "new@example.com"
+		result, err := usecase.Patch(context.Background(), "test-id", 0, model.UserChanges{Email: &email, ExpectedEmail: &expected})
+
+		assert.ErrorIs(t, err, model.ErrConflict)
 		assert.Nil(t, result)
-		assert.Contains(t, err.Error(), "user not found")
-		mockRepo.AssertExpectations(t)
+		assert.Equal(t, "old@example.com", existingUser.Email())
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("失敗: ユーザー名が他のユーザーに使われている", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+
+		existingUser := model.ReconstructUser(model.UserSnapshot{
+			ID:       "test-id",
+			Username: "olduser",
+			Email:    "old@example.com",
+		})
+		other := model.ReconstructUser(model.UserSnapshot{ID: "other-id", Username: "NewUser"})
+
+		mockRepo.On("FindByIDForUpdate", "test-id").Return(existingUser, nil)
+		mockRepo.On("FindByUsername", "newuser").Return(other, nil)
+
+		username := "newuser"
+		result, err := usecase.Patch(context.Background(), "test-id", 0, model.UserChanges{Username: &username})
+
+		assert.ErrorIs(t, err, model.ErrConflict)
+		assert.Nil(t, result)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
 	})
 }
 
 func TestUserUsecase_Delete(t *testing.T) {
 	t.Run("成功: ユーザーを削除できる", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		usecase := NewUserUsecase(mockRepo)
+		txManager := &stubTransactionManager{}
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, txManager, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
 
-		existingUser := &model.User{
+		existingUser := model.ReconstructUser(model.UserSnapshot{
 			ID:       "test-id",
 			Username: "testuser",
 			Email:    "test@example.com",
-		}
+		})
 
-		mockRepo.On("FindByID", "test-id").Return(existingUser, nil)
+		mockRepo.On("FindByIDForUpdate", "test-id").Return(existingUser, nil)
 		mockRepo.On("Delete", existingUser).Return(nil)
 
-		err := usecase.Delete("test-id")
+		err := usecase.Delete(context.Background(), "test-id", 0)
 
 		assert.NoError(t, err)
+		assert.Equal(t, 1, txManager.calls)
 		mockRepo.AssertExpectations(t)
 	})
 
 	t.Run("失敗: ユーザーが見つからない", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		usecase := NewUserUsecase(mockRepo)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
 
-		mockRepo.On("FindByID", "nonexistent-id").Return(nil, errors.New("user not found"))
+		mockRepo.On("FindByIDForUpdate", "nonexistent-id").Return(nil, errors.New("user not found"))
 
-		err := usecase.Delete("nonexistent-id")
+		err := usecase.Delete(context.Background(), "nonexistent-id", 0)
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "user not found")
@@ -265,21 +585,214 @@ func TestUserUsecase_Delete(t *testing.T) {
 
 	t.Run("失敗: 削除エラー", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		usecase := NewUserUsecase(mockRepo)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
 
-		existingUser := &model.User{
+		existingUser := model.ReconstructUser(model.UserSnapshot{
 			ID:       "test-id",
 			Username: "testuser",
 			Email:    "test@example.com",
-		}
+		})
 
-		mockRepo.On("FindByID", "test-id").Return(existingUser, nil)
+		mockRepo.On("FindByIDForUpdate", "test-id").Return(existingUser, nil)
 		mockRepo.On("Delete", existingUser).Return(errors.New("delete error"))
 
-		err := usecase.Delete("test-id")
+		err := usecase.Delete(context.Background(), "test-id", 0)
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "delete error")
 		mockRepo.AssertExpectations(t)
 	})
-}
\ No newline at end of file
+}
+
+// testIDs testUserFactory 既定の設定でIDを採番し、ユーザーを作成する
+var testIDs = model.NewUUIDv7Generator()
+var testUserFactory = newTestUserFactory(model.DefaultPasswordPolicy())
+
+func newTestUserFactory(policy model.PasswordPolicy) *model.UserFactory {
+	factory, err := model.NewUserFactory(testIDs, model.EmailPolicy{}, policy)
+	if err != nil {
+		panic(err)
+	}
+	return factory
+}
+
+// adminContext 管理者が操作しているコンテキスト
+func adminContext() context.Context {
+	return WithActor(context.Background(), &Claims{UserID: "admin-id", Role: model.RoleAdmin})
+}
+
+func TestUserUsecase_ChangeStatus(t *testing.T) {
+	t.Run("成功: 利用停止と再開ができる", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+		verifiedAt := time.Now()
+		existingUser := model.ReconstructUser(model.UserSnapshot{ID: "test-id", EmailVerifiedAt: &verifiedAt, Status: model.UserStatusActive, Version: 1})
+
+		mockRepo.On("FindByIDForUpdate", "test-id").Return(existingUser, nil)
+		mockRepo.On("Update", existingUser).Return(existingUser, nil)
+
+		suspended, err := usecase.Suspend(adminContext(), "test-id", 1, "スパム投稿")
+		assert.NoError(t, err)
+		assert.Equal(t, model.UserStatusSuspended, suspended.Status())
+		assert.Equal(t, "スパム投稿", suspended.StatusReason())
+
+		reinstated, err := usecase.Reinstate(adminContext(), "test-id", 0, "異議申し立てを承認")
+		assert.NoError(t, err)
+		assert.Equal(t, model.UserStatusActive, reinstated.Status())
+		mockRepo.AssertNumberOfCalls(t, "Update", 2)
+	})
+
+	t.Run("成功: 役割を変更できる", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+		existingUser := model.ReconstructUser(model.UserSnapshot{ID: "test-id", Role: model.RoleMember})
+
+		mockRepo.On("FindByIDForUpdate", "test-id").Return(existingUser, nil)
+		mockRepo.On("Update", existingUser).Return(existingUser, nil)
+
+		result, err := usecase.AssignRole(adminContext(), "test-id", 0, "support")
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.RoleSupport, result.Role())
+	})
+
+	t.Run("失敗: サポートは管理者を利用停止できない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		recorder := &stubDenialRecorder{}
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, NewAccessPolicy(recorder), testUserFactory, testIDs)
+		existingUser := model.ReconstructUser(model.UserSnapshot{ID: "test-id", Role: model.RoleAdmin, Status: model.UserStatusActive})
+
+		mockRepo.On("FindByIDForUpdate", "test-id").Return(existingUser, nil)
+
+		ctx := WithActor(context.Background(), &Claims{UserID: "support-id", Role: model.RoleSupport})
+		result, err := usecase.Suspend(ctx, "test-id", 0, "規約違反")
+
+		assert.ErrorIs(t, err, model.ErrForbidden)
+		assert.Nil(t, result)
+		assert.Equal(t, model.UserStatusActive, existingUser.Status())
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+		if assert.Len(t, recorder.denials, 1) {
+			assert.Equal(t, model.PermissionSuspendUsers, recorder.denials[0].Permission)
+			assert.Equal(t, "test-id", recorder.denials[0].TargetID)
+		}
+	})
+
+	t.Run("成功: サポートは他のサポートを利用停止できる", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+		existingUser := model.ReconstructUser(model.UserSnapshot{ID: "test-id", Role: model.RoleSupport, Status: model.UserStatusActive})
+
+		mockRepo.On("FindByIDForUpdate", "test-id").Return(existingUser, nil)
+		mockRepo.On("Update", existingUser).Return(existingUser, nil)
+
+		ctx := WithActor(context.Background(), &Claims{UserID: "support-id", Role: model.RoleSupport})
+		result, err := usecase.Suspend(ctx, "test-id", 0, "規約違反")
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.UserStatusSuspended, result.Status())
+	})
+
+	t.Run("失敗: 無効化されたユーザーは再開できない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+		existingUser := model.ReconstructUser(model.UserSnapshot{ID: "test-id", Status: model.UserStatusDeactivated})
+
+		mockRepo.On("FindByIDForUpdate", "test-id").Return(existingUser, nil)
+
+		result, err := usecase.Reinstate(adminContext(), "test-id", 0, "誤って無効化した")
+
+		assert.ErrorIs(t, err, model.ErrConflict)
+		assert.Nil(t, result)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("失敗: バージョンが一致しない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+		existingUser := model.ReconstructUser(model.UserSnapshot{ID: "test-id", Status: model.UserStatusActive, Version: 2})
+
+		mockRepo.On("FindByIDForUpdate", "test-id").Return(existingUser, nil)
+
+		_, err := usecase.Deactivate(adminContext(), "test-id", 1, "退会")
+
+		assert.ErrorIs(t, err, model.ErrPreconditionFailed)
+		assert.Equal(t, model.UserStatusActive, existingUser.Status())
+	})
+}
+
+func TestUserUsecase_Restore(t *testing.T) {
+	t.Run("成功: 論理削除されたユーザーを復元できる", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+		deletedAt := time.Now()
+		deletedUser := model.ReconstructUser(model.UserSnapshot{ID: "test-id", Version: 2, DeletedAt: &deletedAt})
+
+		mockRepo.On("FindDeletedByIDForUpdate", "test-id").Return(deletedUser, nil)
+		mockRepo.On("Update", deletedUser).Return(deletedUser, nil)
+
+		result, err := usecase.Restore(context.Background(), "test-id", 2)
+
+		assert.NoError(t, err)
+		assert.False(t, result.IsDeleted())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("失敗: 削除されていないユーザー", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+
+		mockRepo.On("FindDeletedByIDForUpdate", "test-id").Return(nil, model.NewNotFoundError("ユーザーが見つかりません", nil))
+
+		result, err := usecase.Restore(context.Background(), "test-id", 0)
+
+		assert.ErrorIs(t, err, model.ErrNotFound)
+		assert.Nil(t, result)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+}
+
+func TestUserUsecase_FindAll_IncludeDeleted(t *testing.T) {
+	query := repository.UserQuery{Limit: repository.DefaultUserLimit, IncludeDeleted: true}
+
+	t.Run("成功: サポートは削除されたユーザーを含めて取得できる", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+		ctx := WithActor(context.Background(), &Claims{UserID: "support-id", Role: model.RoleSupport})
+
+		mockRepo.On("FindAll", query).Return(&repository.UserPage{}, nil)
+
+		_, err := usecase.FindAll(ctx, query)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("失敗: 権限の無い利用者と未認証", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := NewUserUsecase(mockRepo, &stubAuditLogRepository{}, &stubTransactionManager{}, &stubVerificationSender{}, testAccessPolicy, testUserFactory, testIDs)
+		member := WithActor(context.Background(), &Claims{UserID: "member-id", Role: model.RoleMember})
+
+		_, memberErr := usecase.FindAll(member, query)
+		_, anonymousErr := usecase.FindAll(context.Background(), query)
+
+		assert.ErrorIs(t, memberErr, model.ErrForbidden)
+		assert.ErrorIs(t, anonymousErr, model.ErrUnauthorized)
+		mockRepo.AssertNotCalled(t, "FindAll", mock.Anything)
+	})
+}
+
+func TestUserPurger(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	purger := NewUserPurger(mockRepo, 30*24*time.Hour, time.Hour)
+
+	var deletedBefore time.Time
+	mockRepo.On("Purge", mock.AnythingOfType("time.Time")).Run(func(args mock.Arguments) {
+		deletedBefore = args.Get(0).(time.Time)
+	}).Return(int64(2), nil)
+
+	purged, err := purger.Purge(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+	assert.WithinDuration(t, time.Now().Add(-30*24*time.Hour), deletedBefore, time.Minute)
+}
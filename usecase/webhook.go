@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"time"
+)
+
+// WebhookUseCase Webhookの購読先と配信履歴を管理する。操作の権限はルーティングで確認する
+type WebhookUseCase interface {
+	Create(ctx context.Context, url string, eventTypes []string, description string) (*model.WebhookSubscription, error)
+	FindByID(ctx context.Context, id string) (*model.WebhookSubscription, error)
+	FindAll(ctx context.Context) ([]*model.WebhookSubscription, error)
+	// Update activeがnilの場合は配信の状態を変えない。trueにすると、失敗が続いて止まった配信も再開する
+	Update(ctx context.Context, id string, url string, eventTypes []string, description string, active *bool) (*model.WebhookSubscription, error)
+	Delete(ctx context.Context, id string) error
+	// FindDeliveries query.SubscriptionIDの購読先への配信履歴を返す
+	FindDeliveries(ctx context.Context, query repository.WebhookDeliveryQuery) (*repository.WebhookDeliveryPage, error)
+	// FindDelivery 配信と、配信を試みた記録を返す
+	FindDelivery(ctx context.Context, subscriptionID string, deliveryID string) (*model.WebhookDelivery, []*model.WebhookDeliveryAttempt, error)
+	// Replay 配信し終えた配信を同じ本文で送り直す
+	Replay(ctx context.Context, subscriptionID string, deliveryID string) (*model.WebhookDelivery, error)
+}
+
+type webhookUsecase struct {
+	subscriptionRepo repository.WebhookSubscriptionRepository
+	deliveryRepo     repository.WebhookDeliveryRepository
+	ids              model.IDGenerator
+}
+
+func NewWebhookUsecase(subscriptionRepo repository.WebhookSubscriptionRepository, deliveryRepo repository.WebhookDeliveryRepository, ids model.IDGenerator) WebhookUseCase {
+	return &webhookUsecase{subscriptionRepo: subscriptionRepo, deliveryRepo: deliveryRepo, ids: ids}
+}
+
+func (u *webhookUsecase) Create(ctx context.Context, url string, eventTypes []string, description string) (*model.WebhookSubscription, error) {
+	subscription, err := model.NewWebhookSubscription(u.ids, url, eventTypes, description)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.subscriptionRepo.Create(ctx, &subscription); err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (u *webhookUsecase) FindByID(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	subscription, err := u.subscriptionRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (u *webhookUsecase) FindAll(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	subscriptions, err := u.subscriptionRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (u *webhookUsecase) Update(ctx context.Context, id string, url string, eventTypes []string, description string, active *bool) (*model.WebhookSubscription, error) {
+	subscription, err := u.subscriptionRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := subscription.Change(url, eventTypes, description, now); err != nil {
+		return nil, err
+	}
+	if active != nil && *active && !subscription.Active {
+		subscription.Enable(now)
+	} else if active != nil && !*active {
+		subscription.Disable(now)
+	}
+	if err := u.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (u *webhookUsecase) Delete(ctx context.Context, id string) error {
+	return u.subscriptionRepo.Delete(ctx, id)
+}
+
+func (u *webhookUsecase) FindDeliveries(ctx context.Context, query repository.WebhookDeliveryQuery) (*repository.WebhookDeliveryPage, error) {
+	if _, err := u.subscriptionRepo.FindByID(ctx, query.SubscriptionID); err != nil {
+		return nil, err
+	}
+	page, err := u.deliveryRepo.FindAll(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (u *webhookUsecase) FindDelivery(ctx context.Context, subscriptionID string, deliveryID string) (*model.WebhookDelivery, []*model.WebhookDeliveryAttempt, error) {
+	delivery, err := u.findDelivery(ctx, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	attempts, err := u.deliveryRepo.FindAttempts(ctx, delivery.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return delivery, attempts, nil
+}
+
+// Replay 購読先の配信が止まっている場合は、再開するまで送らない
+func (u *webhookUsecase) Replay(ctx context.Context, subscriptionID string, deliveryID string) (*model.WebhookDelivery, error) {
+	delivery, err := u.findDelivery(ctx, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if err := delivery.Replay(time.Now()); err != nil {
+		return nil, err
+	}
+	if err := u.deliveryRepo.Update(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// findDelivery 別の購読先への配信は見つからないものとして扱う
+func (u *webhookUsecase) findDelivery(ctx context.Context, subscriptionID string, deliveryID string) (*model.WebhookDelivery, error) {
+	delivery, err := u.deliveryRepo.FindByID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.SubscriptionID != subscriptionID {
+		return nil, model.NewNotFoundError("配信が見つかりません", nil)
+	}
+	return delivery, nil
+}
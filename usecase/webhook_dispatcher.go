@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// webhookEventPublisher アウトボックスのメッセージを、そのイベントを購読している購読先への配信として保存する。
+// 送信はWebhookDispatcherが行うため、購読先が応答しなくてもアウトボックスの配信は滞らない
+type webhookEventPublisher struct {
+	subscriptionRepo repository.WebhookSubscriptionRepository
+	deliveryRepo     repository.WebhookDeliveryRepository
+	ids              model.IDGenerator
+}
+
+func NewWebhookEventPublisher(subscriptionRepo repository.WebhookSubscriptionRepository, deliveryRepo repository.WebhookDeliveryRepository, ids model.IDGenerator) EventPublisher {
+	return &webhookEventPublisher{subscriptionRepo: subscriptionRepo, deliveryRepo: deliveryRepo, ids: ids}
+}
+
+func (p *webhookEventPublisher) Publish(ctx context.Context, message *model.OutboxMessage) error {
+	subscriptions, err := p.subscriptionRepo.FindActive(ctx)
+	if err != nil {
+		return err
+	}
+	var deliveries []*model.WebhookDelivery
+	for _, subscription := range subscriptions {
+		if !subscription.Subscribes(message.EventType) {
+			continue
+		}
+		delivery, err := model.NewWebhookDelivery(p.ids, subscription, message)
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+	return p.deliveryRepo.CreateAll(ctx, deliveries)
+}
+
+// WebhookSender 配信の本文に署名を付けて購読先に送信する
+type WebhookSender interface {
+	// Send 応答のステータスコードを返す。2xx以外の応答や応答が無い場合はエラーを返す
+	Send(ctx context.Context, subscription *model.WebhookSubscription, delivery *model.WebhookDelivery) (int, error)
+}
+
+// WebhookDispatcherConfig RetryBaseDelayは1回目の失敗後に待つ時間で、失敗するたびに倍にしてRetryMaxDelayで頭打ちにする
+type WebhookDispatcherConfig struct {
+	BatchSize      int
+	Interval       time.Duration
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// MaxAttempts 1つの配信を試みる回数の上限
+	MaxAttempts int
+	// DisableAfterFailures 購読先への配信が続けてこの回数失敗したら、その購読先への配信を止める
+	DisableAfterFailures int
+}
+
+// WebhookDispatcher 配信待ちの配信を定期的に購読先に送信する
+type WebhookDispatcher struct {
+	subscriptionRepo repository.WebhookSubscriptionRepository
+	deliveryRepo     repository.WebhookDeliveryRepository
+	sender           WebhookSender
+	ids              model.IDGenerator
+	config           WebhookDispatcherConfig
+}
+
+func NewWebhookDispatcher(subscriptionRepo repository.WebhookSubscriptionRepository, deliveryRepo repository.WebhookDeliveryRepository, sender WebhookSender, ids model.IDGenerator, config WebhookDispatcherConfig) *WebhookDispatcher {
+	return &WebhookDispatcher{subscriptionRepo: subscriptionRepo, deliveryRepo: deliveryRepo, sender: sender, ids: ids, config: config}
+}
+
+// Dispatch 次に試す日時を迎えた配信を送信し、配信できた件数を返す。
+// 送信した結果は1回ごとに記録し、失敗した配信は次に試す日時を遅らせて残りの配信を続ける
+func (d *WebhookDispatcher) Dispatch(ctx context.Context) (int, error) {
+	deliveries, err := d.deliveryRepo.FindDue(ctx, time.Now(), d.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	subscriptions := map[string]*model.WebhookSubscription{}
+	delivered := 0
+	for _, delivery := range deliveries {
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			subscription, err = d.subscriptionRepo.FindByID(ctx, delivery.SubscriptionID)
+			if errors.Is(err, model.ErrNotFound) {
+				// 取得した後に購読先が削除された
+				continue
+			}
+			if err != nil {
+				return delivered, err
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+		if !subscription.Active {
+			continue
+		}
+
+		sent, err := d.send(ctx, subscription, delivery)
+		if err != nil {
+			return delivered, err
+		}
+		if sent {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// send 1つの配信を送信して結果を保存し、配信できた場合はtrueを返す
+func (d *WebhookDispatcher) send(ctx context.Context, subscription *model.WebhookSubscription, delivery *model.WebhookDelivery) (bool, error) {
+	start := time.Now()
+	statusCode, sendErr := d.sender.Send(ctx, subscription, delivery)
+	now := time.Now()
+
+	attempt := model.NewWebhookDeliveryAttempt(d.ids, delivery, statusCode, sendErr, now.Sub(start), now)
+	if err := d.deliveryRepo.CreateAttempt(ctx, &attempt); err != nil {
+		return false, err
+	}
+
+	if sendErr == nil {
+		delivery.Succeed(statusCode, now)
+		if err := d.deliveryRepo.Update(ctx, delivery); err != nil {
+			return false, err
+		}
+		return true, d.subscriptionRepo.ResetFailures(ctx, subscription.ID)
+	}
+
+	delivery.Fail(statusCode, sendErr.Error(), d.config.MaxAttempts, now.Add(exponentialBackoff(d.config.RetryBaseDelay, d.config.RetryMaxDelay, delivery.Attempts+1)), now)
+	if err := d.deliveryRepo.Update(ctx, delivery); err != nil {
+		return false, err
+	}
+	log.Printf("failed to deliver webhook %s to %s (%s, attempt %d): %v", delivery.ID, subscription.ID, delivery.EventType, delivery.Attempts, sendErr)
+
+	disabled, err := d.subscriptionRepo.RecordFailure(ctx, subscription.ID, d.config.DisableAfterFailures, now)
+	if err != nil {
+		return false, err
+	}
+	if disabled {
+		// 同じ取得分に残っている配信も、購読先の配信を再開するまで送らない
+		subscription.Disable(now)
+		log.Printf("disabled webhook %s after %d consecutive failures", subscription.ID, d.config.DisableAfterFailures)
+	}
+	return false, nil
+}
+
+// Run ctxがキャンセルされるまでIntervalごとにDispatchを実行する
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := d.Dispatch(ctx); err != nil {
+				log.Printf("failed to dispatch webhooks: %v", err)
+			}
+		}
+	}
+}
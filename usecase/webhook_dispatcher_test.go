@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// stubWebhookSender 送信した配信のIDを記録し、statusesに指定したステータスコードで応答する。指定が無い配信には200で応答する
+type stubWebhookSender struct {
+	sent     []string
+	statuses map[string]int
+}
+
+func (s *stubWebhookSender) Send(ctx context.Context, subscription *model.WebhookSubscription, delivery *model.WebhookDelivery) (int, error) {
+	s.sent = append(s.sent, delivery.ID)
+	if status, ok := s.statuses[delivery.ID]; ok {
+		return status, errors.New("unexpected status")
+	}
+	return 200, nil
+}
+
+var webhookDispatcherConfig = WebhookDispatcherConfig{
+	BatchSize:            10,
+	Interval:             time.Second,
+	RetryBaseDelay:       time.Second,
+	RetryMaxDelay:        10 * time.Second,
+	MaxAttempts:          3,
+	DisableAfterFailures: 5,
+}
+
+func TestWebhookEventPublisher_Publish(t *testing.T) {
+	t.Run("成功: イベントを購読している購読先への配信のみ作成する", func(t *testing.T) {
+		// Arrange
+		mockSubscriptionRepo := new(MockWebhookSubscriptionRepository)
+		mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+		publisher := NewWebhookEventPublisher(mockSubscriptionRepo, mockDeliveryRepo, testIDs)
+		subscribed := newTestWebhookSubscription(t, model.EventTypeUserDeleted)
+		other := newTestWebhookSubscription(t, model.EventTypeUserRegistered)
+		mockSubscriptionRepo.On("FindActive").Return([]*model.WebhookSubscription{subscribed, other}, nil)
+		var deliveries []*model.WebhookDelivery
+		mockDeliveryRepo.On("CreateAll", mock.Anything).Run(func(args mock.Arguments) {
+			deliveries = args.Get(0).([]*model.WebhookDelivery)
+		}).Return(nil)
+		message, err := model.NewOutboxMessage(testIDs, model.UserDeleted{UserID: "user-1", At: time.Now()})
+		assert.NoError(t, err)
+
+		// Act
+		err = publisher.Publish(context.Background(), &message)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, deliveries, 1)
+		assert.Equal(t, subscribed.ID, deliveries[0].SubscriptionID)
+		assert.Equal(t, message.ID, deliveries[0].EventID)
+	})
+}
+
+func TestWebhookDispatcher_Dispatch(t *testing.T) {
+	t.Run("成功: 配信して失敗した回数を数え直す", func(t *testing.T) {
+		// Arrange
+		mockSubscriptionRepo := new(MockWebhookSubscriptionRepository)
+		mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+		sender := &stubWebhookSender{}
+		dispatcher := NewWebhookDispatcher(mockSubscriptionRepo, mockDeliveryRepo, sender, testIDs, webhookDispatcherConfig)
+		subscription := newTestWebhookSubscription(t, model.EventTypeUserDeleted)
+		delivery := &model.WebhookDelivery{ID: "delivery-1", SubscriptionID: subscription.ID, Status: model.WebhookDeliveryPending}
+		mockDeliveryRepo.On("FindDue", mock.AnythingOfType("time.Time"), 10).Return([]*model.WebhookDelivery{delivery}, nil)
+		mockSubscriptionRepo.On("FindByID", subscription.ID).Return(subscription, nil)
+		mockDeliveryRepo.On("CreateAttempt", mock.AnythingOfType("*model.WebhookDeliveryAttempt")).Return(nil)
+		mockDeliveryRepo.On("Update", delivery).Return(nil)
+		mockSubscriptionRepo.On("ResetFailures", subscription.ID).Return(nil)
+
+		// Act
+		delivered, err := dispatcher.Dispatch(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 1, delivered)
+		assert.Equal(t, model.WebhookDeliverySucceeded, delivery.Status)
+		assert.Equal(t, 200, delivery.LastStatusCode)
+		mockSubscriptionRepo.AssertExpectations(t)
+		mockDeliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("成功: 失敗した配信は間隔を倍にしながら再送し、上限の回数で諦める", func(t *testing.T) {
+		// Arrange
+		mockSubscriptionRepo := new(MockWebhookSubscriptionRepository)
+		mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+		sender := &stubWebhookSender{statuses: map[string]int{"delivery-1": 500, "delivery-2": 500}}
+		dispatcher := NewWebhookDispatcher(mockSubscriptionRepo, mockDeliveryRepo, sender, testIDs, webhookDispatcherConfig)
+		subscription := newTestWebhookSubscription(t, model.EventTypeUserDeleted)
+		retrying := &model.WebhookDelivery{ID: "delivery-1", SubscriptionID: subscription.ID, Status: model.WebhookDeliveryPending, Attempts: 1}
+		exhausted := &model.WebhookDelivery{ID: "delivery-2", SubscriptionID: subscription.ID, Status: model.WebhookDeliveryPending, Attempts: 2}
+		mockDeliveryRepo.On("FindDue", mock.AnythingOfType("time.Time"), 10).Return([]*model.WebhookDelivery{retrying, exhausted}, nil)
+		mockSubscriptionRepo.On("FindByID", subscription.ID).Return(subscription, nil).Once()
+		mockDeliveryRepo.On("CreateAttempt", mock.AnythingOfType("*model.WebhookDeliveryAttempt")).Return(nil)
+		mockDeliveryRepo.On("Update", mock.AnythingOfType("*model.WebhookDelivery")).Return(nil)
+		mockSubscriptionRepo.On("RecordFailure", subscription.ID, 5, mock.AnythingOfType("time.Time")).Return(false, nil)
+
+		// Act
+		delivered, err := dispatcher.Dispatch(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Zero(t, delivered)
+		assert.Equal(t, model.WebhookDeliveryPending, retrying.Status)
+		assert.WithinDuration(t, time.Now().Add(2*time.Second), retrying.NextAttemptAt, time.Second)
+		assert.Equal(t, 500, retrying.LastStatusCode)
+		assert.Equal(t, model.WebhookDeliveryFailed, exhausted.Status)
+		mockSubscriptionRepo.AssertNumberOfCalls(t, "RecordFailure", 2)
+	})
+
+	t.Run("成功: 購読先の配信を止めたら、残りの配信を送らない", func(t *testing.T) {
+		// Arrange
+		mockSubscriptionRepo := new(MockWebhookSubscriptionRepository)
+		mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+		sender := &stubWebhookSender{statuses: map[string]int{"delivery-1": 410}}
+		dispatcher := NewWebhookDispatcher(mockSubscriptionRepo, mockDeliveryRepo, sender, testIDs, webhookDispatcherConfig)
+		subscription := newTestWebhookSubscription(t, model.EventTypeUserDeleted)
+		deliveries := []*model.WebhookDelivery{
+			{ID: "delivery-1", SubscriptionID: subscription.ID, Status: model.WebhookDeliveryPending},
+			{ID: "delivery-2", SubscriptionID: subscription.ID, Status: model.WebhookDeliveryPending},
+		}
+		mockDeliveryRepo.On("FindDue", mock.AnythingOfType("time.Time"), 10).Return(deliveries, nil)
+		mockSubscriptionRepo.On("FindByID", subscription.ID).Return(subscription, nil)
+		mockDeliveryRepo.On("CreateAttempt", mock.AnythingOfType("*model.WebhookDeliveryAttempt")).Return(nil)
+		mockDeliveryRepo.On("Update", mock.AnythingOfType("*model.WebhookDelivery")).Return(nil)
+		mockSubscriptionRepo.On("RecordFailure", subscription.ID, 5, mock.AnythingOfType("time.Time")).Return(true, nil)
+
+		// Act
+		_, err := dispatcher.Dispatch(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"delivery-1"}, sender.sent)
+		assert.False(t, subscription.Active)
+	})
+
+	t.Run("成功: 取得した後に削除された購読先への配信は送らない", func(t *testing.T) {
+		// Arrange
+		mockSubscriptionRepo := new(MockWebhookSubscriptionRepository)
+		mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+		sender := &stubWebhookSender{}
+		dispatcher := NewWebhookDispatcher(mockSubscriptionRepo, mockDeliveryRepo, sender, testIDs, webhookDispatcherConfig)
+		delivery := &model.WebhookDelivery{ID: "delivery-1", SubscriptionID: "deleted", Status: model.WebhookDeliveryPending}
+		mockDeliveryRepo.On("FindDue", mock.AnythingOfType("time.Time"), 10).Return([]*model.WebhookDelivery{delivery}, nil)
+		mockSubscriptionRepo.On("FindByID", "deleted").Return(nil, model.NewNotFoundError("Webhookが見つかりません", nil))
+
+		// Act
+		delivered, err := dispatcher.Dispatch(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Zero(t, delivered)
+		assert.Empty(t, sender.sent)
+	})
+
+	t.Run("失敗: 配信を取得できない", func(t *testing.T) {
+		// Arrange
+		mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+		dispatcher := NewWebhookDispatcher(new(MockWebhookSubscriptionRepository), mockDeliveryRepo, &stubWebhookSender{}, testIDs, webhookDispatcherConfig)
+		mockDeliveryRepo.On("FindDue", mock.AnythingOfType("time.Time"), 10).Return(nil, errors.New("database error"))
+
+		// Act
+		delivered, err := dispatcher.Dispatch(context.Background())
+
+		// Assert
+		assert.EqualError(t, err, "database error")
+		assert.Zero(t, delivered)
+	})
+}
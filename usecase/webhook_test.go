@@ -0,0 +1,263 @@
+package usecase
+
+import (
+	"api-sample-with-echo-ddd/domain/model"
+	"api-sample-with-echo-ddd/domain/repository"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWebhookSubscriptionRepository is a mock implementation of WebhookSubscriptionRepository
+type MockWebhookSubscriptionRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookSubscriptionRepository) Create(ctx context.Context, subscription *model.WebhookSubscription) error {
+	args := m.Called(subscription)
+	return args.Error(0)
+}
+
+func (m *MockWebhookSubscriptionRepository) FindByID(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookSubscriptionRepository) FindAll(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookSubscriptionRepository) FindActive(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookSubscriptionRepository) Update(ctx context.Context, subscription *model.WebhookSubscription) error {
+	args := m.Called(subscription)
+	return args.Error(0)
+}
+
+func (m *MockWebhookSubscriptionRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookSubscriptionRepository) ResetFailures(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookSubscriptionRepository) RecordFailure(ctx context.Context, id string, threshold int, at time.Time) (bool, error) {
+	args := m.Called(id, threshold, at)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockWebhookDeliveryRepository is a mock implementation of WebhookDeliveryRepository
+type MockWebhookDeliveryRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookDeliveryRepository) CreateAll(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	args := m.Called(deliveries)
+	return args.Error(0)
+}
+
+func (m *MockWebhookDeliveryRepository) FindByID(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDeliveryRepository) FindAll(ctx context.Context, query repository.WebhookDeliveryQuery) (*repository.WebhookDeliveryPage, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.WebhookDeliveryPage), args.Error(1)
+}
+
+func (m *MockWebhookDeliveryRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	args := m.Called(now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDeliveryRepository) Update(ctx context.Context, delivery *model.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookDeliveryRepository) CreateAttempt(ctx context.Context, attempt *model.WebhookDeliveryAttempt) error {
+	args := m.Called(attempt)
+	return args.Error(0)
+}
+
+func (m *MockWebhookDeliveryRepository) FindAttempts(ctx context.Context, deliveryID string) ([]*model.WebhookDeliveryAttempt, error) {
+	args := m.Called(deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.WebhookDeliveryAttempt), args.Error(1)
+}
+
+func newTestWebhookSubscription(t *testing.T, eventTypes ...string) *model.WebhookSubscription {
+	subscription, err := model.NewWebhookSubscription(testIDs, "https://example.com/hooks", eventTypes, "")
+	assert.NoError(t, err)
+	return &subscription
+}
+
+func TestWebhookUsecase_Create(t *testing.T) {
+	t.Run("成功: 秘密鍵を生成して保存する", func(t *testing.T) {
+		// Arrange
+		mockSubscriptionRepo := new(MockWebhookSubscriptionRepository)
+		usecase := NewWebhookUsecase(mockSubscriptionRepo, new(MockWebhookDeliveryRepository), testIDs)
+		mockSubscriptionRepo.On("Create", mock.AnythingOfType("*model.WebhookSubscription")).Return(nil)
+
+		// Act
+		subscription, err := usecase.Create(context.Background(), "https://example.com/hooks", []string{model.EventTypeUserRegistered}, "partner")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NotEmpty(t, subscription.Secret)
+		mockSubscriptionRepo.AssertExpectations(t)
+	})
+
+	t.Run("失敗: 入力が不正な場合は保存しない", func(t *testing.T) {
+		// Arrange
+		mockSubscriptionRepo := new(MockWebhookSubscriptionRepository)
+		usecase := NewWebhookUsecase(mockSubscriptionRepo, new(MockWebhookDeliveryRepository), testIDs)
+
+		// Act
+		_, err := usecase.Create(context.Background(), "not a url", []string{model.EventTypeUserRegistered}, "")
+
+		// Assert
+		assert.ErrorIs(t, err, model.ErrValidation)
+		mockSubscriptionRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestWebhookUsecase_Update(t *testing.T) {
+	t.Run("成功: 止まった配信を再開し、失敗した回数を数え直す", func(t *testing.T) {
+		// Arrange
+		mockSubscriptionRepo := new(MockWebhookSubscriptionRepository)
+		usecase := NewWebhookUsecase(mockSubscriptionRepo, new(MockWebhookDeliveryRepository), testIDs)
+		subscription := newTestWebhookSubscription(t, model.EventTypeUserRegistered)
+		subscription.ConsecutiveFailures = 20
+		subscription.Disable(time.Now())
+		mockSubscriptionRepo.On("FindByID", subscription.ID).Return(subscription, nil)
+		mockSubscriptionRepo.On("Update", subscription).Return(nil)
+		active := true
+
+		// Act
+		updated, err := usecase.Update(context.Background(), subscription.ID, "https://example.com/v2/hooks", []string{model.EventTypeUserDeleted}, "", &active)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, updated.Active)
+		assert.Zero(t, updated.ConsecutiveFailures)
+		assert.Equal(t, "https://example.com/v2/hooks", updated.URL)
+		assert.Equal(t, []string{model.EventTypeUserDeleted}, updated.EventTypes)
+	})
+
+	t.Run("成功: activeを省略した場合は配信の状態を変えない", func(t *testing.T) {
+		// Arrange
+		mockSubscriptionRepo := new(MockWebhookSubscriptionRepository)
+		usecase := NewWebhookUsecase(mockSubscriptionRepo, new(MockWebhookDeliveryRepository), testIDs)
+		subscription := newTestWebhookSubscription(t, model.EventTypeUserRegistered)
+		subscription.Disable(time.Now())
+		mockSubscriptionRepo.On("FindByID", subscription.ID).Return(subscription, nil)
+		mockSubscriptionRepo.On("Update", subscription).Return(nil)
+
+		// Act
+		updated, err := usecase.Update(context.Background(), subscription.ID, subscription.URL, subscription.EventTypes, "renamed", nil)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.False(t, updated.Active)
+		assert.Equal(t, "renamed", updated.Description)
+	})
+}
+
+func TestWebhookUsecase_Replay(t *testing.T) {
+	t.Run("成功: 配信し終えた配信を配信待ちに戻す", func(t *testing.T) {
+		// Arrange
+		mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+		usecase := NewWebhookUsecase(new(MockWebhookSubscriptionRepository), mockDeliveryRepo, testIDs)
+		delivery := &model.WebhookDelivery{ID: "delivery-1", SubscriptionID: "subscription-1", Status: model.WebhookDeliveryFailed, Attempts: 8}
+		mockDeliveryRepo.On("FindByID", "delivery-1").Return(delivery, nil)
+		mockDeliveryRepo.On("Update", delivery).Return(nil)
+
+		// Act
+		replayed, err := usecase.Replay(context.Background(), "subscription-1", "delivery-1")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, model.WebhookDeliveryPending, replayed.Status)
+		assert.Zero(t, replayed.Attempts)
+		mockDeliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("失敗: 別の購読先への配信", func(t *testing.T) {
+		// Arrange
+		mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+		usecase := NewWebhookUsecase(new(MockWebhookSubscriptionRepository), mockDeliveryRepo, testIDs)
+		delivery := &model.WebhookDelivery{ID: "delivery-1", SubscriptionID: "subscription-2", Status: model.WebhookDeliveryFailed}
+		mockDeliveryRepo.On("FindByID", "delivery-1").Return(delivery, nil)
+
+		// Act
+		_, err := usecase.Replay(context.Background(), "subscription-1", "delivery-1")
+
+		// Assert
+		assert.ErrorIs(t, err, model.ErrNotFound)
+		mockDeliveryRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("失敗: 配信待ちの配信", func(t *testing.T) {
+		// Arrange
+		mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+		usecase := NewWebhookUsecase(new(MockWebhookSubscriptionRepository), mockDeliveryRepo, testIDs)
+		delivery := &model.WebhookDelivery{ID: "delivery-1", SubscriptionID: "subscription-1", Status: model.WebhookDeliveryPending}
+		mockDeliveryRepo.On("FindByID", "delivery-1").Return(delivery, nil)
+
+		// Act
+		_, err := usecase.Replay(context.Background(), "subscription-1", "delivery-1")
+
+		// Assert
+		assert.ErrorIs(t, err, model.ErrConflict)
+	})
+}
+
+func TestWebhookUsecase_FindDeliveries(t *testing.T) {
+	t.Run("失敗: 存在しない購読先", func(t *testing.T) {
+		// Arrange
+		mockSubscriptionRepo := new(MockWebhookSubscriptionRepository)
+		mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+		usecase := NewWebhookUsecase(mockSubscriptionRepo, mockDeliveryRepo, testIDs)
+		mockSubscriptionRepo.On("FindByID", "missing").Return(nil, model.NewNotFoundError("Webhookが見つかりません", errors.New("record not found")))
+
+		// Act
+		_, err := usecase.FindDeliveries(context.Background(), repository.WebhookDeliveryQuery{SubscriptionID: "missing"})
+
+		// Assert
+		assert.ErrorIs(t, err, model.ErrNotFound)
+		mockDeliveryRepo.AssertNotCalled(t, "FindAll", mock.Anything)
+	})
+}